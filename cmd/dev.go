@@ -0,0 +1,25 @@
+package cmd
+
+import (
+
+    "github.com/helviojunior/intelparser/internal/ascii"
+    "github.com/spf13/cobra"
+)
+
+var devCmd = &cobra.Command{
+    Use:   "dev",
+    Short: "Development and testing tools",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# dev
+
+Development and testing tools.
+`)),
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        // Explicitly call the parent's PersistentPreRunE, overridden here
+        return rootCmd.PersistentPreRunE(cmd, args)
+    },
+}
+
+func init() {
+    rootCmd.AddCommand(devCmd)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+    "errors"
+    "path/filepath"
+
+    "github.com/helviojunior/intelparser/internal/ascii"
+    "github.com/helviojunior/intelparser/internal/tools"
+    "github.com/helviojunior/intelparser/pkg/ixmock"
+    "github.com/helviojunior/intelparser/pkg/log"
+    "github.com/spf13/cobra"
+)
+
+var devMockIXCmdFlags = struct {
+    fixtures string
+    listen   string
+    apiKey   string
+    credits  int
+}{}
+var devMockIXCmd = &cobra.Command{
+    Use:   "mock-intelx",
+    Short: "Serve a local mock of the IntelX API from a fixtures folder",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# dev mock-intelx
+
+Serve a local mock of the IntelX API, so the downloaders and the parsers can be
+exercised end to end without network access or API credits. Point the IntelX
+commands to it with --api-url.
+
+Every file of the fixtures folder is an item. The search matches the term at the
+file name or data (case insensitive) and honors the buckets, media, dates, sort
+and max results filters. The export ZIP has the Info.csv and the items data, and
+the phonebook lists the emails, domains and URLs found at the matched items.
+
+The optional items.json of the fixtures folder sets the items metadata:
+
+    [
+      {
+        "file": "combo/list.txt",
+        "name": "Combolist 2024",
+        "date": "2024-05-01 10:00:00",
+        "bucket": "leaks.private",
+        "media": 24,
+        "xscore": 80
+      }
+    ]
+
+Any API key is accepted unless --api-key is set. Each path has --credits credits,
+after that the requests fail with HTTP 402 (daily limits exceeded).
+`)),
+    Example: `
+   - intelparser dev mock-intelx --fixtures ~/fixtures
+   - intelparser dev mock-intelx --fixtures ~/fixtures --listen 127.0.0.1:9090 --credits 5
+   - intelparser download intelx --api-url http://127.0.0.1:8080/ --api-key 00000000-0000-0000-0000-000000000000 --term sec4us.com.br
+`,
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if devMockIXCmdFlags.fixtures == "" {
+            return errors.New("a fixtures folder must be specified")
+        }
+
+        if devMockIXCmdFlags.fixtures, err = filepath.Abs(devMockIXCmdFlags.fixtures); err != nil {
+            return err
+        }
+
+        if ft, err := tools.FileType(devMockIXCmdFlags.fixtures); err != nil || ft != "directory" {
+            return errors.New("fixtures folder not found: " + devMockIXCmdFlags.fixtures)
+        }
+
+        return nil
+    },
+    RunE: func(cmd *cobra.Command, args []string) error {
+        srv, err := ixmock.NewServer(devMockIXCmdFlags.fixtures, devMockIXCmdFlags.credits)
+        if err != nil {
+            return err
+        }
+        srv.APIKey = devMockIXCmdFlags.apiKey
+
+        log.Info("IntelX mock listening", "url", "http://" + devMockIXCmdFlags.listen + "/", "items", srv.Items(), "fixtures", devMockIXCmdFlags.fixtures)
+
+        return srv.ListenAndServe(devMockIXCmdFlags.listen)
+    },
+}
+
+func init() {
+    devCmd.AddCommand(devMockIXCmd)
+
+    devMockIXCmd.Flags().StringVar(&devMockIXCmdFlags.fixtures, "fixtures", "", "Folder with the items to serve")
+    devMockIXCmd.Flags().StringVar(&devMockIXCmdFlags.listen, "listen", "127.0.0.1:8080", "Address to listen on")
+    devMockIXCmd.Flags().StringVar(&devMockIXCmdFlags.apiKey, "api-key", "", "Accepted API key. Default any key")
+    devMockIXCmd.Flags().IntVar(&devMockIXCmdFlags.credits, "credits", 1000, "Credits per API path")
+}
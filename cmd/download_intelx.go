@@ -5,8 +5,12 @@ import (
     "errors"
     "fmt"
     "time"
+    "net/url"
     "os"
-    "sync"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
 
     "github.com/gofrs/uuid"
     "github.com/helviojunior/intelparser/internal/ascii"
@@ -16,10 +20,39 @@ import (
     "github.com/helviojunior/intelparser/pkg/readers"
     resolver "github.com/helviojunior/gopathresolver"
     "github.com/spf13/cobra"
+    "github.com/spf13/pflag"
+    "golang.org/x/exp/maps"
 )
 
 var searchTerm string
 var ixApiKey string
+var ixApiURL string
+var ixConfigFile string
+var ixKeys = []downloaders.IntelXKey{}
+var ixKeyRing *downloaders.IntelXKeyRing
+var ixTermList = []string{}
+var dwnIXCmdFlags = struct {
+    dateFrom   string
+    dateTo     string
+    buckets    string
+    media      string
+    sort       string
+    maxResults int
+    sinceLast  bool
+    waitCredits bool
+    rotateKeys bool
+    perItem    bool
+    itemThreads int
+    itemRetries int
+    termThreads int
+    merge      bool
+
+    from       time.Time
+    to         time.Time
+    bucketList []string
+    mediaType  int
+    sortOrder  int
+}{}
 var dwnIXCmd = &cobra.Command{
     Use:   "intelx",
     Short: "Search and Download from IntelX.io",
@@ -35,6 +68,9 @@ or by setting the IXAPIKEY environment variable.
    - intelparser download intelx --term sec4us.com.br
    - intelparser download intelx --term "~/Desktop/term_list.txt"
    - intelparser download intelx --term sec4us.com.br --api-key 00000000-0000-0000-0000-000000000000
+   - intelparser download intelx --term sec4us.com.br --date-from 2024-01-01 --buckets leaks.private,pastes --max-results 500
+   - intelparser download intelx --term sec4us.com.br --media text --sort xscore-desc --max-results 100
+   - intelparser download intelx --term "~/Desktop/term_list.txt" --term-threads 5 --merge
 
    Terms types supported:
    * Email address
@@ -52,135 +88,352 @@ or by setting the IXAPIKEY environment variable.
    * IBAN
    `,
     PreRunE: func(cmd *cobra.Command, args []string) error {
-        var err error
-
-        if ixApiKey == "" {
-            eKey := os.Getenv("IXAPIKEY")
-            if eKey == "" {
-                return errors.New("IntelX API key not provided. You can specify it using the --api-key parameter in the command line or by setting the IXAPIKEY environment variable.")
-            }
-
-            ixApiKey = eKey
-        }
-        
-        _, err = uuid.FromString(ixApiKey)
-        if err != nil {
+        if err := checkIntelXFlags(); err != nil {
             return err
         }
 
-        if searchTerm == "" {
-            return errors.New("Search term not set")
+        if dwnIXCmdFlags.termThreads < 1 {
+            return errors.New("--term-threads must be at least 1")
         }
 
-        return nil
+        return checkIntelXCredits(downloaders.IntelXPathSearch, downloaders.IntelXPathExport)
     },
     Run: func(cmd *cobra.Command, args []string) {
-
-        termList := []string{}
-        termChan := make(chan string)
-        wg := sync.WaitGroup{}
-        status := &downloaders.IntelXDownloaderStatus{
-          TotalFiles    : 0,
-          Downloaded    : 0,
-          Duplicated    : 0,
-          TotalBytes    : 0,
-        }
-
-        // Check if search term is a file path
-        fileTerm, err := resolver.ResolveFullPath(searchTerm)
-        if err == nil {
-            
-          ft, err := tools.FileType(fileTerm)
-          if err == nil {
-              if ft != "file" {
-                 log.Error("Search term must be a single text or file path")
-                 os.Exit(2)
-              }
-
-              err = readers.ReadFileList(fileTerm, &termList)
-          }else{
-            termList = append(termList, searchTerm)
-          }
-        }else{
-          termList = append(termList, searchTerm)
+        threads := dwnIXCmdFlags.termThreads
+        if threads > len(ixTermList) {
+            threads = len(ixTermList)
         }
-
-        if len(termList) == 0 {
-            log.Error("Search term not set")
-            os.Exit(2)
+        if max := ixKeyRing.MaxConcurrentSearches(); max > 0 && threads > max {
+            log.Warn("Concurrent terms limited by the API keys", "term_threads", max)
+            threads = max
         }
 
-        go func() {
-            defer close(termChan)
-            for _, t := range termList {
-                termChan <- t
-            }
-        
-        }()
-
-        wg.Add(1)
-        go func() {
-          defer wg.Done()
-          for true {
-            term, ok := <-termChan
-            if !ok {
-              return
-            }
-
-            log.Infof("Quering term %s", term)
+        queue := newIntelXTermQueue(ixTermList, threads)
 
-            zipFile, err := resolver.ResolveFullPath(fmt.Sprintf("./ix_%s_%s.zip", tools.SafeFileName(term), startTime.Format("2006-01-02_15-04-05")))
+        if dwnIXCmdFlags.merge {
+            zipFile, err := resolver.ResolveFullPath(fmt.Sprintf("./ix_merged_%s.zip", startTime.Format("2006-01-02_15-04-05")))
             if err != nil {
                 log.Error("Error setting output file", "err", err)
                 os.Exit(2)
             }
 
-            dwn, err := downloaders.NewIntelXDownloader(term, ixApiKey, zipFile)
-            if err != nil {
-                log.Error("Error getting downloader instance", "err", err)
+            if queue.Merger, err = downloaders.NewIntelXMerger(zipFile); err != nil {
+                log.Error("Error getting merger instance", "err", err)
                 os.Exit(2)
             }
+        }
 
-            dwn.ProxyURL = downloadProxy
-
-            st := dwn.Run()
-            dwn.Close()
-
-            status.TotalFiles += st.TotalFiles
-            status.Duplicated += st.Duplicated
-            status.Downloaded += st.Downloaded
-            status.TotalBytes += st.TotalBytes
+        queue.Run()
 
-          }
-        }()
+        if queue.Merger != nil {
+            if err := queue.Merger.Close(); err != nil {
+                log.Error("Error merging the terms", "err", err)
+            }
+        }
 
-        wg.Wait()
+        if len(queue.Runs) > 1 {
+            queue.PrintTable()
+        }
 
+        status, finished := queue.Status()
         diff := time.Now().Sub(startTime)
         out := time.Time{}.Add(diff)
 
         st := "Download status\n"
         st += "     -> Elapsed time.....: %s\n"
+        st += "     -> Searched terms...: %s\n"
         st += "     -> Listed files.....: %s\n"
         st += "     -> Duplicated Files.: %s\n"
         st += "     -> Downloaded Files.: %s\n"
+        st += "     -> Failed Files.....: %s\n"
         st += "     -> Bytes downloaded.: %s\n"
 
         log.Infof(st, 
             out.Format("15:04:05"),
+            fmt.Sprintf("%s/%s", tools.FormatIntComma(finished), tools.FormatIntComma(len(queue.Runs))),
             tools.FormatIntComma(status.TotalFiles), 
             tools.FormatIntComma(status.Duplicated),
             tools.FormatIntComma(status.Downloaded),
+            tools.FormatIntComma(status.Failed),
             tools.Bytes(uint64(status.TotalBytes)),
         )
 
+        if queue.Merger != nil {
+            log.Info("Merged files", "unique", queue.Merger.Items(), "shared", status.Shared, "zip", queue.Merger.ZipFile)
+        }
+
     },
 }
 
 func init() {
     downloadCmd.AddCommand(dwnIXCmd)
 
-    dwnIXCmd.Flags().StringVar(&searchTerm, "term", "", "Search term (or filename with terms) to performs a search and queries the results.")
-    dwnIXCmd.Flags().StringVar(&ixApiKey, "api-key", "", "IntelX API Key. You can also provide API Key using Environment Variable 'IXAPIKEY'.")
-    
+    addIntelXFlags(dwnIXCmd.Flags())
+    dwnIXCmd.Flags().BoolVar(&dwnIXCmdFlags.sinceLast, "since-last", false, "Download only the items not downloaded at the previous runs of the term (state stored at ~/.intelparser.db)")
+    dwnIXCmd.Flags().IntVar(&dwnIXCmdFlags.termThreads, "term-threads", 1, "Number of terms of the term list downloaded at the same time (limited by the concurrent searches of the API keys)")
+    dwnIXCmd.Flags().BoolVar(&dwnIXCmdFlags.merge, "merge", false, "Merge all terms into one ZIP file, keeping once the items found by more than one term")
+}
+
+// intelxMediaNames returns the --media names sorted by the IntelX media number
+func intelxMediaNames() []string {
+    names := maps.Keys(downloaders.IntelXMedia)
+    sort.Slice(names, func(i, j int) bool {
+        return downloaders.IntelXMedia[names[i]] < downloaders.IntelXMedia[names[j]]
+    })
+    return names
+}
+
+// addIntelXFlags adds the IntelX search flags, shared by the commands downloading from IntelX
+func addIntelXFlags(flags *pflag.FlagSet) {
+    flags.StringVar(&searchTerm, "term", "", "Search term (or filename with terms) to performs a search and queries the results.")
+    flags.StringVar(&ixApiKey, "api-key", "", "IntelX API Key or comma-separated key names of the config file. You can also provide API Key using Environment Variable 'IXAPIKEY'.")
+    flags.StringVar(&ixApiURL, "api-url", "", "IntelX API URL. Default the endpoint of the key (e.g. https://2.intelx.io/ or https://free.intelx.io/)")
+    flags.StringVar(&ixConfigFile, "config", "", "Config file with the IntelX API keys (default ~/.intelparser.json)")
+    flags.BoolVar(&dwnIXCmdFlags.rotateKeys, "rotate-keys", false, "Rotate the terms across the API keys, skipping the keys without credits (default uses the first key)")
+
+    flags.StringVar(&dwnIXCmdFlags.dateFrom, "date-from", "", "Search only results from this date. (Format: yyyy-mm-dd)")
+    flags.StringVar(&dwnIXCmdFlags.dateTo, "date-to", "", "Search only results up to this date. (Format: yyyy-mm-dd)")
+    flags.StringVar(&dwnIXCmdFlags.buckets, "buckets", "", "Comma-separated buckets to search (e.g. leaks.private,leaks.public,pastes,darknet.tor). Default all buckets of the API key")
+    flags.StringVar(&dwnIXCmdFlags.media, "media", "all", "Media type to search (" + strings.Join(intelxMediaNames(), ", ") + ") or the IntelX media number")
+    flags.StringVar(&dwnIXCmdFlags.sort, "sort", "date-desc", "Sort order of the results (date-desc, date-asc, xscore-desc, xscore-asc, none). Only date sorts page over all results")
+    flags.IntVar(&dwnIXCmdFlags.maxResults, "max-results", 0, "Maximum number of results to download, 0 for all results (IntelX applies the limit per bucket)")
+    flags.BoolVar(&dwnIXCmdFlags.perItem, "per-item", false, "Download the items one by one (file/read) instead of the ZIP export, retrying each failed item")
+    flags.IntVar(&dwnIXCmdFlags.itemThreads, "item-threads", 3, "Number of concurrent item downloads with --per-item")
+    flags.IntVar(&dwnIXCmdFlags.itemRetries, "item-retries", 3, "Retries of each failed item download with --per-item")
+    flags.BoolVar(&dwnIXCmdFlags.waitCredits, "wait-credits", false, "Pause when the IntelX credits are exhausted, resuming when they are reset (default stops the term list)")
+}
+
+// checkIntelXCredits shows the API keys capabilities and checks there are credits to search the terms
+func checkIntelXCredits(paths ...string) error {
+    accounts := []*downloaders.IntelXAccount{}
+    for _, k := range ixKeys {
+        acc := downloaders.NewIntelXAccount(k.Key)
+        acc.Name = k.Name
+        acc.APIURL = k.URL
+        acc.ProxyURL = downloadProxy
+        acc.Wait = dwnIXCmdFlags.waitCredits
+        accounts = append(accounts, acc)
+
+        err := acc.ResolveURL()
+        if err == nil && acc.Info == nil {
+            err = acc.Refresh()
+        }
+        if err != nil {
+            log.Warn("Unable to get the IntelX API key info, the credits will not be checked", "key", acc.Name, "err", err)
+            continue
+        }
+
+        log.Info("IntelX API key", "key", acc.Name, "url", acc.APIURL)
+        acc.Print(paths...)
+
+        for _, b := range dwnIXCmdFlags.bucketList {
+            if !tools.SliceHasStr(acc.Info.Buckets, b) {
+                log.Warn("Bucket not allowed for the API key", "key", acc.Name, "bucket", b)
+            }
+        }
+    }
+
+    ixKeyRing = downloaders.NewIntelXKeyRing(accounts, dwnIXCmdFlags.rotateKeys)
+
+    // Each term needs at least one request of each path
+    if err := ixKeyRing.Check(len(ixTermList), paths...); err != nil {
+        if !dwnIXCmdFlags.waitCredits {
+            return errors.New(err.Error() + ". Use --wait-credits to pause until the credits are reset")
+        }
+        log.Warn("The credits are not enough for all terms, the searches will be paused", "err", err)
+    }
+
+    return nil
+}
+
+// loadIntelXKeys selects the API keys: --api-key (keys or key names of the config file),
+// IXAPIKEY environment variable or the keys of the config file
+func loadIntelXKeys() error {
+    cfgFile := ixConfigFile
+    if cfgFile == "" {
+        cfgFile = filepath.Join(opts.Writer.UserPath, ".intelparser.json")
+    }
+
+    cfg, err := downloaders.LoadIntelXConfig(cfgFile)
+    if err != nil {
+        return err
+    }
+
+    value := ixApiKey
+    if value == "" {
+        value = os.Getenv("IXAPIKEY")
+    }
+
+    ixKeys = []downloaders.IntelXKey{}
+    if value == "" {
+        ixKeys = append(ixKeys, cfg.Keys...)
+    }else{
+        for i, v := range strings.Split(value, ",") {
+            v = strings.Trim(v, " ")
+            if v == "" {
+                continue
+            }
+
+            if _, err := uuid.FromString(v); err == nil {
+                ixKeys = append(ixKeys, downloaders.IntelXKey{Name: fmt.Sprintf("key%d", i + 1), Key: v})
+            }else if k := cfg.Key(v); k != nil {
+                ixKeys = append(ixKeys, *k)
+            }else{
+                return errors.New("invalid API key or key name not found at " + cfgFile + ": " + v)
+            }
+        }
+    }
+
+    if len(ixKeys) == 0 {
+        return errors.New("IntelX API key not provided. You can specify it using the --api-key parameter in the command line, by setting the IXAPIKEY environment variable or at the config file " + cfgFile)
+    }
+
+    if !dwnIXCmdFlags.rotateKeys && len(ixKeys) > 1 {
+        log.Debug("Using the first API key, use --rotate-keys to use all keys", "key", ixKeys[0].Name)
+        ixKeys = ixKeys[:1]
+    }
+
+    if ixApiURL != "" {
+        u, err := url.Parse(ixApiURL)
+        if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+            return errors.New("invalid --api-url: " + ixApiURL)
+        }
+
+        for i := range ixKeys {
+            ixKeys[i].URL = ixApiURL
+        }
+    }
+
+    return nil
+}
+
+// checkIntelXFlags checks the IntelX search flags
+func checkIntelXFlags() error {
+    var err error
+
+    if err = loadIntelXKeys(); err != nil {
+        return err
+    }
+
+    if searchTerm == "" {
+        return errors.New("Search term not set")
+    }
+
+    if ixTermList, err = intelxTermList(); err != nil {
+        return err
+    }
+
+    if dwnIXCmdFlags.dateFrom != "" {
+        if dwnIXCmdFlags.from, err = time.Parse("2006-01-02", dwnIXCmdFlags.dateFrom); err != nil {
+            return errors.New("invalid --date-from (Format: yyyy-mm-dd)")
+        }
+    }
+
+    if dwnIXCmdFlags.dateTo != "" {
+        if dwnIXCmdFlags.to, err = time.Parse("2006-01-02", dwnIXCmdFlags.dateTo); err != nil {
+            return errors.New("invalid --date-to (Format: yyyy-mm-dd)")
+        }
+        // The last day is included
+        dwnIXCmdFlags.to = dwnIXCmdFlags.to.Add(24 * time.Hour - time.Second)
+
+        if !dwnIXCmdFlags.from.IsZero() && dwnIXCmdFlags.to.Before(dwnIXCmdFlags.from) {
+            return errors.New("--date-to must be after --date-from")
+        }
+    }
+
+    dwnIXCmdFlags.bucketList = []string{}
+    for _, b := range strings.Split(dwnIXCmdFlags.buckets, ",") {
+        b = strings.ToLower(strings.Trim(b, " "))
+        if b != "" {
+            dwnIXCmdFlags.bucketList = append(dwnIXCmdFlags.bucketList, b)
+        }
+    }
+
+    media, ok := downloaders.IntelXMedia[strings.ToLower(dwnIXCmdFlags.media)]
+    if !ok {
+        if media, err = strconv.Atoi(dwnIXCmdFlags.media); err != nil || media < 0 {
+            return errors.New("invalid --media: " + dwnIXCmdFlags.media)
+        }
+    }
+    dwnIXCmdFlags.mediaType = media
+
+    if dwnIXCmdFlags.sortOrder, ok = downloaders.IntelXSort[strings.ToLower(dwnIXCmdFlags.sort)]; !ok {
+        return errors.New("invalid --sort: " + dwnIXCmdFlags.sort)
+    }
+
+    if dwnIXCmdFlags.maxResults < 0 {
+        return errors.New("--max-results must be 0 (all results) or greater")
+    }
+
+    if dwnIXCmdFlags.itemThreads < 1 {
+        return errors.New("--item-threads must be at least 1")
+    }
+
+    if dwnIXCmdFlags.itemRetries < 0 {
+        return errors.New("--item-retries must be 0 or greater")
+    }
+    return nil
+}
+
+// intelxTermList returns the --term or the terms of the --term file
+func intelxTermList() ([]string, error) {
+    termList := []string{}
+
+    // Check if search term is a file path
+    fileTerm, err := resolver.ResolveFullPath(searchTerm)
+    if err == nil {
+        ft, err := tools.FileType(fileTerm)
+        if err == nil {
+            if ft != "file" {
+                return nil, errors.New("Search term must be a single text or file path")
+            }
+
+            if err = readers.ReadFileList(fileTerm, &termList); err != nil {
+                return nil, err
+            }
+        }else{
+            termList = append(termList, searchTerm)
+        }
+    }else{
+        termList = append(termList, searchTerm)
+    }
+
+    if len(termList) == 0 {
+        return nil, errors.New("Search term not set")
+    }
+
+    return termList, nil
+}
+
+// newIntelXDownloader returns a downloader of the term with the IntelX search flags and the API key of the account
+func newIntelXDownloader(term string, zipFile string, acc *downloaders.IntelXAccount) (*downloaders.IntelXDownloader, error) {
+    dwn, err := downloaders.NewIntelXDownloader(term, acc.APIKey(), zipFile)
+    if err != nil {
+        return nil, err
+    }
+
+    dwn.ProxyURL = downloadProxy
+    dwn.APIURL = acc.APIURL
+    dwn.Buckets = dwnIXCmdFlags.bucketList
+    dwn.Media = dwnIXCmdFlags.mediaType
+    dwn.Sort = dwnIXCmdFlags.sortOrder
+    dwn.MaxResults = dwnIXCmdFlags.maxResults
+    if !dwnIXCmdFlags.from.IsZero() {
+        dwn.DateFrom = dwnIXCmdFlags.from
+    }
+    dwn.DateTo = dwnIXCmdFlags.to
+
+    dwn.Account = acc
+    dwn.PerItem = dwnIXCmdFlags.perItem
+    if dwn.PerItem {
+        dwn.Threads = dwnIXCmdFlags.itemThreads
+    }
+    dwn.Retries = dwnIXCmdFlags.itemRetries
+    dwn.SinceLast = dwnIXCmdFlags.sinceLast
+    dwn.StateDbURI = opts.Writer.GlobalDbURI
+    if dwn.StateDbURI == "" {
+        dwn.StateDbURI = "sqlite:///" + opts.Writer.UserPath + "/.intelparser.db"
+    }
+
+    return dwn, nil
 }
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+    "errors"
+    "strings"
+
+    "github.com/helviojunior/intelparser/internal/ascii"
+    "github.com/helviojunior/intelparser/internal/tools"
+    "github.com/helviojunior/intelparser/pkg/downloaders"
+    "github.com/helviojunior/intelparser/pkg/log"
+    "github.com/helviojunior/intelparser/pkg/runner"
+    "github.com/spf13/cobra"
+)
+
+var dwnPBCmdFlags = struct {
+    target     string
+    targetType int
+}{}
+var dwnPBCmd = &cobra.Command{
+    Use:   "intelx-phonebook",
+    Short: "List the emails, domains and URLs of a term from IntelX.io phonebook",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# download intelx-phonebook
+
+Search the IntelX.io phonebook, listing every email, domain and URL known for
+a term (e.g. a domain). The selectors are written as e-mails and URLs (the
+domains as URLs) through the writers, as a file named phonebook_[term].txt.
+
+An IntelX API key must be provided. You can specify it using the --api-key parameter in the command line
+or by setting the IXAPIKEY environment variable.
+`)),
+    Example: `
+   - intelparser download intelx-phonebook --term sec4us.com.br
+   - intelparser download intelx-phonebook --term sec4us.com.br --target emails --write-csv
+   - intelparser download intelx-phonebook --term "~/Desktop/term_list.txt" --write-elastic --write-elasticsearch-uri "http://127.0.0.1:9200/intelparser"
+`,
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var ok bool
+
+        if err := checkIntelXFlags(); err != nil {
+            return err
+        }
+
+        if dwnPBCmdFlags.targetType, ok = downloaders.IntelXPhonebookTarget[strings.ToLower(dwnPBCmdFlags.target)]; !ok {
+            return errors.New("invalid --target: " + dwnPBCmdFlags.target)
+        }
+
+        if err := checkIntelXCredits(downloaders.IntelXPathPhonebook); err != nil {
+            return err
+        }
+
+        return setupParser()
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+        status := runner.Status{}
+        for i, term := range ixTermList {
+            acc, err := ixKeyRing.Acquire(downloaders.IntelXPathPhonebook)
+            if err != nil {
+                log.Error("Stopping the term list", "err", err, "not_searched", len(ixTermList) - i)
+                break
+            }
+
+            pb := downloaders.NewIntelXPhonebook(term, acc.APIKey())
+            pb.ProxyURL = downloadProxy
+            pb.APIURL = acc.APIURL
+            pb.Account = acc
+            pb.Buckets = dwnIXCmdFlags.bucketList
+            pb.Media = dwnIXCmdFlags.mediaType
+            pb.Sort = dwnIXCmdFlags.sortOrder
+            pb.Target = dwnPBCmdFlags.targetType
+            pb.DateFrom = dwnIXCmdFlags.from
+            pb.DateTo = dwnIXCmdFlags.to
+            if dwnIXCmdFlags.maxResults > 0 {
+                pb.MaxResults = dwnIXCmdFlags.maxResults
+            }
+
+            selectors, err := pb.Search()
+            if err != nil {
+                status.Error += 1
+                continue
+            }
+
+            if len(selectors) == 0 {
+                log.Warn("No result found", "term", term)
+                continue
+            }
+
+            file := pb.ToFile(selectors)
+            status.Parsed += 1
+            status.Email += len(file.Emails)
+            status.Url += len(file.URLs)
+
+            for _, w := range scanWriters {
+                if err := w.Write(file); err != nil {
+                    log.Error("Error writing phonebook results", "term", term, "err", err)
+                }
+            }
+
+            log.Info("Phonebook saved", "term", term, "selectors", len(selectors), "emails", len(file.Emails), "urls", len(file.URLs))
+        }
+
+        printParseStatistics(status)
+
+        tools.RemoveFolder(tempFolder)
+    },
+}
+
+func init() {
+    downloadCmd.AddCommand(dwnPBCmd)
+
+    addIntelXFlags(dwnPBCmd.Flags())
+    addParserFlags(dwnPBCmd.Flags())
+    dwnPBCmd.Flags().StringVar(&dwnPBCmdFlags.target, "target", "all", "Selector types to list (all, domains, emails, urls)")
+}
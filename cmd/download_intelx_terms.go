@@ -0,0 +1,251 @@
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "strings"
+    "sync"
+    "text/tabwriter"
+    "time"
+
+    "github.com/helviojunior/intelparser/internal/ascii"
+    "github.com/helviojunior/intelparser/internal/tools"
+    "github.com/helviojunior/intelparser/pkg/downloaders"
+    "github.com/helviojunior/intelparser/pkg/log"
+    resolver "github.com/helviojunior/gopathresolver"
+    "golang.org/x/term"
+)
+
+// ixTermRun is the download of a term of the list
+type ixTermRun struct {
+    Index   int // Position in the list, starting at 1
+    Term    string
+    Key     string
+    Step    string // Queued, Running, Done, Failed or Not searched
+    ZipFile string
+
+    downloader *downloaders.IntelXDownloader
+}
+
+// Status returns a copy of the term download status, empty while queued
+func (r *ixTermRun) Status() downloaders.IntelXDownloaderStatus {
+    if r.downloader == nil {
+        return downloaders.IntelXDownloaderStatus{}
+    }
+    return r.downloader.Status()
+}
+
+// ixTermQueue downloads the terms of the list, up to Threads terms at the same time
+type ixTermQueue struct {
+    Runs    []*ixTermRun
+    Threads int
+
+    // Merger receives the files of all terms, instead of a ZIP file per term
+    Merger *downloaders.IntelXMerger
+
+    stopped bool
+    mutex   sync.Mutex
+}
+
+func newIntelXTermQueue(terms []string, threads int) *ixTermQueue {
+    q := &ixTermQueue{
+        Runs:    []*ixTermRun{},
+        Threads: threads,
+        mutex:   sync.Mutex{},
+    }
+    for i, t := range terms {
+        q.Runs = append(q.Runs, &ixTermRun{Index: i + 1, Term: t, Step: "Queued"})
+    }
+
+    return q
+}
+
+// Run downloads all terms, stopping the list when the API keys have no credits left
+func (q *ixTermQueue) Run() {
+    runs := make(chan *ixTermRun)
+    wg := sync.WaitGroup{}
+    done := make(chan bool)
+
+    go func() {
+        defer close(runs)
+        for _, r := range q.Runs {
+            runs <- r
+        }
+    }()
+
+    // With several terms at the same time, their progress is shown together
+    printer := sync.WaitGroup{}
+    if q.Threads > 1 {
+        printer.Add(1)
+        go func() {
+            defer printer.Done()
+            q.printProgress(done)
+        }()
+    }
+
+    for w := 0; w < q.Threads; w++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for r := range runs {
+                q.download(r)
+            }
+        }()
+    }
+
+    wg.Wait()
+    close(done)
+    printer.Wait()
+
+    notSearched := 0
+    for _, r := range q.Runs {
+        if r.Step == "Queued" {
+            r.Step = "Not searched"
+            notSearched++
+        }
+    }
+    if notSearched > 0 {
+        log.Warn("Terms not searched", "qty", notSearched)
+    }
+}
+
+func (q *ixTermQueue) download(run *ixTermRun) {
+    q.mutex.Lock()
+    stopped := q.stopped
+    q.mutex.Unlock()
+    if stopped {
+        return
+    }
+
+    acc, err := ixKeyRing.Acquire(downloaders.IntelXPathSearch, downloaders.IntelXPathExport)
+    if err != nil {
+        q.mutex.Lock()
+        if !q.stopped {
+            log.Error("Stopping the term list", "err", err)
+        }
+        q.stopped = true
+        q.mutex.Unlock()
+        return
+    }
+
+    log.Infof("Quering term %s", run.Term)
+
+    // The index avoids the same name for terms differing only in unsafe chars
+    name := fmt.Sprintf("ix_%s_%s", tools.SafeFileName(run.Term), startTime.Format("2006-01-02_15-04-05"))
+    if len(q.Runs) > 1 {
+        name = fmt.Sprintf("%s_%d", name, run.Index)
+    }
+    zipFile, err := resolver.ResolveFullPath("./" + name + ".zip")
+    if err != nil {
+        log.Error("Error setting output file", "term", run.Term, "err", err)
+        q.setStep(run, "Failed")
+        return
+    }
+
+    dwn, err := newIntelXDownloader(run.Term, zipFile, acc)
+    if err != nil {
+        log.Error("Error getting downloader instance", "term", run.Term, "err", err)
+        q.setStep(run, "Failed")
+        return
+    }
+    dwn.Merger = q.Merger
+    dwn.HideStatus = q.Threads > 1
+
+    q.mutex.Lock()
+    run.Key = acc.Name
+    run.Step = "Running"
+    run.downloader = dwn
+    q.mutex.Unlock()
+
+    st := dwn.Run()
+    dwn.Close()
+
+    step := "Done"
+    if st.Err != nil {
+        step = "Failed"
+    } else if q.Merger == nil && tools.FileExists(zipFile) {
+        run.ZipFile = zipFile
+    }
+    q.setStep(run, step)
+}
+
+func (q *ixTermQueue) setStep(run *ixTermRun, step string) {
+    q.mutex.Lock()
+    defer q.mutex.Unlock()
+
+    run.Step = step
+}
+
+// Status returns the counters of all terms and how many terms are finished
+func (q *ixTermQueue) Status() (status *downloaders.IntelXDownloaderStatus, finished int) {
+    q.mutex.Lock()
+    defer q.mutex.Unlock()
+
+    status = &downloaders.IntelXDownloaderStatus{}
+    for _, r := range q.Runs {
+        st := r.Status()
+        status.Add(&st)
+        if r.Step == "Done" || r.Step == "Failed" {
+            finished++
+        }
+    }
+
+    return status, finished
+}
+
+// printProgress prints the progress of all terms until done is closed
+func (q *ixTermQueue) printProgress(done chan bool) {
+    isTerminal := term.IsTerminal(int(os.Stdin.Fd()))
+    spin := ""
+
+    if isTerminal {
+        ascii.HideCursor()
+        defer ascii.ShowCursor()
+    }
+
+    for {
+        st, finished := q.Status()
+        st.IsTerminal = isTerminal
+        st.Spin = spin
+        st.Step = fmt.Sprintf("terms: %d/%d", finished, len(q.Runs))
+        st.Print()
+        spin = st.Spin
+
+        wait := time.Second * 10
+        if isTerminal {
+            wait = time.Second / 4
+        }
+
+        select {
+        case <-done:
+            st.Clear()
+            return
+        case <-time.After(wait):
+        }
+    }
+}
+
+// PrintTable logs the status of each term
+func (q *ixTermQueue) PrintTable() {
+    var table strings.Builder
+    w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+    fmt.Fprintln(w, "TERM\tKEY\tSTATUS\tLISTED\tDOWNLOADED\tDUPLICATED\tSHARED\tFAILED\tBYTES\tZIP")
+    for _, r := range q.Runs {
+        st := r.Status()
+        fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%s\t%s\n",
+            r.Term,
+            r.Key,
+            r.Step,
+            st.TotalFiles,
+            st.Downloaded,
+            st.Duplicated,
+            st.Shared,
+            st.Failed,
+            tools.Bytes(uint64(st.TotalBytes)),
+            r.ZipFile,
+        )
+    }
+    w.Flush()
+
+    log.Info("Terms status\n" + table.String())
+}
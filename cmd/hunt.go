@@ -0,0 +1,39 @@
+package cmd
+
+import (
+    "github.com/helviojunior/intelparser/internal/ascii"
+    "github.com/spf13/cobra"
+)
+
+var huntCmd = &cobra.Command{
+    Use:   "hunt",
+    Short: "Download and parse in one command",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# hunt
+
+Search and download from the sources, sending the downloaded files directly
+to the parser and writers (no ZIP file is created).
+
+The parse options and writers are accepted.
+`)),
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        // Annoying quirk, but because I'm overriding PersistentPreRun
+        // here which overrides the parent it seems.
+        // So we need to explicitly call the parent's one now.
+        if err = rootCmd.PersistentPreRunE(cmd, args); err != nil {
+            return err
+        }
+
+        return setupParser()
+    },
+}
+
+func init() {
+    rootCmd.AddCommand(huntCmd)
+
+    addParserFlags(huntCmd.PersistentFlags())
+
+    huntCmd.PersistentFlags().StringVarP(&downloadProxy, "proxy", "X", "", "Proxy to pass traffic through: <scheme://ip:port>")
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+    "fmt"
+    "log/slog"
+    "os"
+    "path/filepath"
+
+    "github.com/helviojunior/intelparser/internal/ascii"
+    "github.com/helviojunior/intelparser/internal/tools"
+    "github.com/helviojunior/intelparser/pkg/downloaders"
+    "github.com/helviojunior/intelparser/pkg/ixapi"
+    "github.com/helviojunior/intelparser/pkg/log"
+    "github.com/helviojunior/intelparser/pkg/runner"
+    "github.com/helviojunior/intelparser/pkg/runner/parsers"
+    "github.com/spf13/cobra"
+)
+
+var huntIXCmd = &cobra.Command{
+    Use:   "intelx",
+    Short: "Search IntelX.io and parse the downloaded files",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# hunt intelx
+
+Search and download from IntelX.io, parsing the downloaded files directly.
+The file metadata (name, date, bucket...) comes from the search results.
+
+An IntelX API key must be provided. You can specify it using the --api-key parameter in the command line
+or by setting the IXAPIKEY environment variable.
+`)),
+    Example: `
+   - intelparser hunt intelx --term sec4us.com.br
+   - intelparser hunt intelx --term sec4us.com.br --date-from 2024-01-01 --buckets leaks.private --write-elastic --write-elasticsearch-uri "http://127.0.0.1:9200/intelparser"
+   - intelparser hunt intelx --term "~/Desktop/term_list.txt" --since-last --write-jsonl
+`,
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        if err := checkIntelXFlags(); err != nil {
+            return err
+        }
+
+        return checkIntelXCredits(downloaders.IntelXPathSearch, downloaders.IntelXPathExport)
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+        status, err := huntIntelX(ixTermList)
+        if err != nil {
+            log.Error("Error starting parser", "err", err)
+            os.Exit(2)
+        }
+
+        printParseStatistics(status)
+
+        tools.RemoveFolder(tempFolder)
+    },
+}
+
+// huntIntelX downloads the terms, sending the downloaded files directly to a new runner
+func huntIntelX(termList []string) (runner.Status, error) {
+    var err error
+
+    // An slog-capable logger to use with drivers and runners
+    logger := slog.New(log.Logger)
+
+    // Configure the driver
+    parserDriver, err = parsers.Lookup("intelx").New(logger, *opts)
+    if err != nil {
+        return runner.Status{}, err
+    }
+    ix := parserDriver.(*parsers.IntelxParser)
+
+    scanRunner, err = runner.NewRunner(logger, parserDriver, *opts, scanWriters)
+    if err != nil {
+        parserDriver.Close()
+        return runner.Status{}, err
+    }
+
+    downloads := []*downloaders.IntelXDownloader{}
+
+    go func() {
+        defer close(scanRunner.Files)
+
+        for i, term := range termList {
+            acc, err := ixKeyRing.Acquire(downloaders.IntelXPathSearch, downloaders.IntelXPathExport)
+            if err != nil {
+                log.Error("Stopping the term list", "err", err, "not_searched", len(termList) - i)
+                break
+            }
+
+            log.Infof("Quering term %s", term)
+
+            dwn, err := newIntelXDownloader(term, "", acc)
+            if err != nil {
+                log.Error("Error getting downloader instance", "err", err)
+                continue
+            }
+            downloads = append(downloads, dwn)
+
+            // Same path as the files of a download intelx ZIP file
+            virtual := fmt.Sprintf("ix_%s_%s", tools.SafeFileName(term), startTime.Format("2006-01-02_15-04-05"))
+            dwn.OnDownload = func(item ixapi.SearchResult, file_path string) {
+                ix.AddSearchResult(item)
+                scanRunner.Files <- runner.FileItem{
+                    RealPath: file_path,
+                    VirtualPath: filepath.Join(virtual, filepath.Base(file_path)),
+                    Driver: "intelx",
+                }
+            }
+
+            if err := dwn.Search(); err != nil {
+                log.Error("Error searching term", "term", term, "err", err)
+                dwn.SinceLast = false // keep the previous state, the items are searched again at the next run
+            }
+            dwn.Close()
+        }
+    }()
+
+    log.Info("Starting parser", "parser", "intelx")
+    status := scanRunner.Run()
+    scanRunner.Close()
+
+    // The files were parsed, so they are stored as seen
+    for _, dwn := range downloads {
+        if dwn.SinceLast {
+            if err := dwn.SaveState(); err != nil {
+                log.Error("Error saving watch state", "term", dwn.Term, "err", err)
+            }
+        }
+        dwn.Clean()
+    }
+
+    return status, nil
+}
+
+func init() {
+    huntCmd.AddCommand(huntIXCmd)
+
+    addIntelXFlags(huntIXCmd.Flags())
+    huntIXCmd.Flags().BoolVar(&dwnIXCmdFlags.sinceLast, "since-last", false, "Download only the items not downloaded at the previous --since-last runs of the term (state stored at ~/.intelparser.db)")
+}
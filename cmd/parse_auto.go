@@ -0,0 +1,80 @@
+package cmd
+
+import (
+    "log/slog"
+    "strings"
+
+    "github.com/helviojunior/intelparser/internal/ascii"
+    "github.com/helviojunior/intelparser/pkg/log"
+    "github.com/helviojunior/intelparser/pkg/runner"
+    "github.com/helviojunior/intelparser/pkg/readers"
+    "github.com/helviojunior/intelparser/pkg/runner/parsers"
+    "github.com/spf13/cobra"
+)
+
+var autoCmdOptions = &readers.FileReaderOptions{}
+var autoCmd = &cobra.Command{
+    Use:   "auto",
+    Short: "Parse files routing each one to the matching parser",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# parse auto
+
+Parse a file or path routing each input to the matching parser.
+
+Folders are checked first: IntelX downloads (Info.csv) and Telegram
+exports (result.json) are parsed as a whole. Other files, including the
+ones of browser profiles/stealer logs, are sniffed one by one by the
+extension and content (JSON, CSV, SQL dumps, mbox/eml, browser stores)
+and the remaining ones are parsed with the text rules. ZIP files are
+extracted and their content is sniffed.
+
+The options of all parsers are accepted.
+
+`)),
+    Example: `
+   - intelparser parse auto -p ~/Downloads/leaks/
+   - intelparser parse auto -p ~/Downloads/leaks.zip --mapping ~/Desktop/mapping.json
+   - intelparser parse auto -p ~/Downloads/leaks/ --write-elastic --write-elasticsearch-uri "http://127.0.0.1:9200/intelparser"
+`,
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if autoCmdOptions.Path, err = resolveParsePath(autoCmdOptions.Path); err != nil {
+            return err
+        }
+
+        // An slog-capable logger to use with drivers and runners
+        logger := slog.New(log.Logger)
+
+        // Configure the driver, the drivers are created on the first routed file
+        parserDriver, err = parsers.NewAuto(logger, *opts)
+        if err != nil {
+            return err
+        }
+
+        // Get the runner up. Basically, all of the subcommands will use this.
+        scanRunner, err = runner.NewRunner(logger, parserDriver, *opts, scanWriters)
+        if err != nil {
+            return err
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+        runParser(nil, autoCmdOptions.Path)
+    },
+}
+
+func init() {
+    parserCmd.AddCommand(autoCmd)
+
+    names := []string{}
+    for _, reg := range parsers.Registrations() {
+        names = append(names, reg.Name)
+        if reg.Flags != nil {
+            reg.Flags(autoCmd.Flags())
+        }
+    }
+
+    autoCmd.Flags().StringVarP(&autoCmdOptions.Path, "path", "p", "", "A file or a path with file(s) of any supported type (" + strings.Join(names, ", ") + ").")
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+    "errors"
+    "log/slog"
+    "path/filepath"
+    "strings"
+
+    "github.com/helviojunior/intelparser/internal/ascii"
+    "github.com/helviojunior/intelparser/internal/tools"
+    "github.com/helviojunior/intelparser/pkg/database"
+    "github.com/helviojunior/intelparser/pkg/log"
+    "github.com/helviojunior/intelparser/pkg/models"
+    "github.com/helviojunior/intelparser/pkg/runner"
+    "github.com/helviojunior/intelparser/pkg/runner/parsers"
+    "github.com/spf13/cobra"
+)
+
+var requeueCmdFlags = struct {
+    codes    string
+    parser   string
+    failures []models.File
+}{}
+var requeueCmd = &cobra.Command{
+    Use:   "failures",
+    Short: "Re-queue the files that failed or were skipped",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# parse failures
+
+Re-queue the files listed by report failures, usually with different
+options (e.g. a bigger --max-target-megabytes or another --parser).
+
+The files extracted from ZIP files are re-queued by their ZIP file, the
+files already parsed are ignored. The failures solved are removed from
+the control database.
+`)),
+    Example: `
+   - intelparser parse failures
+   - intelparser parse failures --code size_limit --max-target-megabytes 0
+   - intelparser parse failures --code unsupported_mime,parse_error --parser text
+`,
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if opts.Writer.NoControlDb {
+            return errors.New("the failures are read from the control database, it cannot be disabled")
+        }
+
+        codes, err := parseFailureCodes(requeueCmdFlags.codes)
+        if err != nil {
+            return err
+        }
+
+        if requeueCmdFlags.parser != "" && parsers.Lookup(requeueCmdFlags.parser) == nil {
+            return errors.New("invalid parser: " + requeueCmdFlags.parser)
+        }
+
+        conn, err := database.Connection(opts.Writer.GlobalDbURI, true, false)
+        if err != nil {
+            return err
+        }
+
+        if requeueCmdFlags.failures, err = loadFailures(conn, codes); err != nil {
+            return err
+        }
+
+        // An slog-capable logger to use with drivers and runners
+        logger := slog.New(log.Logger)
+
+        // Each file is routed to the parser that failed it (or --parser)
+        parserDriver, err = parsers.NewAuto(logger, *opts)
+        if err != nil {
+            return err
+        }
+
+        // Get the runner up. Basically, all of the subcommands will use this.
+        scanRunner, err = runner.NewRunner(logger, parserDriver, *opts, scanWriters)
+        if err != nil {
+            return err
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+        if len(requeueCmdFlags.failures) == 0 {
+            log.Warn("No failed or skipped files to re-queue")
+            scanRunner.Close()
+            tools.RemoveFolder(tempFolder)
+            return
+        }
+
+        log.Info("Re-queueing files", "count", len(requeueCmdFlags.failures))
+
+        go func() {
+            defer close(scanRunner.Files)
+            requeueFailures(requeueCmdFlags.failures, requeueCmdFlags.parser)
+        }()
+
+        status := scanRunner.Run()
+        scanRunner.Close()
+
+        printParseStatistics(status)
+
+        tools.RemoveFolder(tempFolder)
+    },
+}
+
+func init() {
+    parserCmd.AddCommand(requeueCmd)
+
+    names := []string{}
+    for _, reg := range parsers.Registrations() {
+        names = append(names, reg.Name)
+        if reg.Flags != nil {
+            reg.Flags(requeueCmd.Flags())
+        }
+    }
+
+    requeueCmd.Flags().StringVar(&requeueCmdFlags.codes, "code", "", "Comma-separated reason codes to re-queue (" + strings.Join(runner.FailureCodes, ", ") + ")")
+    requeueCmd.Flags().StringVar(&requeueCmdFlags.parser, "parser", "", "Parse the files with this parser instead of the one that failed it (" + strings.Join(names, ", ") + ")")
+}
+
+// requeueFailures sends the failed files to the runner. The files extracted
+// from a ZIP file are sent again with the ZIP file.
+func requeueFailures(failures []models.File, parser string) {
+    archives := map[string]bool{}
+    indexes := map[string]bool{}
+
+    for _, f := range failures {
+        driver := f.Parser
+        if parser != "" {
+            driver = parser
+        }
+
+        if f.SourcePath != "" && tools.FileExists(f.SourcePath) {
+            // Export files need the export index (e.g. IntelX Info.csv) loaded first
+            if reg := parsers.Lookup(driver); reg != nil && reg.Index != "" {
+                index := filepath.Join(filepath.Dir(f.SourcePath), reg.Index)
+                if !indexes[index] && tools.FileExists(index) {
+                    indexes[index] = true
+                    err := scanRunner.ParsePositionalFile(runner.FileItem{
+                        RealPath: index,
+                        VirtualPath: filepath.Join(filepath.Dir(f.FilePath), reg.Index),
+                        Driver: reg.Name,
+                    })
+                    if err != nil {
+                        log.Error("error parsing export index", "file", index, "err", err)
+                    }
+                }
+            }
+
+            scanRunner.Files <- runner.FileItem{
+                RealPath: f.SourcePath,
+                VirtualPath: f.FilePath,
+                Driver: driver,
+                Archive: f.SourceArchive,
+            }
+            continue
+        }
+
+        if f.SourceArchive != "" && tools.FileExists(f.SourceArchive) {
+            if archives[f.SourceArchive] {
+                continue
+            }
+            archives[f.SourceArchive] = true
+
+            log.Debug("Re-queueing ZIP file", "file", f.SourceArchive)
+            if err := AddInputs(parsers.Lookup(parser), f.SourceArchive, filepath.Base(f.SourceArchive)); err != nil {
+                log.Error("error listing files", "err", err)
+            }
+            continue
+        }
+
+        log.Warn("Source file not found, ignoring", "file", f.FilePath, "source", f.SourcePath)
+    }
+}
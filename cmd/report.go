@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bufio"
+    "database/sql"
     "encoding/json"
     "fmt"
     "io"
@@ -25,6 +26,10 @@ type ConvStatus struct {
     Url int
     Email int
     Credential int
+    Secret int
+    PII int
+    Wallet int
+    Cookie int
     Spin string
     IsTerminal bool
 }
@@ -91,18 +96,22 @@ func (st *ConvStatus) Print() {
     if st.IsTerminal {
         st.Spin = ascii.GetNextSpinner(st.Spin)
 
-        fmt.Fprintf(os.Stderr, "%s\n %s converted %d: cred: %d, url: %d, email: %d\r\033[A", 
+        fmt.Fprintf(os.Stderr, "%s\n %s converted %d: cred: %d, url: %d, email: %d, secret: %d, pii: %d, wallet: %d, cookie: %d\r\033[A", 
             "                                                                        ",
             ascii.ColoredSpin(st.Spin), 
             st.Converted, 
             st.Credential, 
             st.Url, 
-            st.Email)
+            st.Email,
+            st.Secret,
+            st.PII,
+            st.Wallet,
+            st.Cookie)
 
     }else{
         log.Info("STATUS", 
             "converted", st.Converted,
-            "creds", st.Credential, "url", st.Url, "email", st.Email)
+            "creds", st.Credential, "url", st.Url, "email", st.Email, "secret", st.Secret, "pii", st.PII, "wallet", st.Wallet, "cookie", st.Cookie)
     }
 } 
 
@@ -145,7 +154,31 @@ func getFilteredOnly(file models.File) *models.File {
         }
     }
 
-    if !containsFilterWord(nf.Content) && len(nf.Credentials) == 0 && len(nf.Emails) == 0 && len(nf.URLs) == 0 {
+    for _, sec := range file.Secrets {
+        if containsFilterWord(sec.Value) || containsFilterWord(sec.NearText) {
+            nf.Secrets = append(nf.Secrets, sec)
+        }
+    }
+
+    for _, p := range file.PIIs {
+        if containsFilterWord(p.Value) || containsFilterWord(p.NearText) {
+            nf.PIIs = append(nf.PIIs, p)
+        }
+    }
+
+    for _, w := range file.Wallets {
+        if containsFilterWord(w.Value) || containsFilterWord(w.NearText) {
+            nf.Wallets = append(nf.Wallets, w)
+        }
+    }
+
+    for _, c := range file.Cookies {
+        if containsFilterWord(c.Domain) || containsFilterWord(c.NearText) {
+            nf.Cookies = append(nf.Cookies, c)
+        }
+    }
+
+    if !containsFilterWord(nf.Content) && len(nf.Credentials) == 0 && len(nf.Emails) == 0 && len(nf.URLs) == 0 && len(nf.Secrets) == 0 && len(nf.PIIs) == 0 && len(nf.Wallets) == 0 && len(nf.Cookies) == 0 {
         return nil
     }
 
@@ -187,7 +220,8 @@ func convertFromDbTo(from string, writer writers.Writer, status *ConvStatus) err
     //    return err
     //}
 
-    rows, err := conn.Model(&models.File{}).Rows()
+    // The failed and skipped files are listed by report failures
+    rows, err := conn.Model(&models.File{}).Where("failed = ?", false).Rows()
     defer rows.Close()
     if err != nil {
         return err
@@ -205,23 +239,54 @@ func convertFromDbTo(from string, writer writers.Writer, status *ConvStatus) err
             sql1 += " AND [time] >= '" + opts.DateFilter.Format("2006-01-02") + "' "
         }
 
-        sqlCred := sql1 + prepareSQL([]string{"username", "url", "password"})
-        rCred, err := conn.Model(&models.Credential{}).Where(sqlCred).Rows()
-        defer rCred.Close()
+        // The cursors of the file are closed once its findings are checked
+        cursors := []*sql.Rows{}
+        closeCursors := func() {
+            for _, c := range cursors {
+                c.Close()
+            }
+        }
+        query := func(model interface{}, where string) (*sql.Rows, error) {
+            r, err := conn.Model(model).Where(where).Rows()
+            if err != nil {
+                closeCursors()
+                return nil, err
+            }
+            cursors = append(cursors, r)
+            return r, nil
+        }
+
+        rCred, err := query(&models.Credential{}, sql1 + prepareSQL([]string{"username", "url", "password"}))
+        if err != nil {
+            return err
+        }
+
+        rEml, err := query(&models.Email{}, sql1 + prepareSQL([]string{"email"}))
+        if err != nil {
+            return err
+        }
+
+        rUrl, err := query(&models.URL{}, sql1 + prepareSQL([]string{"url"}))
+        if err != nil {
+            return err
+        }
+
+        rSecret, err := query(&models.Secret{}, sql1 + prepareSQL([]string{"value", "near_text"}))
+        if err != nil {
+            return err
+        }
+
+        rPII, err := query(&models.PII{}, sql1 + prepareSQL([]string{"value", "near_text"}))
         if err != nil {
             return err
         }
 
-        sqlEmail := sql1 + prepareSQL([]string{"email"})
-        rEml, err := conn.Model(&models.Email{}).Where(sqlEmail).Rows()
-        defer rEml.Close()
+        rWallet, err := query(&models.Wallet{}, sql1 + prepareSQL([]string{"value", "near_text"}))
         if err != nil {
             return err
         }
 
-        sqlUrl := sql1 + prepareSQL([]string{"url"})
-        rUrl, err := conn.Model(&models.URL{}).Where(sqlUrl).Rows()
-        defer rUrl.Close()
+        rCookie, err := query(&models.Cookie{}, sql1 + prepareSQL([]string{"domain", "near_text"}))
         if err != nil {
             return err
         }
@@ -270,9 +335,66 @@ func convertFromDbTo(from string, writer writers.Writer, status *ConvStatus) err
             }
         }()
 
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            logger.Debug("Checking secrets...")
+            var sec models.Secret
+            for rSecret.Next() {
+                conn.ScanRows(rSecret, &sec)
+                if containsFilterWord(sec.Value) || containsFilterWord(sec.NearText) {
+                    newResult.Secrets = append(newResult.Secrets, sec)
+                    status.Secret++
+                }
+            }
+        }()
+
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            logger.Debug("Checking pii...")
+            var p models.PII
+            for rPII.Next() {
+                conn.ScanRows(rPII, &p)
+                if containsFilterWord(p.Value) || containsFilterWord(p.NearText) {
+                    newResult.PIIs = append(newResult.PIIs, p)
+                    status.PII++
+                }
+            }
+        }()
+
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            logger.Debug("Checking wallets...")
+            var w models.Wallet
+            for rWallet.Next() {
+                conn.ScanRows(rWallet, &w)
+                if containsFilterWord(w.Value) || containsFilterWord(w.NearText) {
+                    newResult.Wallets = append(newResult.Wallets, w)
+                    status.Wallet++
+                }
+            }
+        }()
+
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            logger.Debug("Checking cookies...")
+            var c models.Cookie
+            for rCookie.Next() {
+                conn.ScanRows(rCookie, &c)
+                if containsFilterWord(c.Domain) || containsFilterWord(c.NearText) {
+                    newResult.Cookies = append(newResult.Cookies, c)
+                    status.Cookie++
+                }
+            }
+        }()
+
         wg.Wait()
+        closeCursors()
 
-        if containsFilterWord(newResult.Content) || len(newResult.Credentials) != 0 || len(newResult.Emails) != 0 || len(newResult.URLs) != 0 {
+        if containsFilterWord(newResult.Content) || len(newResult.Credentials) != 0 || len(newResult.Emails) != 0 || len(newResult.URLs) != 0 || len(newResult.Secrets) != 0 || len(newResult.PIIs) != 0 || len(newResult.Wallets) != 0 || len(newResult.Cookies) != 0 {
             logger.Debug("Converting file!")
             status.Converted++
             if err := writer.Write(newResult); err != nil {
@@ -325,6 +447,10 @@ func convertFromJsonlTo(from string, writer writers.Writer, status *ConvStatus)
             status.Url += len(newResult.URLs)
             status.Email += len(newResult.Emails)
             status.Credential += len(newResult.Credentials)
+            status.Secret += len(newResult.Secrets)
+            status.PII += len(newResult.PIIs)
+            status.Wallet += len(newResult.Wallets)
+            status.Cookie += len(newResult.Cookies)
         }
 
         if err == io.EOF {
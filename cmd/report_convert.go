@@ -118,6 +118,10 @@ target.`)),
             Url: 0,
             Email: 0,
             Credential: 0,
+            Secret: 0,
+            PII: 0,
+            Wallet: 0,
+            Cookie: 0,
             Spin: "",
             IsTerminal: term.IsTerminal(int(os.Stdin.Fd())),
         }
@@ -171,6 +175,10 @@ target.`)),
         st += "     -> Credentials......: %s\n"
         st += "     -> URLs.............: %s\n"
         st += "     -> E-mails..........: %s\n"
+        st += "     -> Secrets..........: %s\n"
+        st += "     -> PII..............: %s\n"
+        st += "     -> Wallets..........: %s\n"
+        st += "     -> Cookies..........: %s\n"
 
         log.Infof(st, 
             out.Format("15:04:05"),
@@ -178,9 +186,13 @@ target.`)),
             tools.FormatIntComma(status.Credential),
             tools.FormatIntComma(status.Url),
             tools.FormatIntComma(status.Email),
+            tools.FormatIntComma(status.Secret),
+            tools.FormatIntComma(status.PII),
+            tools.FormatIntComma(status.Wallet),
+            tools.FormatIntComma(status.Cookie),
         )
 
-        if (status.Credential + status.Url + status.Email) == 0 {
+        if (status.Credential + status.Url + status.Email + status.Secret + status.PII + status.Wallet + status.Cookie) == 0 {
             log.Warn("No records were converted. Cleaning up output file...")
 
             err = os.Remove(convertCmdFlags.toFile)
@@ -84,6 +84,10 @@ A --from-file and --elasticsearch-uri must be specified.`)),
             Url: 0,
             Email: 0,
             Credential: 0,
+            Secret: 0,
+            PII: 0,
+            Wallet: 0,
+            Cookie: 0,
             Spin: "",
             IsTerminal: term.IsTerminal(int(os.Stdin.Fd())),
         }
@@ -131,6 +135,10 @@ A --from-file and --elasticsearch-uri must be specified.`)),
         st += "     -> Credentials......: %s\n"
         st += "     -> URLs.............: %s\n"
         st += "     -> E-mails..........: %s\n"
+        st += "     -> Secrets..........: %s\n"
+        st += "     -> PII..............: %s\n"
+        st += "     -> Wallets..........: %s\n"
+        st += "     -> Cookies..........: %s\n"
 
         log.Infof(st, 
             out.Format("15:04:05"),
@@ -138,6 +146,10 @@ A --from-file and --elasticsearch-uri must be specified.`)),
             tools.FormatIntComma(status.Credential),
             tools.FormatIntComma(status.Url),
             tools.FormatIntComma(status.Email),
+            tools.FormatIntComma(status.Secret),
+            tools.FormatIntComma(status.PII),
+            tools.FormatIntComma(status.Wallet),
+            tools.FormatIntComma(status.Cookie),
         )
 
     },
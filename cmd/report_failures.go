@@ -0,0 +1,157 @@
+package cmd
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "strings"
+    "text/tabwriter"
+
+    "github.com/helviojunior/intelparser/internal/ascii"
+    "github.com/helviojunior/intelparser/internal/tools"
+    "github.com/helviojunior/intelparser/pkg/database"
+    "github.com/helviojunior/intelparser/pkg/log"
+    "github.com/helviojunior/intelparser/pkg/models"
+    "github.com/helviojunior/intelparser/pkg/runner"
+    resolver "github.com/helviojunior/gopathresolver"
+    "github.com/spf13/cobra"
+    "gorm.io/gorm"
+)
+
+var failuresCmdFlags = struct {
+    fromFile string
+    codes    string
+    codeList []string
+}{}
+var failuresCmd = &cobra.Command{
+    Use:   "failures",
+    Short: "List the files that failed or were skipped while parsing",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# report failures
+
+List the files that failed or were skipped while parsing, with the reason
+code stored at the control database:
+
+- parse_error: the parser returned an error
+- unsupported_mime: binary file type without text to parse
+- size_limit: file bigger than --max-target-megabytes
+- not_indexed: file not listed at the export index (e.g. IntelX Info.csv)
+- permission: permission denied reading the file
+- not_found: file removed before it was parsed
+- no_parser: no parser matches the file
+
+The listed files can be re-queued with different options using
+parse failures.`)),
+    Example: `
+   - intelparser report failures
+   - intelparser report failures --code size_limit,unsupported_mime
+   - intelparser report failures --from-file ./intelparser.db`,
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if failuresCmdFlags.codeList, err = parseFailureCodes(failuresCmdFlags.codes); err != nil {
+            return err
+        }
+
+        failuresCmdFlags.fromFile, err = resolver.ResolveFullPath(failuresCmdFlags.fromFile)
+        if err != nil {
+            return err
+        }
+
+        if !tools.FileExists(failuresCmdFlags.fromFile) {
+            return errors.New("Control database not found")
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+        conn, err := database.Connection(fmt.Sprintf("sqlite:///%s", failuresCmdFlags.fromFile), true, false)
+        if err != nil {
+            log.Error("could not open the control database", "err", err)
+            return
+        }
+
+        failures, err := loadFailures(conn, failuresCmdFlags.codeList)
+        if err != nil {
+            log.Error("could not list the failures", "err", err)
+            return
+        }
+
+        if len(failures) == 0 {
+            log.Warn("No failed or skipped files")
+            return
+        }
+
+        codes := map[string]int{}
+        w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+        fmt.Fprintln(w, "CODE\tPARSER\tDATE\tFILE\tREASON")
+        for _, f := range failures {
+            codes[f.FailedCode]++
+            fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+                f.FailedCode,
+                f.Parser,
+                f.IndexedAt.Format("2006-01-02 15:04:05"),
+                f.FilePath,
+                f.FailedReason,
+            )
+        }
+        w.Flush()
+
+        st := "Failures by reason\n"
+        for _, c := range runner.FailureCodes {
+            if codes[c] > 0 {
+                st += fmt.Sprintf("     -> %s: %s\n", (c + " " + strings.Repeat(".", 17))[:17], tools.FormatIntComma(codes[c]))
+            }
+        }
+        log.Warn(st)
+
+        log.Info("To re-queue them with different options: intelparser parse failures --code <codes> [--parser <name>] [--max-target-megabytes <size>]")
+    },
+}
+
+func init() {
+    reportCmd.AddCommand(failuresCmd)
+
+    failuresCmd.Flags().StringVar(&failuresCmdFlags.fromFile, "from-file", "~/.intelparser.db", "The control database to list the failures from")
+    failuresCmd.Flags().StringVar(&failuresCmdFlags.codes, "code", "", "Comma-separated reason codes to list (" + strings.Join(runner.FailureCodes, ", ") + ")")
+}
+
+// parseFailureCodes checks the comma-separated reason codes
+func parseFailureCodes(codes string) ([]string, error) {
+    list := []string{}
+    for _, c := range strings.Split(codes, ",") {
+        c = strings.ToLower(strings.Trim(c, " "))
+        if c == "" {
+            continue
+        }
+        if !tools.SliceHasStr(runner.FailureCodes, c) {
+            return nil, errors.New("invalid reason code: " + c)
+        }
+        list = append(list, c)
+    }
+    return list, nil
+}
+
+// loadFailures returns the failed and skipped files of the control database,
+// filtered by the reason codes and the report --filter/--date-from
+func loadFailures(conn *gorm.DB, codes []string) ([]models.File, error) {
+    var rows []models.File
+    q := conn.Model(&models.File{}).Where("failed = ?", true)
+    if len(codes) > 0 {
+        q = q.Where("failed_code IN ?", codes)
+    }
+    if opts.DateFilter != nil {
+        q = q.Where("indexed_at >= ?", *opts.DateFilter)
+    }
+    if err := q.Order("failed_code, file_path").Find(&rows).Error; err != nil {
+        return nil, err
+    }
+
+    failures := []models.File{}
+    for _, f := range rows {
+        if containsFilterWord(f.FilePath) || containsFilterWord(f.FailedReason) {
+            failures = append(failures, f)
+        }
+    }
+    return failures, nil
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+    "errors"
+    "time"
+
+    "github.com/helviojunior/intelparser/internal/ascii"
+    "github.com/spf13/cobra"
+)
+
+var watchInterval time.Duration
+var watchCmd = &cobra.Command{
+    Use:   "watch",
+    Short: "Monitor sources, parsing the new files continuously",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# watch
+
+Monitor sources on an interval. Each check downloads only the items not seen
+at the previous checks and sends them to the parser and writers.
+
+The parse options and writers are accepted.
+`)),
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        // Annoying quirk, but because I'm overriding PersistentPreRun
+        // here which overrides the parent it seems.
+        // So we need to explicitly call the parent's one now.
+        if err = rootCmd.PersistentPreRunE(cmd, args); err != nil {
+            return err
+        }
+
+        if watchInterval < time.Minute {
+            return errors.New("--interval must be at least 1m")
+        }
+
+        return setupParser()
+    },
+}
+
+func init() {
+    rootCmd.AddCommand(watchCmd)
+
+    addParserFlags(watchCmd.PersistentFlags())
+
+    watchCmd.PersistentFlags().DurationVar(&watchInterval, "interval", time.Hour, "Interval between the checks (e.g. 30m, 6h)")
+    watchCmd.PersistentFlags().StringVarP(&downloadProxy, "proxy", "X", "", "Proxy to pass traffic through: <scheme://ip:port>")
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+    "os"
+    "time"
+
+    "github.com/helviojunior/intelparser/internal/ascii"
+    "github.com/helviojunior/intelparser/pkg/downloaders"
+    "github.com/helviojunior/intelparser/pkg/log"
+    "github.com/spf13/cobra"
+)
+
+var watchIXCmd = &cobra.Command{
+    Use:   "intelx",
+    Short: "Monitor IntelX.io search terms",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# watch intelx
+
+Search IntelX.io on an interval, downloading only the items not seen at the
+previous checks of the term (the state is stored at the control database,
+the same used by download intelx --since-last). The downloaded files are
+sent directly to the parser and writers, as hunt intelx does.
+
+An IntelX API key must be provided. You can specify it using the --api-key parameter in the command line
+or by setting the IXAPIKEY environment variable.
+`)),
+    Example: `
+   - intelparser watch intelx --term sec4us.com.br --interval 6h
+   - intelparser watch intelx --term "~/Desktop/term_list.txt" --buckets leaks.private,pastes --write-elastic --write-elasticsearch-uri "http://127.0.0.1:9200/intelparser"
+`,
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        if err := checkIntelXFlags(); err != nil {
+            return err
+        }
+
+        return checkIntelXCredits(downloaders.IntelXPathSearch, downloaders.IntelXPathExport)
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+        dwnIXCmdFlags.sinceLast = true
+
+        for {
+            // Statistics of this check only
+            startTime = time.Now()
+            status, err := huntIntelX(ixTermList)
+            if err != nil {
+                log.Error("Error starting parser", "err", err)
+                os.Exit(2)
+            }
+            printParseStatistics(status)
+
+            log.Info("Waiting the next check", "at", time.Now().Add(watchInterval).Format("2006-01-02 15:04:05"))
+            time.Sleep(watchInterval)
+        }
+    },
+}
+
+func init() {
+    watchCmd.AddCommand(watchIXCmd)
+
+    addIntelXFlags(watchIXCmd.Flags())
+}
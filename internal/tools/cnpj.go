@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"strings"
+	re "regexp"
+)
+
+var cnpjRe = re.MustCompile(`\b(\d{2}\.?\d{3}\.?\d{3}/?\d{4}-?\d{2})\b`)
+
+func ExtractCNPJ(text string) (bool, string) {
+	groups := cnpjRe.FindStringSubmatch(text)
+    if len(groups) == 2 {
+    	if ValidateCNPJ(groups[1]) {
+    		return true, cleanCpf.ReplaceAllString(groups[1], "")
+    	}
+    }
+
+    return false, ""
+}
+
+func ValidateCNPJ(cnpj string) bool {
+	// Remove dots, slashes and dashes
+	cnpj = cleanCpf.ReplaceAllString(cnpj, "")
+
+	// Must be 14 digits
+	if len(cnpj) != 14 {
+		return false
+	}
+
+	// Reject all digits equal (e.g., "11111111111111")
+	if cnpj == strings.Repeat(cnpj[0:1], 14) {
+		return false
+	}
+
+	weights1 := []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+	weights2 := []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+
+	// Validate first digit
+	sum := 0
+	for i := 0; i < 12; i++ {
+		sum += int(cnpj[i]-'0') * weights1[i]
+	}
+	d1 := sum % 11
+	if d1 < 2 {
+		d1 = 0
+	} else {
+		d1 = 11 - d1
+	}
+	if d1 != int(cnpj[12]-'0') {
+		return false
+	}
+
+	// Validate second digit
+	sum = 0
+	for i := 0; i < 13; i++ {
+		sum += int(cnpj[i]-'0') * weights2[i]
+	}
+	d2 := sum % 11
+	if d2 < 2 {
+		d2 = 0
+	} else {
+		d2 = 11 - d2
+	}
+	if d2 != int(cnpj[13]-'0') {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"testing"
+)
+
+func TestValidateCNPJ(t *testing.T) {
+	tests := []struct {
+		cnpj string
+		want bool
+	}{
+		{"11.222.333/0001-81", true},
+		{"11222333000181", true},
+		{"11.444.777/0001-61", true},
+		{"11.222.333/0001-82", false},
+		{"11.111.111/1111-11", false},
+		{"00000000000000", false},
+		{"1122233300018", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidateCNPJ(tt.cnpj); got != tt.want {
+			t.Errorf("ValidateCNPJ(%q) = %v, want %v", tt.cnpj, got, tt.want)
+		}
+	}
+}
+
+func TestExtractCNPJ(t *testing.T) {
+	ok, cnpj := ExtractCNPJ("empresa: 11.222.333/0001-81 (matriz)")
+	if !ok || cnpj != "11222333000181" {
+		t.Errorf("ExtractCNPJ() = %v, %q, want true, %q", ok, cnpj, "11222333000181")
+	}
+
+	if ok, _ := ExtractCNPJ("empresa: 11.222.333/0001-80"); ok {
+		t.Error("ExtractCNPJ() accepted an invalid check digit")
+	}
+}
@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"strconv"
+)
+
+// ValidateLuhn checks the Luhn (mod 10) checksum of a digit only string
+func ValidateLuhn(number string) bool {
+	if len(number) < 2 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		if number[i] < '0' || number[i] > '9' {
+			return false
+		}
+
+		d := int(number[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+
+	return sum%10 == 0
+}
+
+// CardBrand returns the card brand based on its BIN (IIN) range,
+// or an empty string if the range is unknown
+func CardBrand(number string) string {
+	size := len(number)
+	if size < 12 {
+		return ""
+	}
+
+	p1, _ := strconv.Atoi(number[0:1])
+	p2, _ := strconv.Atoi(number[0:2])
+	p3, _ := strconv.Atoi(number[0:3])
+	p4, _ := strconv.Atoi(number[0:4])
+	p6, _ := strconv.Atoi(number[0:6])
+
+	switch {
+	case p2 == 34 || p2 == 37:
+		if size == 15 {
+			return "amex"
+		}
+	case (p6 >= 401178 && p6 <= 401179) || p6 == 431274 || p6 == 438935 || p6 == 451416 ||
+		p6 == 457393 || p6 == 457631 || p6 == 457632 || p6 == 504175 || p6 == 627780 ||
+		p6 == 636297 || p6 == 636368 || (p6 >= 506699 && p6 <= 506778) ||
+		(p6 >= 509000 && p6 <= 509999) || (p6 >= 650031 && p6 <= 650033) ||
+		(p6 >= 650035 && p6 <= 650051) || (p6 >= 650405 && p6 <= 650439) ||
+		(p6 >= 650485 && p6 <= 650538) || (p6 >= 650541 && p6 <= 650598) ||
+		(p6 >= 650700 && p6 <= 650718) || (p6 >= 650720 && p6 <= 650727) ||
+		(p6 >= 650901 && p6 <= 650920) || (p6 >= 651652 && p6 <= 651679) ||
+		(p6 >= 655000 && p6 <= 655019) || (p6 >= 655021 && p6 <= 655058):
+		if size == 16 {
+			return "elo"
+		}
+	case p6 == 606282 || p4 == 3841:
+		if size >= 13 && size <= 19 {
+			return "hipercard"
+		}
+	case p1 == 4:
+		if size == 13 || size == 16 || size == 19 {
+			return "visa"
+		}
+	case (p2 >= 51 && p2 <= 55) || (p4 >= 2221 && p4 <= 2720):
+		if size == 16 {
+			return "mastercard"
+		}
+	case p4 == 6011 || p2 == 65 || (p3 >= 644 && p3 <= 649) || (p6 >= 622126 && p6 <= 622925):
+		if size >= 16 && size <= 19 {
+			return "discover"
+		}
+	case p4 >= 3528 && p4 <= 3589:
+		if size >= 16 && size <= 19 {
+			return "jcb"
+		}
+	case p3 >= 300 && p3 <= 305 || p2 == 36 || p2 == 38 || p2 == 39:
+		if size >= 14 && size <= 19 {
+			return "diners"
+		}
+	case p2 == 62:
+		if size >= 16 && size <= 19 {
+			return "unionpay"
+		}
+	case p2 == 50 || (p2 >= 56 && p2 <= 69):
+		if size >= 12 && size <= 19 {
+			return "maestro"
+		}
+	}
+
+	return ""
+}
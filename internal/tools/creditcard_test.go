@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"testing"
+)
+
+func TestValidateLuhn(t *testing.T) {
+	tests := []struct {
+		number string
+		want   bool
+	}{
+		{"4111111111111111", true},
+		{"5555555555554444", true},
+		{"378282246310005", true},
+		{"6011111111111117", true},
+		{"4111111111111112", false},
+		{"4111 1111 1111 1111", false},
+		{"0", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidateLuhn(tt.number); got != tt.want {
+			t.Errorf("ValidateLuhn(%q) = %v, want %v", tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestCardBrand(t *testing.T) {
+	tests := []struct {
+		number string
+		want   string
+	}{
+		{"4111111111111111", "visa"},
+		{"5555555555554444", "mastercard"},
+		{"2221000000000009", "mastercard"},
+		{"378282246310005", "amex"},
+		{"6011111111111117", "discover"},
+		{"3530111333300000", "jcb"},
+		{"30569309025904", "diners"},
+		{"6062825624254001", "hipercard"},
+		{"5067000000000000", "elo"},
+		{"37828224631000", ""},
+		{"41111111111", ""},
+	}
+
+	for _, tt := range tests {
+		if got := CardBrand(tt.number); got != tt.want {
+			t.Errorf("CardBrand(%q) = %q, want %q", tt.number, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var bip39Index map[string]int
+
+func init() {
+	bip39Index = make(map[string]int, len(Bip39English))
+	for i, w := range Bip39English {
+		bip39Index[w] = i
+	}
+}
+
+// Base58Decode decodes a bitcoin alphabet base58 string
+func Base58Decode(s string) ([]byte, bool) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		idx := strings.IndexRune(base58Alphabet, c)
+		if idx == -1 {
+			return nil, false
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(idx)))
+	}
+
+	decoded := n.Bytes()
+
+	// Leading '1' chars are leading zero bytes
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+
+	return append(make([]byte, zeros), decoded...), true
+}
+
+// Base58CheckDecode decodes a base58check string returning the version byte and payload
+func Base58CheckDecode(s string) (byte, []byte, bool) {
+	data, ok := Base58Decode(s)
+	if !ok || len(data) < 5 {
+		return 0, nil, false
+	}
+
+	payload := data[:len(data)-4]
+	h1 := sha256.Sum256(payload)
+	h2 := sha256.Sum256(h1[:])
+	if !bytes.Equal(h2[:4], data[len(data)-4:]) {
+		return 0, nil, false
+	}
+
+	return payload[0], payload[1:], true
+}
+
+// ValidateBitcoinAddress checks the address checksum and returns its kind
+// (p2pkh, p2sh, bech32 or bech32m)
+func ValidateBitcoinAddress(addr string) (bool, string) {
+	if strings.HasPrefix(strings.ToLower(addr), "bc1") {
+		return validateBech32(addr, "bc")
+	}
+
+	version, payload, ok := Base58CheckDecode(addr)
+	if !ok || len(payload) != 20 {
+		return false, ""
+	}
+
+	switch version {
+	case 0x00:
+		return true, "p2pkh"
+	case 0x05:
+		return true, "p2sh"
+	}
+
+	return false, ""
+}
+
+// ValidateBitcoinWIF checks a Wallet Import Format private key
+func ValidateBitcoinWIF(wif string) (bool, string) {
+	version, payload, ok := Base58CheckDecode(wif)
+	if !ok || version != 0x80 {
+		return false, ""
+	}
+
+	switch {
+	case len(payload) == 32:
+		return true, "uncompressed"
+	case len(payload) == 33 && payload[32] == 0x01:
+		return true, "compressed"
+	}
+
+	return false, ""
+}
+
+func bech32Polymod(values []byte) uint32 {
+	gen := []uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (b>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func validateBech32(addr string, hrp string) (bool, string) {
+	if strings.ToLower(addr) != addr && strings.ToUpper(addr) != addr {
+		return false, ""
+	}
+	addr = strings.ToLower(addr)
+
+	pos := strings.LastIndexByte(addr, '1')
+	if pos < 1 || pos+7 > len(addr) || len(addr) > 90 || addr[:pos] != hrp {
+		return false, ""
+	}
+
+	data := make([]byte, 0, len(addr)-pos-1)
+	for _, c := range addr[pos+1:] {
+		idx := strings.IndexRune(bech32Charset, c)
+		if idx == -1 {
+			return false, ""
+		}
+		data = append(data, byte(idx))
+	}
+
+	values := make([]byte, 0, len(hrp)*2+1+len(data))
+	for _, c := range hrp {
+		values = append(values, byte(c>>5))
+	}
+	values = append(values, 0)
+	for _, c := range hrp {
+		values = append(values, byte(c&31))
+	}
+	values = append(values, data...)
+
+	switch bech32Polymod(values) {
+	case 1:
+		return true, "bech32"
+	case 0x2bc830a3:
+		return true, "bech32m"
+	}
+
+	return false, ""
+}
+
+// Keccak256 returns the legacy (pre NIST) keccak 256 hash used by Ethereum and Monero
+func Keccak256(data []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// ValidateEthereumAddress checks the address format and, for mixed case
+// addresses, the EIP-55 checksum. The second return is true when the checksum
+// was verified.
+func ValidateEthereumAddress(addr string) (bool, bool) {
+	if len(addr) != 42 || !strings.HasPrefix(addr, "0x") {
+		return false, false
+	}
+
+	hexPart := addr[2:]
+	if _, err := hex.DecodeString(hexPart); err != nil {
+		return false, false
+	}
+
+	if strings.ToLower(hexPart) == hexPart || strings.ToUpper(hexPart) == hexPart {
+		return true, false
+	}
+
+	hash := hex.EncodeToString(Keccak256([]byte(strings.ToLower(hexPart))))
+	for i, c := range hexPart {
+		if c >= '0' && c <= '9' {
+			continue
+		}
+		upper := hash[i] >= '8'
+		if upper != (c >= 'A' && c <= 'F') {
+			return false, false
+		}
+	}
+
+	return true, true
+}
+
+// moneroBlockSizes maps the encoded block size to the decoded one
+var moneroBlockSizes = map[int]int{0: 0, 2: 1, 3: 2, 5: 3, 6: 4, 7: 5, 9: 6, 10: 7, 11: 8}
+
+func moneroBase58Decode(s string) ([]byte, bool) {
+	out := []byte{}
+	for i := 0; i < len(s); i += 11 {
+		end := i + 11
+		if end > len(s) {
+			end = len(s)
+		}
+		block := s[i:end]
+		size, ok := moneroBlockSizes[len(block)]
+		if !ok {
+			return nil, false
+		}
+
+		n := new(big.Int)
+		radix := big.NewInt(58)
+		for _, c := range block {
+			idx := strings.IndexRune(base58Alphabet, c)
+			if idx == -1 {
+				return nil, false
+			}
+			n.Mul(n, radix)
+			n.Add(n, big.NewInt(int64(idx)))
+		}
+
+		b := n.Bytes()
+		if len(b) > size {
+			return nil, false
+		}
+		out = append(out, make([]byte, size-len(b))...)
+		out = append(out, b...)
+	}
+	return out, true
+}
+
+// ValidateMoneroAddress checks a standard, subaddress or integrated Monero address
+func ValidateMoneroAddress(addr string) (bool, string) {
+	if len(addr) != 95 && len(addr) != 106 {
+		return false, ""
+	}
+
+	data, ok := moneroBase58Decode(addr)
+	if !ok || len(data) < 69 {
+		return false, ""
+	}
+
+	payload := data[:len(data)-4]
+	if !bytes.Equal(Keccak256(payload)[:4], data[len(data)-4:]) {
+		return false, ""
+	}
+
+	switch payload[0] {
+	case 0x12:
+		return true, "standard"
+	case 0x2a:
+		return true, "subaddress"
+	case 0x13:
+		return true, "integrated"
+	}
+
+	return false, ""
+}
+
+// IsBip39Word returns true if the word is at the BIP-39 english wordlist
+func IsBip39Word(word string) bool {
+	_, ok := bip39Index[word]
+	return ok
+}
+
+// ValidateBip39Mnemonic checks the word count, wordlist and checksum of a mnemonic
+func ValidateBip39Mnemonic(words []string) bool {
+	n := len(words)
+	if n < 12 || n > 24 || n%3 != 0 {
+		return false
+	}
+
+	bits := new(big.Int)
+	for _, w := range words {
+		idx, ok := bip39Index[w]
+		if !ok {
+			return false
+		}
+		bits.Lsh(bits, 11)
+		bits.Or(bits, big.NewInt(int64(idx)))
+	}
+
+	totalBits := n * 11
+	csBits := totalBits / 33
+	entBits := totalBits - csBits
+
+	checksum := new(big.Int).And(bits, big.NewInt(int64(1<<uint(csBits))-1))
+	entropy := new(big.Int).Rsh(bits, uint(csBits)).Bytes()
+	entropy = append(make([]byte, entBits/8-len(entropy)), entropy...)
+
+	hash := sha256.Sum256(entropy)
+	expected := int64(hash[0]) >> uint(8-csBits)
+
+	return checksum.Int64() == expected
+}
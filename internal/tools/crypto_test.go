@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestValidateBitcoinAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		ok   bool
+		kind string
+	}{
+		{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", true, "p2pkh"},
+		{"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", true, "p2sh"},
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", true, "bech32"},
+		{"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", true, "bech32"},
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", true, "bech32m"},
+		{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", false, ""},
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", false, ""},
+		{"1A1zP1eP5QGefi2DMPTfTL5SLmv7Div0Na", false, ""},
+		{"", false, ""},
+	}
+
+	for _, tt := range tests {
+		ok, kind := ValidateBitcoinAddress(tt.addr)
+		if ok != tt.ok || kind != tt.kind {
+			t.Errorf("ValidateBitcoinAddress(%q) = %v, %q, want %v, %q", tt.addr, ok, kind, tt.ok, tt.kind)
+		}
+	}
+}
+
+func TestValidateBitcoinWIF(t *testing.T) {
+	tests := []struct {
+		wif  string
+		ok   bool
+		kind string
+	}{
+		{"5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ", true, "uncompressed"},
+		{"KwdMAjGmerYanjeui5SHS7JkmpZvVipYvB2LJGU1ZxJwYvP98617", true, "compressed"},
+		{"5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTK", false, ""},
+		{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", false, ""},
+	}
+
+	for _, tt := range tests {
+		ok, kind := ValidateBitcoinWIF(tt.wif)
+		if ok != tt.ok || kind != tt.kind {
+			t.Errorf("ValidateBitcoinWIF(%q) = %v, %q, want %v, %q", tt.wif, ok, kind, tt.ok, tt.kind)
+		}
+	}
+}
+
+func TestKeccak256(t *testing.T) {
+	want := "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"
+	if got := hex.EncodeToString(Keccak256([]byte{})); got != want {
+		t.Errorf("Keccak256(\"\") = %s, want %s", got, want)
+	}
+}
+
+func TestValidateEthereumAddress(t *testing.T) {
+	tests := []struct {
+		addr     string
+		ok       bool
+		checksum bool
+	}{
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", true, true},
+		{"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", true, true},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", true, false},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", false, false},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1bea", false, false},
+		{"5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", false, false},
+	}
+
+	for _, tt := range tests {
+		ok, checksum := ValidateEthereumAddress(tt.addr)
+		if ok != tt.ok || checksum != tt.checksum {
+			t.Errorf("ValidateEthereumAddress(%q) = %v, %v, want %v, %v", tt.addr, ok, checksum, tt.ok, tt.checksum)
+		}
+	}
+}
+
+func TestValidateMoneroAddress(t *testing.T) {
+	valid := "44AFFq5kSiGBoZ4NMDwYtN18obc8AemS33DBLWs3H7otXft3XjrpDtQGv7SqSsaBYBb98uNbr2VBBEt7f2wfn3RVGQBEP3A"
+	if ok, kind := ValidateMoneroAddress(valid); !ok || kind != "standard" {
+		t.Errorf("ValidateMoneroAddress(%q) = %v, %q, want true, standard", valid, ok, kind)
+	}
+
+	// Last char changed, invalid checksum
+	invalid := valid[:len(valid)-1] + "B"
+	if ok, _ := ValidateMoneroAddress(invalid); ok {
+		t.Errorf("ValidateMoneroAddress(%q) accepted an invalid checksum", invalid)
+	}
+
+	if ok, _ := ValidateMoneroAddress(valid[:90]); ok {
+		t.Error("ValidateMoneroAddress() accepted an invalid size")
+	}
+}
+
+func TestValidateBip39Mnemonic(t *testing.T) {
+	tests := []struct {
+		name     string
+		mnemonic string
+		want     bool
+	}{
+		{"12 words", strings.Repeat("abandon ", 11) + "about", true},
+		{"24 words", strings.Repeat("abandon ", 23) + "art", true},
+		{"trezor vector", "legal winner thank year wave sausage worth useful legal winner thank yellow", true},
+		{"bad checksum", strings.Repeat("abandon ", 12), false},
+		{"word not at the list", strings.Repeat("abandon ", 11) + "bitcoin", false},
+		{"bad word count", strings.Repeat("abandon ", 10) + "about", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidateBip39Mnemonic(strings.Fields(tt.mnemonic)); got != tt.want {
+			t.Errorf("ValidateBip39Mnemonic(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
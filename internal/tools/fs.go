@@ -260,49 +260,78 @@ func RemoveFolder(path string) error {
     return nil
 }
 
-func Unzip(src, dest string) error {
+// ErrUnzipTooBig is returned when the extracted files are bigger than the limit
+var ErrUnzipTooBig = errors.New("zip file extracted size exceeds the limit")
+
+// Unzip extracts the zip file to dest. Entries with absolute paths or escaping
+// dest (zip slip) are refused, and the extraction stops with ErrUnzipTooBig
+// once the extracted files are bigger than maxBytes (0 to disable the limit)
+func Unzip(src, dest string, maxBytes int64) error {
     r, err := zip.OpenReader(src)
     if err != nil {
         return err
     }
     defer r.Close()
 
+    var total int64
     for _, f := range r.File {
-        rc, err := f.Open()
-        if err != nil {
-            return err
+        if !filepath.IsLocal(f.Name) {
+            return fmt.Errorf("invalid zip entry path: %s", f.Name)
         }
-        defer rc.Close()
 
         fpath := filepath.Join(dest, f.Name)
         if f.FileInfo().IsDir() {
-            os.MkdirAll(fpath, f.Mode())
-        } else {
-            var fdir string
-            if lastIndex := strings.LastIndex(fpath,string(os.PathSeparator)); lastIndex > -1 {
-                fdir = fpath[:lastIndex]
-            }
-
-            err = os.MkdirAll(fdir, f.Mode())
-            if err != nil {
-                return err
-            }
-            f, err := os.OpenFile(
-                fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-            if err != nil {
+            if err = os.MkdirAll(fpath, 0o755); err != nil {
                 return err
             }
-            defer f.Close()
+            continue
+        }
 
-            _, err = io.Copy(f, rc)
-            if err != nil {
-                return err
-            }
+        var remaining int64 = -1
+        if maxBytes > 0 {
+            remaining = maxBytes - total
+        }
+        n, err := unzipFile(f, fpath, remaining)
+        total += n
+        if err != nil {
+            return err
         }
     }
     return nil
 }
 
+// unzipFile writes a zip entry to fpath, at most remaining bytes (-1 to disable the limit)
+func unzipFile(f *zip.File, fpath string, remaining int64) (int64, error) {
+    if err := os.MkdirAll(filepath.Dir(fpath), 0o755); err != nil {
+        return 0, err
+    }
+
+    rc, err := f.Open()
+    if err != nil {
+        return 0, err
+    }
+    defer rc.Close()
+
+    out, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode().Perm() | 0o600)
+    if err != nil {
+        return 0, err
+    }
+    defer out.Close()
+
+    var r io.Reader = rc
+    if remaining >= 0 {
+        r = io.LimitReader(rc, remaining + 1)
+    }
+    n, err := io.Copy(out, r)
+    if err != nil {
+        return n, err
+    }
+    if remaining >= 0 && n > remaining {
+        return n, ErrUnzipTooBig
+    }
+    return n, nil
+}
+
 func GetHashFromFile(file_path string) (string, error) {
 	f, err := os.Open(file_path)
 	if err != nil {
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type zipEntry struct {
+	name string
+	data string
+}
+
+func writeTestZip(t *testing.T, entries []zipEntry) string {
+	p := filepath.Join(t.TempDir(), "test.zip")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(e.data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestUnzip(t *testing.T) {
+	tests := []struct {
+		name     string
+		entries  []zipEntry
+		maxBytes int64
+		files    []string
+		err      bool
+	}{
+		{"files and dirs", []zipEntry{{"a.txt", "aaa"}, {"dir/", ""}, {"dir/b.txt", "bbb"}}, 0, []string{"a.txt", "dir/b.txt"}, false},
+		{"parent traversal", []zipEntry{{"../evil.txt", "x"}}, 0, nil, true},
+		{"nested traversal", []zipEntry{{"dir/../../evil.txt", "x"}}, 0, nil, true},
+		{"absolute path", []zipEntry{{"/tmp/evil.txt", "x"}}, 0, nil, true},
+		{"inner dots", []zipEntry{{"dir/../c.txt", "ccc"}}, 0, []string{"c.txt"}, false},
+		{"under the limit", []zipEntry{{"a.txt", "aaa"}, {"b.txt", "bbb"}}, 6, []string{"a.txt", "b.txt"}, false},
+		{"over the limit", []zipEntry{{"a.txt", "aaa"}, {"b.txt", "bbbb"}}, 6, nil, true},
+		{"bomb", []zipEntry{{"bomb.txt", strings.Repeat("0", 1000000)}}, 1000, nil, true},
+	}
+
+	for _, tt := range tests {
+		dest := t.TempDir()
+		err := Unzip(writeTestZip(t, tt.entries), filepath.Join(dest, "out"), tt.maxBytes)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: Unzip() error = %v, want error %v", tt.name, err, tt.err)
+			continue
+		}
+
+		if _, err := os.Stat(filepath.Join(dest, "evil.txt")); err == nil {
+			t.Errorf("%s: Unzip() wrote outside the destination", tt.name)
+		}
+		for _, f := range tt.files {
+			if _, err := os.Stat(filepath.Join(dest, "out", f)); err != nil {
+				t.Errorf("%s: Unzip() missing %s: %v", tt.name, f, err)
+			}
+		}
+	}
+}
+
+func TestUnzipTooBig(t *testing.T) {
+	p := writeTestZip(t, []zipEntry{{"bomb.txt", strings.Repeat("0", 10000)}})
+	if err := Unzip(p, t.TempDir(), 100); !errors.Is(err, ErrUnzipTooBig) {
+		t.Errorf("Unzip() error = %v, want %v", err, ErrUnzipTooBig)
+	}
+}
@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"strings"
+)
+
+// ibanLengths maps the country code to the expected IBAN size
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16,
+	"BG": 22, "BH": 22, "BR": 29, "BY": 28, "CH": 21, "CR": 22, "CY": 28,
+	"CZ": 24, "DE": 22, "DK": 18, "DO": 28, "EE": 20, "EG": 29, "ES": 24,
+	"FI": 18, "FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23, "GL": 18,
+	"GR": 27, "GT": 28, "HR": 21, "HU": 28, "IE": 22, "IL": 23, "IQ": 23,
+	"IS": 26, "IT": 27, "JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LC": 32,
+	"LI": 21, "LT": 20, "LU": 20, "LV": 21, "MC": 27, "MD": 24, "ME": 22,
+	"MK": 19, "MR": 27, "MT": 31, "MU": 30, "NL": 18, "NO": 15, "PK": 24,
+	"PL": 28, "PS": 29, "PT": 25, "QA": 29, "RO": 24, "RS": 22, "SA": 24,
+	"SC": 31, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "ST": 25, "SV": 28,
+	"TL": 23, "TN": 24, "TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20,
+}
+
+// NormalizeIBAN removes separators and converts the IBAN to upper case
+func NormalizeIBAN(iban string) string {
+	iban = strings.ToUpper(iban)
+	iban = strings.Replace(iban, " ", "", -1)
+	iban = strings.Replace(iban, "-", "", -1)
+	return iban
+}
+
+// ValidateIBAN checks the country length and the ISO 13616 mod-97 checksum
+func ValidateIBAN(iban string) bool {
+	iban = NormalizeIBAN(iban)
+
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+
+	if size, ok := ibanLengths[iban[0:2]]; !ok || size != len(iban) {
+		return false
+	}
+
+	// Move the four initial characters to the end and compute
+	// the remainder digit by digit to avoid big numbers
+	rearranged := iban[4:] + iban[0:4]
+	remainder := 0
+	for _, c := range rearranged {
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			v := int(c-'A') + 10
+			remainder = (remainder*100 + v) % 97
+		default:
+			return false
+		}
+	}
+
+	return remainder == 1
+}
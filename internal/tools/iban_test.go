@@ -0,0 +1,29 @@
+package tools
+
+import (
+	"testing"
+)
+
+func TestValidateIBAN(t *testing.T) {
+	tests := []struct {
+		iban string
+		want bool
+	}{
+		{"GB82WEST12345698765432", true},
+		{"GB82 WEST 1234 5698 7654 32", true},
+		{"gb82-west-1234-5698-7654-32", true},
+		{"DE89370400440532013000", true},
+		{"BR1800360305000010009795493C1", true},
+		{"GB82WEST12345698765433", false},
+		{"GB82WEST1234569876543", false},
+		{"XX82WEST12345698765432", false},
+		{"GB82WEST1234569876543!", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidateIBAN(tt.iban); got != tt.want {
+			t.Errorf("ValidateIBAN(%q) = %v, want %v", tt.iban, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package tools
+
+import (
+	"strconv"
+)
+
+// ValidateSSN checks the structural rules of a US Social Security Number
+// (area, group and serial ranges). SSNs have no checksum digit.
+func ValidateSSN(ssn string) bool {
+	ssn = cleanCpf.ReplaceAllString(ssn, "")
+	if len(ssn) != 9 {
+		return false
+	}
+
+	area, _ := strconv.Atoi(ssn[0:3])
+	group, _ := strconv.Atoi(ssn[3:5])
+	serial, _ := strconv.Atoi(ssn[5:9])
+
+	if area == 0 || area == 666 || area >= 900 {
+		return false
+	}
+
+	if group == 0 || serial == 0 {
+		return false
+	}
+
+	// Well known advertising/sample numbers
+	if ssn == "078051120" || ssn == "219099999" || ssn == "123456789" {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,29 @@
+package tools
+
+import (
+	"testing"
+)
+
+func TestValidateSSN(t *testing.T) {
+	tests := []struct {
+		ssn  string
+		want bool
+	}{
+		{"536-22-1234", true},
+		{"536221234", true},
+		{"000-22-1234", false},
+		{"666-22-1234", false},
+		{"900-22-1234", false},
+		{"536-00-1234", false},
+		{"536-22-0000", false},
+		{"078-05-1120", false},
+		{"123-45-6789", false},
+		{"536-22-123", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidateSSN(tt.ssn); got != tt.want {
+			t.Errorf("ValidateSSN(%q) = %v, want %v", tt.ssn, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package tools
 
-import "time"
+import (
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Float64ToTime takes a float64 as number of seconds since unix epoch and returns time.Time
 //
@@ -14,3 +18,45 @@ func Float64ToTime(f float64) time.Time {
 	}
 	return time.Unix(0, int64(f*float64(time.Second)))
 }
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC822Z,
+	time.RFC822,
+	time.ANSIC,
+}
+
+// ParseTime parses dates commonly found at leak dumps (ISO 8601, SQL, RFC 1123 and
+// unix epoch in seconds or milliseconds). Numeric day/month dates (dd/mm/yyyy or
+// mm/dd/yyyy) are ambiguous, so they are not parsed.
+func ParseTime(s string) (time.Time, bool) {
+	s = strings.Trim(s, " \r\n\t\"'")
+	if s == "" {
+		return time.Time{}, false
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+
+	if epoch, err := strconv.ParseInt(s, 10, 64); err == nil {
+		switch {
+		case epoch > 100000000000 && epoch < 10000000000000:
+			return time.UnixMilli(epoch).UTC(), true
+		case epoch > 100000000 && epoch < 10000000000:
+			return time.Unix(epoch, 0).UTC(), true
+		}
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+		ok    bool
+	}{
+		{"2024-03-05T10:20:30Z", time.Date(2024, 3, 5, 10, 20, 30, 0, time.UTC), true},
+		{"2024-03-05 10:20:30", time.Date(2024, 3, 5, 10, 20, 30, 0, time.UTC), true},
+		{"\"2024-03-05\"", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), true},
+		{"Tue, 05 Mar 2024 10:20:30 +0000", time.Date(2024, 3, 5, 10, 20, 30, 0, time.UTC), true},
+		{"1709634030", time.Date(2024, 3, 5, 10, 20, 30, 0, time.UTC), true},
+		{"1709634030000", time.Date(2024, 3, 5, 10, 20, 30, 0, time.UTC), true},
+
+		// Ambiguous day/month order
+		{"05/03/2024", time.Time{}, false},
+		{"03/05/2024 10:20:30", time.Time{}, false},
+
+		// Numbers that are not epochs
+		{"12345", time.Time{}, false},
+		{"", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseTime(tt.value)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
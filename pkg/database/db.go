@@ -79,6 +79,10 @@ func Connection(uri string, shouldExist, debug bool) (*gorm.DB, error) {
 		&models.URL{},
 		&models.Email{},
 		&models.Credential{},
+		&models.Secret{},
+		&models.PII{},
+		&models.Wallet{},
+		&models.Cookie{},
 		&Application{},
 	); err != nil {
 		return nil, err
@@ -0,0 +1,171 @@
+package downloaders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/helviojunior/intelparser/pkg/ixapi"
+	"github.com/helviojunior/intelparser/pkg/log"
+)
+
+// IntelX API paths consuming credits
+const (
+	IntelXPathSearch    = "/intelligent/search"
+	IntelXPathExport    = "/intelligent/search/export"
+	IntelXPathPhonebook = "/phonebook/search"
+	IntelXPathFileRead  = "/file/read"
+)
+
+// ErrNoCredits is returned when the API key has no credits left for the search
+var ErrNoCredits = errors.New("IntelX credits exhausted")
+
+// IntelXAccount keeps the capabilities and the remaining credits of an API key
+type IntelXAccount struct {
+	Name     string // Key name at the config file
+	APIURL   string // Empty for the default endpoint
+	ProxyURL string // Proxy to use
+
+	// Pause until the credits are reset, instead of failing
+	Wait bool
+	// Interval between the credit checks while paused
+	WaitInterval time.Duration
+
+	// Nil if the API key info is not available
+	Info *ixapi.AuthenticateInfo
+
+	apiKey string
+	ctx    context.Context
+	mutex  sync.Mutex
+}
+
+func NewIntelXAccount(apiKey string) *IntelXAccount {
+	return &IntelXAccount{
+		WaitInterval: 15 * time.Minute,
+		apiKey:       apiKey,
+		ctx:          context.Background(),
+		mutex:        sync.Mutex{},
+	}
+}
+
+// Refresh loads the capabilities and credits of the API key
+func (acc *IntelXAccount) Refresh() error {
+	api := ixapi.IntelligenceXAPI{
+		ProxyURL: acc.ProxyURL,
+	}
+	api.Init(acc.APIURL, acc.apiKey)
+
+	info, err := api.AuthenticateInfo(acc.ctx)
+	if err != nil {
+		return err
+	}
+
+	acc.mutex.Lock()
+	defer acc.mutex.Unlock()
+
+	acc.Info = &info
+	return nil
+}
+
+// APIKey returns the API key of the account
+func (acc *IntelXAccount) APIKey() string {
+	return acc.apiKey
+}
+
+// Credit returns the remaining credits of the path, ok is false if there is no credit info of the path
+func (acc *IntelXAccount) Credit(path string) (credit int, max int, ok bool) {
+	acc.mutex.Lock()
+	defer acc.mutex.Unlock()
+
+	if acc.Info == nil {
+		return 0, 0, false
+	}
+
+	path = strings.Trim(strings.ToLower(path), "/")
+	for k, p := range acc.Info.Paths {
+		if strings.Trim(strings.ToLower(k), "/") == path {
+			return p.Credit, p.CreditMax, true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// Print logs the allowed buckets and the remaining credits of the paths
+func (acc *IntelXAccount) Print(paths ...string) {
+	if acc.Info == nil {
+		return
+	}
+
+	buckets := append([]string{}, acc.Info.Buckets...)
+	sort.Strings(buckets)
+	log.Info("IntelX allowed buckets", "buckets", strings.Join(buckets, ", "))
+
+	for _, p := range paths {
+		if credit, max, ok := acc.Credit(p); ok {
+			log.Info("IntelX credits", "path", p, "credits", fmt.Sprintf("%d/%d", credit, max))
+		}
+	}
+}
+
+// Check returns ErrNoCredits if any of the paths has less than need credits.
+// Paths without credit info are not checked.
+func (acc *IntelXAccount) Check(need int, paths ...string) error {
+	for _, p := range paths {
+		if credit, _, ok := acc.Credit(p); ok && credit < need {
+			return fmt.Errorf("%w: %s has %d credits, %d needed", ErrNoCredits, p, credit, need)
+		}
+	}
+
+	return nil
+}
+
+// WaitCredits refreshes the credits and checks there are credits for the paths,
+// pausing until the credits are reset if Wait is set
+func (acc *IntelXAccount) WaitCredits(paths ...string) error {
+	if err := acc.Refresh(); err != nil {
+		log.Debug("Error refreshing IntelX credits", "err", err)
+		return nil
+	}
+
+	err := acc.Check(1, paths...)
+	if err == nil || !acc.Wait {
+		return err
+	}
+
+	return acc.Pause(paths...)
+}
+
+// Pause waits until the paths have credits again, checking them every WaitInterval
+func (acc *IntelXAccount) Pause(paths ...string) error {
+	for {
+		log.Warn("IntelX credits exhausted, pausing", "until", time.Now().Add(acc.WaitInterval).Format("2006-01-02 15:04:05"))
+
+		select {
+		case <-acc.ctx.Done():
+			return acc.ctx.Err()
+		case <-time.After(acc.WaitInterval):
+		}
+
+		if err := acc.Refresh(); err != nil {
+			// Without the credit info, resume and let the API decide
+			log.Debug("Error refreshing IntelX credits", "err", err)
+			return nil
+		}
+
+		if acc.Check(1, paths...) == nil {
+			log.Info("IntelX credits available, resuming")
+			return nil
+		}
+	}
+}
+
+// limitReached returns true if the request failed by the API daily limits
+// and the account pauses until the credits are reset
+func limitReached(acc *IntelXAccount, err error) bool {
+	return acc != nil && acc.Wait && errors.Is(err, ixapi.ErrDailyLimit)
+}
@@ -5,6 +5,7 @@ import (
     "errors"
     "context"
     "sync"
+    "sync/atomic"
     "time"
     "path/filepath"
     "strings"
@@ -23,6 +24,7 @@ import (
     tty "golang.org/x/term"
 
     "github.com/helviojunior/intelparser/internal/ascii"
+    "github.com/helviojunior/intelparser/internal/disk"
     "github.com/helviojunior/intelparser/internal/tools"
     "github.com/helviojunior/intelparser/pkg/database"
     "github.com/helviojunior/intelparser/pkg/ixapi"
@@ -36,19 +38,90 @@ var csvExludedFields = []string{"near_text"}
 
 var byteSizes = []string{"B", "kB"}
 
+// IntelXSort maps the --sort names to the IntelX sort orders
+var IntelXSort = map[string]int{
+	"none":        ixapi.SortNone,
+	"xscore-asc":  ixapi.SortXScoreAsc,
+	"xscore-desc": ixapi.SortXScoreDesc,
+	"date-asc":    ixapi.SortDateAsc,
+	"date-desc":   ixapi.SortDateDesc,
+}
+
+// IntelXMedia maps the --media names to the IntelX media types
+var IntelXMedia = map[string]int{
+	"all":          0,
+	"paste":        1,
+	"paste-user":   2,
+	"forum":        3,
+	"forum-board":  4,
+	"forum-thread": 5,
+	"forum-post":   6,
+	"forum-user":   7,
+	"screenshot":   8,
+	"html-copy":    9,
+	"tweet":        13,
+	"url":          14,
+	"pdf":          15,
+	"word":         16,
+	"excel":        17,
+	"powerpoint":   18,
+	"picture":      19,
+	"audio":        20,
+	"video":        21,
+	"container":    22,
+	"html":         23,
+	"text":         24,
+}
+
 type IntelXDownloader struct {
 	Term string
 	ZipFile string
 	Threads int
 	ProxyURL string // Proxy to use+
+	APIURL string // Empty for the default endpoint
 	Limit int 
 
+	// Search filters
+	DateFrom time.Time
+	DateTo time.Time // zero for now
+	Buckets []string
+	Media int
+	Sort int
+	MaxResults int // 0 for all results, IntelX applies it per bucket
+
+	// Download only the items not seen at the previous runs of the term,
+	// the state is stored at the StateDbURI database. With StateDbURI set the
+	// downloaded items are always stored, and PerItem skips the ones present there
+	SinceLast bool
+	StateDbURI string
+
+	// Account pauses the download when the credits are exhausted, if its Wait is set
+	Account *IntelXAccount
+
+	// Download the items one by one (file/read) instead of the ZIP export,
+	// with Threads workers and Retries attempts per item
+	PerItem bool
+	Retries int
+
+	// OnDownload is called with each downloaded file and its search result,
+	// the file is kept at the temp folder until Clean is called
+	OnDownload func(item ixapi.SearchResult, file_path string)
+
+	// Merger receives the downloaded files instead of the ZIP file, merging several terms
+	Merger *IntelXMerger
+
+	// Do not print the progress, e.g. when several downloaders run together
+	HideStatus bool
+
 	apiKey string
 	ctx    context.Context
 	dbName string
 	conn          *gorm.DB
 	mutex         sync.Mutex
 	tempFolder string
+	seen map[string]bool
+	items map[string]bool // system ids, storage ids and hashes downloaded by PerItem
+	local map[string]bool // system ids and storage ids present at the StateDbURI database
 
 	status *IntelXDownloaderStatus
 
@@ -59,12 +132,26 @@ type IntelXDownloaderStatus struct {
 	TotalFiles int
 	Downloaded int
 	Duplicated int
+	Failed int
+	Shared int // Already downloaded by another term, when merging
 	TotalBytes int64
 	StateBytes int64
 	Spin string
 	Step string
 	Running bool
     IsTerminal bool
+    Err error // Error stopping the download
+}
+
+// Add sums the counters of another download, e.g. of several terms
+func (st *IntelXDownloaderStatus) Add(o *IntelXDownloaderStatus) {
+	st.TotalFiles += o.TotalFiles
+	st.Downloaded += o.Downloaded
+	st.Duplicated += o.Duplicated
+	st.Failed += o.Failed
+	st.Shared += o.Shared
+	st.TotalBytes += o.TotalBytes
+	st.StateBytes += o.StateBytes
 }
 
 func (st *IntelXDownloaderStatus) Print() { 
@@ -102,32 +189,29 @@ func NewIntelXDownloader(term string, apiKey string, outZipFile string) (*IntelX
     }
 
     dbName := filepath.Join(tempFolder, "info.sqlite3")
-    log.Debug("Creating info database", "path", dbName)
-	c, err := database.Connection("sqlite:///"+ dbName, false, false)
+	c, err := newInfoDb(dbName)
 	if err != nil {
 		return nil, err
 	}
 
-	// run database migrations on the connection
-	if err := c.AutoMigrate(
-		&ixapi.Tag{},
-		&ixapi.Relationship{},
-		//&ixapi.Item{},
-		&ixapi.SearchResult{},
-		&ixapi.PanelSearchResultTag{},
-	); err != nil {
-		return nil, err
-	}
-
 	return &IntelXDownloader{
 		Term:     	term,
 		ZipFile:    outZipFile,
 		Threads:    3,
 		Limit:      1000,
+		DateFrom:   time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
+		Buckets:    []string{},
+		Media:      0,
+		Sort:       ixapi.SortDateDesc,
+		MaxResults: 0,
+		Retries:    3,
 		apiKey: 	apiKey,
 		dbName: 	dbName,
 		conn: 		c,
 		tempFolder: tempFolder,
+		seen:       map[string]bool{},
+		items:      map[string]bool{},
+		local:      map[string]bool{},
 		ctx: 		context.Background(),
 		mutex:      sync.Mutex{},
 		results:    make(chan ixapi.SearchResult),
@@ -144,20 +228,79 @@ func NewIntelXDownloader(term string, apiKey string, outZipFile string) (*IntelX
 	}, nil
 }
 
+// newInfoDb creates the database of the search results, stored as info.sqlite3 at the ZIP file
+func newInfoDb(dbName string) (*gorm.DB, error) {
+    log.Debug("Creating info database", "path", dbName)
+	c, err := database.Connection("sqlite:///"+ dbName, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// run database migrations on the connection
+	if err := c.AutoMigrate(
+		&ixapi.Tag{},
+		&ixapi.Relationship{},
+		//&ixapi.Item{},
+		&ixapi.SearchResult{},
+		&ixapi.PanelSearchResultTag{},
+	); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Status returns a copy of the download status, it is safe to call while running
+func (dwn *IntelXDownloader) Status() IntelXDownloaderStatus {
+	dwn.mutex.Lock()
+	defer dwn.mutex.Unlock()
+
+	return *dwn.status
+}
+
+// setStatus updates the download status holding the lock, as the status
+// is read by other goroutines (e.g. the progress of several terms)
+func (dwn *IntelXDownloader) setStatus(update func(st *IntelXDownloaderStatus)) {
+	dwn.mutex.Lock()
+	defer dwn.mutex.Unlock()
+
+	update(dwn.status)
+}
+
+func (dwn *IntelXDownloader) setStep(step string) {
+	dwn.setStatus(func(st *IntelXDownloaderStatus) { st.Step = step })
+}
+
+// fail sets the error stopping the download, returning the status
+func (dwn *IntelXDownloader) fail(err error) *IntelXDownloaderStatus {
+	dwn.setStatus(func(st *IntelXDownloaderStatus) { st.Err = err })
+	return dwn.status
+}
+
+func (dwn *IntelXDownloader) running() bool {
+	return dwn.Status().Running
+}
+
 func (dwn *IntelXDownloader) Run() *IntelXDownloaderStatus { 
 
 	defer dwn.Close()
-	defer dwn.ClearScreen()
+	if !dwn.HideStatus {
+		defer dwn.ClearScreen()
+		ascii.HideCursor()
+	}
 
-	ascii.HideCursor()
 	go func() {
-		for dwn.status.Running {
+		spin := ""
+		for !dwn.HideStatus && dwn.running() {
 			select {
 				case <-dwn.ctx.Done():
 					return
 				default:
-		        	dwn.status.Print()
-		        	if dwn.status.IsTerminal {
+					st := dwn.Status()
+					st.Spin = spin
+		        	st.Print()
+		        	spin = st.Spin
+		        	if st.IsTerminal {
                         time.Sleep(time.Duration(time.Second / 4))
                     }else{
                         time.Sleep(time.Duration(time.Second * 10))
@@ -166,73 +309,53 @@ func (dwn *IntelXDownloader) Run() *IntelXDownloaderStatus {
         }
     }()
 
-	r := true
-	for r {
-		c, err := dwn.SearchNext()
-		if err != nil {
-			return dwn.status
-		}
-
-		if c == 0 || c <= int(float64(dwn.Limit) * 0.95){
-			r = false
-		}
+	if err := dwn.Search(); err != nil {
+		return dwn.fail(err)
 	}
 
-	if dwn.status.TotalFiles == 0 {
-		log.Warn("No result found")
+	var items int64
+	dwn.conn.Model(&ixapi.SearchResult{}).Count(&items)
+
+	if dwn.Status().TotalFiles == 0 {
+		log.Warn("No result found", "term", dwn.Term)
+	}else if items == 0 {
+		log.Warn("No new result since the last run", "term", dwn.Term)
+	}else if dwn.Merger != nil {
+	    dwn.setStep("Merging")
+	    if err := dwn.Merger.Add(dwn); err != nil {
+	        log.Error("Error merging files", "term", dwn.Term, "err", err)
+	        return dwn.fail(err)
+	    }
 	}else{
 
-		dwn.status.Clear()
+		if !dwn.HideStatus {
+			dwn.status.Clear()
+		}
 		log.Info("Writting Info.csv")
-	    dwn.status.Step = "Info.csv"
+	    dwn.setStep("Info.csv")
 	    err := dwn.WriteInfoCsv()
 	    if err != nil {
 	        log.Error("Error writting Info.csv", "err", err)
-	        return dwn.status
+	        return dwn.fail(err)
 	    }
 
 	    //Compress   
-	    dwn.status.Clear()
-	    log.Info("Compressing files")
-	    dwn.status.Step = "Compressing"
-	    log.Debug("Destination", "zip", dwn.ZipFile)
-
-	    entries, err := os.ReadDir(dwn.tempFolder)
-	    if err != nil {
-	        log.Error("Error getting file list from temp folder", "err", err)
-	        return dwn.status
+	    if !dwn.HideStatus {
+	        dwn.status.Clear()
 	    }
-	 
-	 	archive, err := os.Create(dwn.ZipFile)
-	    if err != nil {
-	        log.Error("Error creating zip file", "err", err)
-	        return dwn.status
+	    log.Info("Compressing files")
+	    dwn.setStep("Compressing")
+	    if err := compressFolder(dwn.tempFolder, dwn.ZipFile); err != nil {
+	        return dwn.fail(err)
 	    }
-	    defer archive.Close()
-	    zipWriter := zip.NewWriter(archive)
-
-	    for _, e := range entries {
-	    	log.Debug("Compressing", "file", e.Name())
-	        f1, err := os.Open(filepath.Join(dwn.tempFolder, e.Name()))
-		    if err != nil {
-		        log.Error("Error openning file", "file", e.Name(), "err", err)
-		    }else{
-			    defer f1.Close()
-
-			    w1, err := zipWriter.Create(e.Name())
-			    if err != nil {
-			        log.Error("Error creatting file at Zip container", "file", e.Name(), "err", err)
-			    }else{
-				    if _, err := io.Copy(w1, f1); err != nil {
-				        log.Error("Error copping file data to Zip container", "file", e.Name(), "err", err)
-				    }
-				}
-			}
 
-	    }
-	    zipWriter.Close()
+	    log.Info("Leaks saved", "term", dwn.Term, "files", dwn.Status().TotalFiles, "zip", dwn.ZipFile)
+	}
 
-	    log.Info("Leaks saved", "files", dwn.status.TotalFiles, "zip", dwn.ZipFile)
+	if dwn.SinceLast || dwn.StateDbURI != "" {
+		if err := dwn.SaveState(); err != nil {
+			log.Error("Error saving watch state", "err", err)
+		}
 	}
 
     tools.RemoveFolder(dwn.tempFolder)
@@ -240,13 +363,83 @@ func (dwn *IntelXDownloader) Run() *IntelXDownloaderStatus {
 	return dwn.status
 }
 
+// Search lists the term items and downloads them to the temp folder, page by page
+func (dwn *IntelXDownloader) Search() error {
+	if dwn.SinceLast {
+		if err := dwn.LoadState(); err != nil {
+			log.Error("Error loading watch state", "err", err)
+			return err
+		}
+	}
+
+	r := true
+	for r {
+		c, err := dwn.SearchNext()
+		if err != nil {
+			return err
+		}
+
+		// Only the searches sorted by date can be paged by the date range
+		if c == 0 || c <= int(float64(dwn.Limit) * 0.95) || (dwn.Sort != ixapi.SortDateDesc && dwn.Sort != ixapi.SortDateAsc) {
+			r = false
+		}
+	}
+
+	return nil
+}
+
+// compressFolder writes the files of the folder to the ZIP file
+func compressFolder(folder string, zipFile string) error {
+	log.Debug("Destination", "zip", zipFile)
+
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		log.Error("Error getting file list from temp folder", "err", err)
+		return err
+	}
+
+	archive, err := os.Create(zipFile)
+	if err != nil {
+		log.Error("Error creating zip file", "err", err)
+		return err
+	}
+	defer archive.Close()
+	zipWriter := zip.NewWriter(archive)
+
+	for _, e := range entries {
+		log.Debug("Compressing", "file", e.Name())
+		f1, err := os.Open(filepath.Join(folder, e.Name()))
+		if err != nil {
+			log.Error("Error openning file", "file", e.Name(), "err", err)
+		}else{
+			defer f1.Close()
+
+			w1, err := zipWriter.Create(e.Name())
+			if err != nil {
+				log.Error("Error creatting file at Zip container", "file", e.Name(), "err", err)
+			}else{
+				if _, err := io.Copy(w1, f1); err != nil {
+					log.Error("Error copping file data to Zip container", "file", e.Name(), "err", err)
+				}
+			}
+		}
+	}
+
+	return zipWriter.Close()
+}
+
 func (dwn *IntelXDownloader) WriteInfoCsv() error {
-	file, err := os.OpenFile(filepath.Join(dwn.tempFolder, "Info.csv"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	return writeInfoCsv(dwn.dbName, dwn.tempFolder)
+}
+
+// writeInfoCsv writes the Info.csv of the search results database to the folder
+func writeInfoCsv(dbName string, folder string) error {
+	file, err := os.OpenFile(filepath.Join(folder, "Info.csv"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
 		return err
 	}
 
-	c, err := database.Connection("sqlite:///"+ dwn.dbName, false, false)
+	c, err := database.Connection("sqlite:///"+ dbName, false, false)
 	if err != nil {
 		log.Error("Error reconnecting to database", "err", err)
 		return err
@@ -281,6 +474,10 @@ func (dwn *IntelXDownloader) WriteInfoCsv() error {
 		switch strings.ToLower(name) {
 		case "systemid":
 			name = "System ID"
+		case "storageid":
+			name = "Storage ID"
+		case "searchterm":
+			name = "Search Term"
 		}
 
 		fieldNames = append(fieldNames, name)
@@ -291,9 +488,10 @@ func (dwn *IntelXDownloader) WriteInfoCsv() error {
 	}
 
 	//Write content
-	var item ixapi.SearchResult
     for rows.Next() {
+        var item ixapi.SearchResult
         c.ScanRows(rows, &item)
+        loadItemRelations(c, &item)
 
         // get values from the item
 		val := reflect.ValueOf(*item.GetCsv())
@@ -365,7 +563,13 @@ func (dwn *IntelXDownloader) DownloadResult(api *ixapi.IntelligenceXAPI, searchI
         return err
     }
 
-    if err = tools.Unzip(fileName, dst); err != nil {
+    // The extracted files may not exceed the free space of the temp path
+    var maxBytes int64
+    if di, err := disk.GetInfo(dwn.tempFolder, false); err == nil {
+        maxBytes = int64(di.Free)
+    }
+
+    if err = tools.Unzip(fileName, dst, maxBytes); err != nil {
         logger.Debug("Error extracting zip file", "temp_folder", dst, "err", err)
         return err
     }
@@ -387,8 +591,17 @@ func (dwn *IntelXDownloader) DownloadResult(api *ixapi.IntelligenceXAPI, searchI
 
         if e.Name() != "Info.csv" {
         	id := strings.Replace(e.Name(), filepath.Ext(e.Name()), "", 1)
-        	dwn.conn.Raw("UPDATE intex_result_item SET filename = ?, downloaded = 1 WHERE system_id = ?", e.Name(), id)
-        	dwn.status.Downloaded++
+        	if dwn.Seen(id) {
+        		logger.Debug("Already downloaded at a previous run", "file", e.Name())
+        		continue
+        	}
+        	dwn.mutex.Lock()
+        	err = dwn.conn.Model(&ixapi.SearchResult{}).Where("lower(system_id) = ?", strings.ToLower(id)).Updates(map[string]interface{}{"filename": e.Name(), "downloaded": true}).Error
+        	dwn.mutex.Unlock()
+        	if err != nil {
+        		logger.Debug("Error updating item", "file", e.Name(), "err", err)
+        	}
+        	dwn.setStatus(func(st *IntelXDownloaderStatus) { st.Downloaded++ })
         }else{
         	dstFileName = filepath.Join(dwn.tempFolder, "info_orig_" + tools.SafeFileNameWithRnd(searchID.String()) + ".csv")
         }
@@ -396,6 +609,14 @@ func (dwn *IntelXDownloader) DownloadResult(api *ixapi.IntelligenceXAPI, searchI
 	    if err = os.Rename(filepath.Join(dst, e.Name()), dstFileName); err != nil {
 	        return err
 	    }
+
+	    if dwn.OnDownload != nil && e.Name() != "Info.csv" {
+	    	var item ixapi.SearchResult
+	    	id := strings.ToLower(strings.Replace(e.Name(), filepath.Ext(e.Name()), "", 1))
+	    	dwn.conn.Where("lower(system_id) = ?", id).Limit(1).Find(&item)
+	    	loadItemRelations(dwn.conn, &item)
+	    	dwn.OnDownload(item, dstFileName)
+	    }
     }
 
     tools.RemoveFolder(dst)
@@ -478,6 +699,9 @@ func (dwn *IntelXDownloader) ParseInfo(file_path string) error {
                         }
 
             res.SystemID = strings.ToLower(GetOrDefault(rec, SystemID, ""))
+            if dwn.Seen(res.SystemID) {
+            	continue
+            }
             res.Name = GetOrDefault(rec, Name, "")
             res.Bucket = GetOrDefault(rec, Bucket, "")
             res.MediaH = GetOrDefault(rec, Content, "")
@@ -490,6 +714,16 @@ func (dwn *IntelXDownloader) ParseInfo(file_path string) error {
     return nil
 }
 
+// loadItemRelations loads the tags and the related items of the search result
+func loadItemRelations(conn *gorm.DB, item *ixapi.SearchResult) {
+	if item.ID == 0 {
+		return
+	}
+
+	conn.Where("item_id = ?", item.ID).Find(&item.Tags)
+	conn.Where("item_id = ?", item.ID).Find(&item.Relations)
+}
+
 func GetOrDefault(data []string, index int, def string) string {
 	if index == -1 {
 		return def
@@ -503,8 +737,11 @@ func (dwn *IntelXDownloader) SearchNext() (int, error) {
 	var inserted int
 	var qty int
 
-	DateFrom = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	DateTo = time.Now().UTC()
+	DateFrom = dwn.DateFrom
+	DateTo = dwn.DateTo
+	if DateTo.IsZero() {
+		DateTo = time.Now().UTC()
+	}
 
 	wg := sync.WaitGroup{}
 	logger := log.With("term", dwn.Term)
@@ -514,10 +751,16 @@ func (dwn *IntelXDownloader) SearchNext() (int, error) {
 		ProxyURL: dwn.ProxyURL,
 	}
 
-	api.Init("", dwn.apiKey)
+	api.Init(dwn.APIURL, dwn.apiKey)
+
+	// The next page starts at the oldest (or newest, at ascending sort) date already listed
+	edge := "min"
+	if dwn.Sort == ixapi.SortDateAsc {
+		edge = "max"
+	}
 
 	qty = 0
-	response := dwn.conn.Raw("SELECT count(`id`) as qty, min(`date`) as min_date from intex_result_item")
+	response := dwn.conn.Raw("SELECT count(`id`) as qty, " + edge + "(`date`) as edge_date from intex_result_item")
     if response != nil {
     	log.Debug("Response...")
     	
@@ -528,19 +771,57 @@ func (dwn *IntelXDownloader) SearchNext() (int, error) {
         	tDate = tDate[0:10]
         	mDate, err = time.Parse("2006-01-02", tDate)
         	if err == nil {
-        		DateTo = mDate.AddDate(0, 0, 1)
+        		if dwn.Sort == ixapi.SortDateAsc {
+        			DateFrom = mDate
+        		}else{
+        			DateTo = mDate.AddDate(0, 0, 1)
+        		}
         	}
-        }else if dwn.status.TotalFiles > 0{
+        }else if dwn.Status().TotalFiles > 0{
         	log.Debug("Error", "err", err)
         	return 0, err
         }
     }
 
-    log.Info("Quering IntelX Api (" + strconv.Itoa(qty) + " -> " + strconv.Itoa(qty + dwn.Limit) + ")")
-    dwn.status.Step = "Searching"
+    limit := dwn.Limit
+    if dwn.MaxResults > 0 {
+    	if qty >= dwn.MaxResults {
+    		logger.Debug("Max results reached", "max_results", dwn.MaxResults)
+    		return 0, nil
+    	}
+    	if dwn.MaxResults - qty < limit {
+    		limit = dwn.MaxResults - qty
+    	}
+    }
+
+    log.Info("Quering IntelX Api (" + strconv.Itoa(qty) + " -> " + strconv.Itoa(qty + limit) + ")", "term", dwn.Term)
+    dwn.setStep("Searching")
 
     logger.Debug("Search time", "DateFrom", DateFrom, "DateTo", DateTo)
-	searchID, results, selectorInvalid, err := api.SearchWithDates(dwn.ctx, dwn.Term, DateFrom, DateTo, ixapi.SortDateDesc, dwn.Limit, ixapi.DefaultWaitSortTime, ixapi.DefaultTimeoutGetResults)
+	var searchID *uuid.UUID
+	var results []ixapi.SearchResult
+	var selectorInvalid bool
+	var err error
+	for {
+		searchID, results, selectorInvalid, err = api.SearchWithRequest(dwn.ctx, ixapi.IntelligentSearchRequest{
+				Term: dwn.Term,
+				Buckets: dwn.Buckets,
+				MaxResults: limit,
+				DateFrom: DateFrom.Format("2006-01-02 15:04:05"),
+				DateTo: DateTo.Format("2006-01-02 15:04:05"),
+				Sort: dwn.Sort,
+				Media: dwn.Media,
+			}, ixapi.DefaultWaitSortTime, ixapi.DefaultTimeoutGetResults)
+
+		if !limitReached(dwn.Account, err) {
+			break
+		}
+		dwn.setStep("Paused")
+		if dwn.Account.Pause(IntelXPathSearch) != nil {
+			break
+		}
+		dwn.setStep("Searching")
+	}
 
 	if err != nil && selectorInvalid {
 		logger.Error("Invalid input selector. Please specify a strong selector")
@@ -555,11 +836,15 @@ func (dwn *IntelXDownloader) SearchNext() (int, error) {
 		return 0, err
 	}
 
-	dwn.status.TotalFiles += len(results)
+	dwn.setStatus(func(st *IntelXDownloaderStatus) { st.TotalFiles += len(results) })
 
 	if len(results) > 0 {
 		logger.Debug("Results", "qty", len(results))
 
+		for i := range results {
+			results[i].Term = dwn.Term
+		}
+
 		dwn.results = make(chan ixapi.SearchResult)
 	    go func() {
 	    	defer close(dwn.results)
@@ -574,23 +859,28 @@ func (dwn *IntelXDownloader) SearchNext() (int, error) {
 		    go func() {
 		        defer wg.Done()
 		        
-		        for dwn.status.Running {
+		        for dwn.running() {
 					select {
 					case <-dwn.ctx.Done():
 						return
 					case record, ok := <-dwn.results:
-						if !ok || !dwn.status.Running {
+						if !ok || !dwn.running() {
 							return
 						}
 						
 						logger.Debug("Reg", "did", record.SystemID)
 
+						if dwn.Seen(record.SystemID) {
+							dwn.setStatus(func(st *IntelXDownloaderStatus) { st.Duplicated++ })
+							continue
+						}
+
 						i, _ := dwn.WriteDb(&record, true)
 
 						if i {
 							inserted++
 						}else{
-							dwn.status.Duplicated++
+							dwn.setStatus(func(st *IntelXDownloaderStatus) { st.Duplicated++ })
 						}
 					}
 				}
@@ -600,29 +890,48 @@ func (dwn *IntelXDownloader) SearchNext() (int, error) {
 
 	    wg.Wait()
 
-	    log.Info("Downloading files")
-	    dwn.status.Step = "Downloading"
+	    log.Info("Downloading files", "term", dwn.Term)
+	    dwn.setStep("Downloading")
+
+	    if dwn.PerItem {
+	    	api.SearchTerminate(context.Background(), *searchID)
+	    	if err := dwn.DownloadItems(&api, results); err != nil {
+	    		return 0, err
+	    	}
+	    	return inserted, nil
+	    }
 
-	    downloading := true
+	    downloading := atomic.Bool{}
+	    downloading.Store(true)
 	    var dwn_error error
 	    wg.Add(1)
 		go func() {
 	    	defer wg.Done()
-			err := dwn.DownloadResult(&api, *searchID, dwn.Limit)
+			err := dwn.DownloadResult(&api, *searchID, limit)
+			for limitReached(dwn.Account, err) {
+				dwn.setStep("Paused")
+				if dwn.Account.Pause(IntelXPathExport) != nil {
+					break
+				}
+
+				// The search results are kept by the API, download them again after the pause
+				dwn.setStep("Downloading")
+				err = dwn.DownloadResult(&api, *searchID, limit)
+			}
 			if err != nil {
 				log.Error("Error downloading files", "err", err)
 				dwn_error = err
 			}
 			api.SearchTerminate(context.Background(), *searchID)
-			downloading = false
+			downloading.Store(false)
 		}()
 
 		wg.Add(1)
 		go func() {
 	    	defer wg.Done()
-			for downloading {
-				if api.WriteCounter.Total > 0 {
-					dwn.status.StateBytes = int64(api.WriteCounter.Total)
+			for downloading.Load() {
+				if n := api.WriteCounter.Bytes(); n > 0 {
+					dwn.setStatus(func(st *IntelXDownloaderStatus) { st.StateBytes = int64(n) })
 				}
 				time.Sleep(time.Duration(time.Second/4))
 			}
@@ -634,9 +943,11 @@ func (dwn *IntelXDownloader) SearchNext() (int, error) {
 	    	return 0, dwn_error
 	    }
 
-		if api.WriteCounter.Total > 0 {
-			dwn.status.StateBytes = 0
-			dwn.status.TotalBytes += int64(api.WriteCounter.Total)
+		if n := api.WriteCounter.Bytes(); n > 0 {
+			dwn.setStatus(func(st *IntelXDownloaderStatus) {
+				st.StateBytes = 0
+				st.TotalBytes += int64(n)
+			})
 		}
 	}
 
@@ -671,5 +982,10 @@ func (dwn *IntelXDownloader) WriteDb(result *ixapi.SearchResult, update bool) (b
 }
 
 func (dwn *IntelXDownloader) Close() {
-	dwn.status.Running = false
-}
\ No newline at end of file
+	dwn.setStatus(func(st *IntelXDownloaderStatus) { st.Running = false })
+}
+
+// Clean removes the temp folder with the downloaded files
+func (dwn *IntelXDownloader) Clean() {
+	tools.RemoveFolder(dwn.tempFolder)
+}
@@ -0,0 +1,571 @@
+package downloaders
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/helviojunior/intelparser/internal/tools"
+	"github.com/helviojunior/intelparser/pkg/database"
+	"github.com/helviojunior/intelparser/pkg/ixapi"
+	"github.com/helviojunior/intelparser/pkg/ixmock"
+	"github.com/helviojunior/intelparser/pkg/models"
+	"github.com/helviojunior/intelparser/pkg/runner"
+	"github.com/helviojunior/intelparser/pkg/runner/parsers"
+	"github.com/helviojunior/intelparser/pkg/writers"
+)
+
+const testAPIKey = "00000000-0000-0000-0000-000000000000"
+
+// mockAPI is an IntelX API mock counting the requests by path. The hook may answer
+// a request before the mock (e.g. with HTTP 402/429), returning true when it did
+type mockAPI struct {
+	*httptest.Server
+	hook     func(w http.ResponseWriter, r *http.Request, count int) bool
+	requests map[string]int
+	mutex    sync.Mutex
+}
+
+func newMockAPI(t *testing.T, fixtures map[string]string) *mockAPI {
+	folder := t.TempDir()
+	for name, data := range fixtures {
+		p := filepath.Join(folder, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// The item date is the file time, it must be before the search date range end
+		date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		if err := os.Chtimes(p, date, date); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srv, err := ixmock.NewServer(folder, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockAPI{ requests: map[string]int{} }
+	handler := srv.Handler()
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.mutex.Lock()
+		m.requests[r.URL.Path]++
+		count := m.requests[r.URL.Path]
+		hook := m.hook
+		m.mutex.Unlock()
+
+		if hook != nil && hook(w, r, count) {
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(m.Close)
+
+	return m
+}
+
+// Requests returns the count of requests of the path
+func (m *mockAPI) Requests(path string) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.requests[path]
+}
+
+// newTestDownloader returns a downloader of the mock writing the ZIP file to a temp folder
+func newTestDownloader(t *testing.T, m *mockAPI, term string) *IntelXDownloader {
+	dwn, err := NewIntelXDownloader(term, testAPIKey, filepath.Join(t.TempDir(), "ix.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dwn.APIURL = m.URL
+	dwn.HideStatus = true
+	t.Cleanup(dwn.Clean)
+
+	return dwn
+}
+
+// zipEntries returns the data of the ZIP file entries by name
+func zipEntries(t *testing.T, file_path string) map[string]string {
+	zr, err := zip.OpenReader(file_path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	entries := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		entries[f.Name] = string(data)
+	}
+
+	return entries
+}
+
+// itemFiles returns the count of item files (<system id><ext>) of the ZIP file entries
+func itemFiles(entries map[string]string) int {
+	n := 0
+	for name := range entries {
+		if name != "Info.csv" && name != "info.sqlite3" && !strings.HasPrefix(name, "info_orig_") {
+			n++
+		}
+	}
+	return n
+}
+
+var testFixtures = map[string]string{
+	"combo.txt": "alice@example.com:S3cretPass!\nbob@example.com:Hunter2024\n",
+	"copy.txt":  "alice@example.com:S3cretPass!\nbob@example.com:Hunter2024\n",
+	"paste.txt": "contact carol@example.com for access\n",
+	"other.txt": "nothing to see at other.org\n",
+}
+
+func TestIntelXDownloaderSearchStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		hook    func(w http.ResponseWriter, r *http.Request, count int) bool
+		files   int
+		results int // min result requests
+	}{
+		// The mock answers the first result request with status 3 (no results yet)
+		// and the last one with status 1 (no more results)
+		{"no results yet", nil, 3, 2},
+		{"more results", func(w http.ResponseWriter, r *http.Request, count int) bool {
+			// One record per page, status 0 until the last page
+			if r.URL.Path == "/intelligent/search/result" {
+				q := r.URL.Query()
+				q.Set("limit", "1")
+				r.URL.RawQuery = q.Encode()
+			}
+			return false
+		}, 3, 4},
+		{"search id not found", func(w http.ResponseWriter, r *http.Request, count int) bool {
+			if r.URL.Path == "/intelligent/search/result" {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"records":[],"status":2}`))
+				return true
+			}
+			return false
+		}, 0, 1},
+	}
+
+	for _, tt := range tests {
+		m := newMockAPI(t, testFixtures)
+		m.hook = tt.hook
+
+		dwn := newTestDownloader(t, m, "example.com")
+		status := dwn.Run()
+		if status.Err != nil {
+			t.Errorf("%s: Run() error = %v", tt.name, status.Err)
+			continue
+		}
+		if status.TotalFiles != tt.files {
+			t.Errorf("%s: TotalFiles = %d, want %d", tt.name, status.TotalFiles, tt.files)
+		}
+		if n := m.Requests("/intelligent/search/result"); n < tt.results {
+			t.Errorf("%s: %d result requests, want at least %d", tt.name, n, tt.results)
+		}
+		if _, err := os.Stat(dwn.ZipFile); (err == nil) != (tt.files > 0) {
+			t.Errorf("%s: ZIP file written = %v, want %v", tt.name, err == nil, tt.files > 0)
+		}
+	}
+}
+
+func TestIntelXDownloaderExport(t *testing.T) {
+	m := newMockAPI(t, testFixtures)
+	dwn := newTestDownloader(t, m, "example.com")
+
+	status := dwn.Run()
+	if status.Err != nil {
+		t.Fatalf("Run() error = %v", status.Err)
+	}
+	if status.Downloaded != 3 {
+		t.Errorf("Downloaded = %d, want 3", status.Downloaded)
+	}
+	if n := m.Requests("/intelligent/search/export"); n != 1 {
+		t.Errorf("%d export requests, want 1", n)
+	}
+
+	entries := zipEntries(t, dwn.ZipFile)
+	info, ok := entries["Info.csv"]
+	if !ok {
+		t.Fatalf("Info.csv not found at the ZIP file")
+	}
+
+	records, err := csv.NewReader(strings.NewReader(info)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("Info.csv has %d records, want 3 and the header", len(records) - 1)
+	}
+
+	header := map[string]int{}
+	for i, c := range records[0] {
+		header[c] = i
+	}
+	for _, col := range []string{"System ID", "Storage ID", "Search Term"} {
+		if _, ok := header[col]; !ok {
+			t.Errorf("Info.csv without the %s column", col)
+		}
+	}
+
+	if n := itemFiles(entries); n != 3 {
+		t.Errorf("ZIP file has %d item files, want 3", n)
+	}
+	for _, rec := range records[1:] {
+		if rec[header["Search Term"]] != "example.com" {
+			t.Errorf("Search Term = %q, want example.com", rec[header["Search Term"]])
+		}
+		if _, ok := entries[strings.ToLower(rec[header["System ID"]]) + ".txt"]; !ok {
+			t.Errorf("file of the item %s not found at the ZIP file", rec[header["System ID"]])
+		}
+	}
+}
+
+func TestIntelXDownloaderPerItem(t *testing.T) {
+	m := newMockAPI(t, testFixtures)
+	dwn := newTestDownloader(t, m, "example.com")
+	dwn.PerItem = true
+	dwn.Threads = 2
+
+	downloaded := []string{}
+	mutex := sync.Mutex{}
+	dwn.OnDownload = func(item ixapi.SearchResult, file_path string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		downloaded = append(downloaded, item.SystemID)
+	}
+
+	status := dwn.Run()
+	if status.Err != nil {
+		t.Fatalf("Run() error = %v", status.Err)
+	}
+
+	// combo.txt and copy.txt have the same data (and storage id)
+	if status.Downloaded != 2 || status.Duplicated != 1 || len(downloaded) != 2 {
+		t.Errorf("Downloaded = %d, Duplicated = %d, OnDownload = %d, want 2, 1, 2", status.Downloaded, status.Duplicated, len(downloaded))
+	}
+	if n := m.Requests("/file/read"); n != 2 {
+		t.Errorf("%d file/read requests, want 2", n)
+	}
+	if n := m.Requests("/intelligent/search/export"); n != 0 {
+		t.Errorf("%d export requests, want 0", n)
+	}
+	if n := itemFiles(zipEntries(t, dwn.ZipFile)); n != 2 {
+		t.Errorf("ZIP file has %d item files, want 2", n)
+	}
+}
+
+func TestIntelXDownloaderLimits(t *testing.T) {
+	paymentRequired := func(path string) func(w http.ResponseWriter, r *http.Request, count int) bool {
+		return func(w http.ResponseWriter, r *http.Request, count int) bool {
+			if r.URL.Path == path && count == 1 {
+				w.WriteHeader(http.StatusPaymentRequired)
+				return true
+			}
+			return false
+		}
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		hook     func(w http.ResponseWriter, r *http.Request, count int) bool
+		wait     bool
+		err      error
+		requests int
+	}{
+		{"search rate limit", IntelXPathSearch, func(w http.ResponseWriter, r *http.Request, count int) bool {
+			if r.URL.Path == IntelXPathSearch && count == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return true
+			}
+			return false
+		}, false, nil, 2},
+		{"export credits", IntelXPathExport, paymentRequired(IntelXPathExport), false, ixapi.ErrDailyLimit, 1},
+		{"export credits with wait", IntelXPathExport, paymentRequired(IntelXPathExport), true, nil, 2},
+		{"search credits with wait", IntelXPathSearch, paymentRequired(IntelXPathSearch), true, nil, 2},
+	}
+
+	for _, tt := range tests {
+		m := newMockAPI(t, testFixtures)
+		m.hook = tt.hook
+
+		dwn := newTestDownloader(t, m, "example.com")
+		if tt.wait {
+			dwn.Account = NewIntelXAccount(testAPIKey)
+			dwn.Account.APIURL = m.URL
+			dwn.Account.Wait = true
+			dwn.Account.WaitInterval = 10 * time.Millisecond
+		}
+
+		status := dwn.Run()
+		if !errors.Is(status.Err, tt.err) {
+			t.Errorf("%s: Run() error = %v, want %v", tt.name, status.Err, tt.err)
+		}
+		if n := m.Requests(tt.path); n != tt.requests {
+			t.Errorf("%s: %d %s requests, want %d", tt.name, n, tt.path, tt.requests)
+		}
+		if tt.err == nil && status.Downloaded != 3 {
+			t.Errorf("%s: Downloaded = %d, want 3", tt.name, status.Downloaded)
+		}
+	}
+}
+
+// Only the downloaded items are stored as seen, the others are downloaded at the next run
+func TestIntelXDownloaderSinceLast(t *testing.T) {
+	tests := []struct {
+		name    string
+		perItem bool
+		failing bool
+		seen    int64
+	}{
+		{"export", false, false, 3},
+		{"per item", true, false, 3},
+		{"per item errors", true, true, 0},
+	}
+
+	for _, tt := range tests {
+		m := newMockAPI(t, testFixtures)
+		if tt.failing {
+			m.hook = func(w http.ResponseWriter, r *http.Request, count int) bool {
+				if r.URL.Path == IntelXPathFileRead {
+					w.WriteHeader(http.StatusInternalServerError)
+					return true
+				}
+				return false
+			}
+		}
+
+		stateDb := "sqlite:///" + filepath.Join(t.TempDir(), "state.db")
+		dwn := newTestDownloader(t, m, "example.com")
+		dwn.SinceLast = true
+		dwn.StateDbURI = stateDb
+		dwn.PerItem = tt.perItem
+		dwn.Retries = 0
+		if status := dwn.Run(); status.Err != nil {
+			t.Fatalf("%s: Run() error = %v", tt.name, status.Err)
+		}
+
+		c, err := dwn.watchConnection()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var seen int64
+		c.Model(&IntelXWatchItem{}).Count(&seen)
+		if seen != tt.seen {
+			t.Errorf("%s: %d items seen, want %d", tt.name, seen, tt.seen)
+		}
+
+		// The items not downloaded are searched again
+		m.hook = nil
+		next := newTestDownloader(t, m, "example.com")
+		next.SinceLast = true
+		next.StateDbURI = stateDb
+		next.PerItem = tt.perItem
+		status := next.Run()
+		if status.Err != nil {
+			t.Fatalf("%s: Run() error = %v", tt.name, status.Err)
+		}
+		if tt.failing && status.Downloaded != 2 {
+			t.Errorf("%s: Downloaded = %d at the next run, want 2", tt.name, status.Downloaded)
+		}
+		if !tt.failing && status.Downloaded != 0 {
+			t.Errorf("%s: Downloaded = %d at the next run, want 0", tt.name, status.Downloaded)
+		}
+	}
+}
+
+// The items downloaded at previous runs or parsed into the state database are not downloaded again
+func TestIntelXDownloaderPerItemLocal(t *testing.T) {
+	m := newMockAPI(t, testFixtures)
+	stateDb := "sqlite:///" + filepath.Join(t.TempDir(), "state.db")
+
+	downloaded := []ixapi.SearchResult{}
+	mutex := sync.Mutex{}
+	dwn := newTestDownloader(t, m, "example.com")
+	dwn.PerItem = true
+	dwn.StateDbURI = stateDb
+	dwn.OnDownload = func(item ixapi.SearchResult, file_path string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		downloaded = append(downloaded, item)
+	}
+	if status := dwn.Run(); status.Err != nil || status.Downloaded != 2 {
+		t.Fatalf("Run() = %d downloaded, error %v, want 2", status.Downloaded, status.Err)
+	}
+
+	next := newTestDownloader(t, m, "other term")
+	next.PerItem = true
+	next.StateDbURI = stateDb
+	if status := next.Run(); status.Err != nil || status.Downloaded != 0 {
+		t.Errorf("Run() at the next run = %d downloaded, error %v, want 0", status.Downloaded, status.Err)
+	}
+	if n := m.Requests(IntelXPathFileRead); n != 2 {
+		t.Errorf("%d file/read requests, want 2", n)
+	}
+
+	// A file parsed from an IntelX download, at a new state database
+	parsedDb := filepath.Join(t.TempDir(), "parsed.db")
+	c, err := database.Connection("sqlite:///" + parsedDb, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AutoMigrate(&models.File{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Create(&models.File{ Provider: "IntelX", ProviderId: downloaded[0].SystemID, StorageID: downloaded[0].StorageID, Fingerprint: "parsed" }).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	parsed := newTestDownloader(t, m, "example.com")
+	parsed.PerItem = true
+	parsed.StateDbURI = "sqlite:///" + parsedDb
+	if status := parsed.Run(); status.Err != nil || status.Downloaded != 1 {
+		t.Errorf("Run() with a parsed file = %d downloaded, error %v, want 1", status.Downloaded, status.Err)
+	}
+}
+
+func TestIntelXMerger(t *testing.T) {
+	m := newMockAPI(t, map[string]string{
+		"alice.txt": "alice@example.com:S3cretPass!\n",
+		"carol.txt": "carol@example.com:Qwerty2024\n",
+		"other.txt": "dave@other.org:Passw0rd\n",
+	})
+
+	merger, err := NewIntelXMerger(filepath.Join(t.TempDir(), "merged.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shared := []int{}
+	for _, term := range []string{"alice@example.com", "example.com", "other.org"} {
+		dwn := newTestDownloader(t, m, term)
+		dwn.Merger = merger
+		status := dwn.Run()
+		if status.Err != nil {
+			t.Fatalf("%s: Run() error = %v", term, status.Err)
+		}
+		shared = append(shared, status.Shared)
+	}
+
+	if shared[0] != 0 || shared[1] != 1 || shared[2] != 0 {
+		t.Errorf("Shared = %v, want [0 1 0]", shared)
+	}
+	if merger.Items() != 3 {
+		t.Errorf("Items() = %d, want 3", merger.Items())
+	}
+
+	if err := merger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := zipEntries(t, merger.ZipFile)
+	if n := itemFiles(entries); n != 3 {
+		t.Errorf("merged ZIP file has %d item files, want 3", n)
+	}
+	records, err := csv.NewReader(strings.NewReader(entries["Info.csv"])).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 {
+		t.Errorf("merged Info.csv has %d records, want 3", len(records) - 1)
+	}
+}
+
+// testWriter keeps the results of the runner
+type testWriter struct {
+	results []*models.File
+	mutex   sync.Mutex
+}
+
+func (w *testWriter) Write(result *models.File) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.results = append(w.results, result)
+	return nil
+}
+
+// The exported ZIP file is parsed by the intelx driver, as parse intelx does
+func TestIntelXExportParse(t *testing.T) {
+	m := newMockAPI(t, testFixtures)
+	dwn := newTestDownloader(t, m, "example.com")
+	if status := dwn.Run(); status.Err != nil {
+		t.Fatalf("Run() error = %v", status.Err)
+	}
+
+	folder := t.TempDir()
+	if err := tools.Unzip(dwn.ZipFile, folder, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := runner.NewDefaultOptions()
+	opts.Logging.Silence = true
+	opts.Writer.GlobalDbURI = "sqlite:///" + filepath.Join(t.TempDir(), "missing.db")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	driver, err := parsers.NewInteX(logger, *opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := &testWriter{}
+	run, err := runner.NewRunner(logger, driver, *opts, []writers.Writer{writer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := runner.FileItem{ RealPath: filepath.Join(folder, "Info.csv"), VirtualPath: "Info.csv" }
+	if err := run.ParsePositionalFile(index); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		defer close(run.Files)
+		for _, item := range driver.IndexFiles(index) {
+			run.Files <- item
+		}
+	}()
+	run.Run()
+
+	if len(writer.results) != 3 {
+		t.Fatalf("%d results, want 3", len(writer.results))
+	}
+
+	credentials := 0
+	for _, r := range writer.results {
+		if !strings.HasPrefix(r.Bucket, "IntelX » ") || r.ProviderId == "" {
+			t.Errorf("result %s without the Info.csv data: bucket %q, provider id %q", r.FileName, r.Bucket, r.ProviderId)
+		}
+		if r.SearchTerm != "example.com" {
+			t.Errorf("result %s SearchTerm = %q, want example.com", r.FileName, r.SearchTerm)
+		}
+		for _, c := range r.Credentials {
+			if c.Username == "alice@example.com" && c.Password == "S3cretPass!" {
+				credentials++
+			}
+		}
+	}
+	if credentials != 2 {
+		t.Errorf("alice@example.com credential found at %d files, want 2", credentials)
+	}
+}
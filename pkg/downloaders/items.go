@@ -0,0 +1,221 @@
+package downloaders
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/helviojunior/intelparser/internal/tools"
+	"github.com/helviojunior/intelparser/pkg/ixapi"
+	"github.com/helviojunior/intelparser/pkg/log"
+	"github.com/helviojunior/intelparser/pkg/models"
+)
+
+// DownloadItems downloads the records one by one (file/read) with a pool of Threads workers,
+// writing the same files of the ZIP export (<system id><ext>) to the temp folder
+func (dwn *IntelXDownloader) DownloadItems(api *ixapi.IntelligenceXAPI, records []ixapi.SearchResult) error {
+	dwn.loadLocal(records)
+
+	items := make(chan ixapi.SearchResult)
+	wg := sync.WaitGroup{}
+
+	go func() {
+		defer close(items)
+		for _, record := range records {
+			items <- record
+		}
+	}()
+
+	for w := 0; w < dwn.Threads; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for item := range items {
+				if !dwn.running() {
+					continue
+				}
+
+				if err := dwn.downloadItem(api, item); err != nil {
+					log.Error("Error downloading item", "did", item.SystemID, "err", err)
+					dwn.mutex.Lock()
+					dwn.status.Failed++
+					dwn.mutex.Unlock()
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	return nil
+}
+
+// downloadItem downloads a record, skipping the ones already present locally (same system id, storage id or data)
+func (dwn *IntelXDownloader) downloadItem(api *ixapi.IntelligenceXAPI, item ixapi.SearchResult) error {
+	id := strings.ToLower(item.SystemID)
+	logger := log.With("did", id)
+
+	if dwn.Seen(id) {
+		logger.Debug("Already downloaded at a previous run")
+		return nil
+	}
+
+	if dwn.local[id] || (item.StorageID != "" && dwn.local[item.StorageID]) {
+		logger.Debug("Already present locally", "storageid", item.StorageID)
+		dwn.mutex.Lock()
+		dwn.setDuplicated(id)
+		dwn.mutex.Unlock()
+		return nil
+	}
+
+	if item.StorageID == "" {
+		logger.Debug("Item without storage id, it cannot be downloaded")
+		return nil
+	}
+
+	dwn.mutex.Lock()
+	if dwn.items[id] || dwn.items[item.StorageID] {
+		dwn.status.Duplicated++
+		dwn.mutex.Unlock()
+		logger.Debug("Already downloaded", "storageid", item.StorageID)
+		return nil
+	}
+	dwn.items[id] = true
+	dwn.items[item.StorageID] = true
+	dwn.mutex.Unlock()
+
+	var data []byte
+	var err error
+	for attempt := 0; ; attempt++ {
+		data, err = api.FileRead(dwn.ctx, &item.Item, api.HTTPMaxResponseSize)
+		if err == nil {
+			break
+		}
+
+		if limitReached(dwn.Account, err) {
+			if dwn.Account.Pause(IntelXPathFileRead) != nil {
+				break
+			}
+			attempt--
+			continue
+		}
+
+		if attempt >= dwn.Retries {
+			break
+		}
+
+		logger.Debug("Error reading item, retrying", "attempt", attempt+1, "err", err)
+		time.Sleep(time.Duration(attempt+1) * time.Second)
+	}
+
+	if err != nil {
+		// Allow another record with the same data to be downloaded
+		dwn.mutex.Lock()
+		delete(dwn.items, id)
+		delete(dwn.items, item.StorageID)
+		dwn.mutex.Unlock()
+		return err
+	}
+
+	hash := tools.GetHash(data)
+
+	dwn.mutex.Lock()
+	if dwn.items[hash] {
+		dwn.status.Duplicated++
+		dwn.setDuplicated(id)
+		dwn.mutex.Unlock()
+		logger.Debug("Same data already downloaded", "hash", hash)
+		return nil
+	}
+	dwn.items[hash] = true
+	dwn.mutex.Unlock()
+
+	fileName := id + item.GetExtension()
+	filePath := filepath.Join(dwn.tempFolder, fileName)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return err
+	}
+
+	dwn.mutex.Lock()
+	dwn.conn.Model(&ixapi.SearchResult{}).Where("lower(system_id) = ?", id).Updates(map[string]interface{}{"filename": fileName, "downloaded": true})
+	dwn.status.Downloaded++
+	dwn.status.TotalBytes += int64(len(data))
+	dwn.mutex.Unlock()
+
+	if dwn.OnDownload != nil {
+		dwn.OnDownload(item, filePath)
+	}
+
+	return nil
+}
+
+// setDuplicated flags the item as downloaded, without a file as its data is at another
+// item (same hash) or present locally, so it is stored as seen. The caller must hold dwn.mutex
+func (dwn *IntelXDownloader) setDuplicated(id string) {
+	dwn.conn.Model(&ixapi.SearchResult{}).Where("lower(system_id) = ?", id).Update("downloaded", true)
+}
+
+// loadLocal looks up the StateDbURI database for the records downloaded at previous runs,
+// of any term, or parsed from an IntelX download, so they are not downloaded again
+func (dwn *IntelXDownloader) loadLocal(records []ixapi.SearchResult) {
+	if dwn.StateDbURI == "" {
+		return
+	}
+
+	c, err := dwn.watchConnection()
+	if err != nil {
+		log.Debug("Error opening the state database, downloading all items", "err", err)
+		return
+	}
+	parsed := c.Migrator().HasTable(&models.File{})
+
+	// Batches keep the query parameters under the SQLite limit
+	for i := 0; i < len(records); i += 500 {
+		ids := []string{}
+		storageIDs := []string{}
+		for _, r := range records[i:min(i + 500, len(records))] {
+			ids = append(ids, strings.ToLower(r.SystemID))
+			if r.StorageID != "" {
+				storageIDs = append(storageIDs, r.StorageID)
+			}
+		}
+
+		var seen []string
+		if err := c.Model(&IntelXWatchItem{}).Where("system_id IN ?", ids).Pluck("system_id", &seen).Error; err != nil {
+			log.Debug("Error querying the watch items", "err", err)
+		}
+		for _, id := range seen {
+			dwn.local[id] = true
+		}
+
+		if !parsed {
+			continue
+		}
+		var files []models.File
+		if err := c.Model(&models.File{}).Select("provider_id", "storage_id").
+			Where("provider = ? AND failed = ? AND (lower(provider_id) IN ? OR storage_id IN ?)", "IntelX", false, ids, storageIDs).
+			Find(&files).Error; err != nil {
+			log.Debug("Error querying the parsed files", "err", err)
+		}
+		for _, f := range files {
+			if f.ProviderId != "" {
+				dwn.local[strings.ToLower(f.ProviderId)] = true
+			}
+			if f.StorageID != "" {
+				dwn.local[f.StorageID] = true
+			}
+		}
+	}
+
+	local := 0
+	for _, r := range records {
+		if dwn.local[strings.ToLower(r.SystemID)] || (r.StorageID != "" && dwn.local[r.StorageID]) {
+			local++
+		}
+	}
+	if local > 0 {
+		log.Info("Skipping the items present locally", "term", dwn.Term, "items", local)
+	}
+}
@@ -0,0 +1,192 @@
+package downloaders
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gofrs/uuid"
+	"github.com/helviojunior/intelparser/pkg/ixapi"
+	"github.com/helviojunior/intelparser/pkg/log"
+)
+
+// IntelXKey is a named API key of the config file
+type IntelXKey struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	URL  string `json:"url"` // Empty to select the endpoint automatically
+}
+
+// IntelXConfig is the IntelX section of the config file
+type IntelXConfig struct {
+	Keys []IntelXKey `json:"keys"`
+}
+
+type configFile struct {
+	IntelX IntelXConfig `json:"intelx"`
+}
+
+// LoadIntelXConfig reads the IntelX keys of the config file, a missing file has no keys
+func LoadIntelXConfig(path string) (*IntelXConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &IntelXConfig{Keys: []IntelXKey{}}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var cfg configFile
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	for i, k := range cfg.IntelX.Keys {
+		if _, err := uuid.FromString(k.Key); err != nil {
+			return nil, fmt.Errorf("invalid key %q at config file %s: %w", k.Name, path, err)
+		}
+		if k.Name == "" {
+			cfg.IntelX.Keys[i].Name = fmt.Sprintf("key%d", i+1)
+		}
+	}
+
+	return &cfg.IntelX, nil
+}
+
+// Key returns the key with the name, nil if not found
+func (cfg *IntelXConfig) Key(name string) *IntelXKey {
+	for i, k := range cfg.Keys {
+		if strings.EqualFold(k.Name, name) {
+			return &cfg.Keys[i]
+		}
+	}
+
+	return nil
+}
+
+// ResolveURL selects the endpoint of the API key when APIURL is empty, trying the known
+// IntelX endpoints (e.g. free keys only work at free.intelx.io)
+func (acc *IntelXAccount) ResolveURL() error {
+	if acc.APIURL != "" {
+		return nil
+	}
+
+	var err error
+	for _, u := range ixapi.APIURLs {
+		acc.APIURL = u
+		if err = acc.Refresh(); err == nil {
+			log.Debug("IntelX endpoint selected", "key", acc.Name, "url", u)
+			return nil
+		}
+		log.Debug("IntelX endpoint not available for the key", "key", acc.Name, "url", u, "err", err)
+	}
+
+	acc.APIURL = ""
+	return err
+}
+
+// IntelXKeyRing selects the API key of each search, rotating across the keys if Rotate is set.
+// The keys without credits are skipped.
+type IntelXKeyRing struct {
+	Accounts []*IntelXAccount
+	Rotate   bool
+
+	current int
+	mutex   sync.Mutex
+}
+
+func NewIntelXKeyRing(accounts []*IntelXAccount, rotate bool) *IntelXKeyRing {
+	return &IntelXKeyRing{
+		Accounts: accounts,
+		Rotate:   rotate,
+		current:  0,
+		mutex:    sync.Mutex{},
+	}
+}
+
+// Check returns ErrNoCredits if the keys have, together, less than need credits of any of the paths.
+// Only the first key is used without Rotate.
+func (r *IntelXKeyRing) Check(need int, paths ...string) error {
+	accounts := r.Accounts
+	if !r.Rotate {
+		accounts = accounts[:1]
+	}
+
+	for _, p := range paths {
+		total := 0
+		known := false
+		for _, acc := range accounts {
+			if credit, _, ok := acc.Credit(p); ok {
+				total += credit
+				known = true
+			}
+		}
+		if known && total < need {
+			return fmt.Errorf("%w: %s has %d credits, %d needed", ErrNoCredits, p, total, need)
+		}
+	}
+
+	return nil
+}
+
+// Acquire returns the key to use at the next search. With Rotate, the next key with
+// credits is returned, otherwise the current key, pausing if its Wait is set.
+func (r *IntelXKeyRing) Acquire(paths ...string) (*IntelXAccount, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.Rotate || len(r.Accounts) == 1 {
+		acc := r.Accounts[r.current]
+		return acc, acc.WaitCredits(paths...)
+	}
+
+	var err error
+	for i := 1; i <= len(r.Accounts); i++ {
+		idx := (r.current + i) % len(r.Accounts)
+		acc := r.Accounts[idx]
+
+		if err = acc.Refresh(); err != nil {
+			log.Debug("Error refreshing IntelX credits", "key", acc.Name, "err", err)
+		} else if err = acc.Check(1, paths...); err != nil {
+			log.Debug("No credits left at the key", "key", acc.Name, "err", err)
+			continue
+		}
+
+		r.current = idx
+		return acc, nil
+	}
+
+	// All keys without credits, pause on the current one
+	acc := r.Accounts[r.current]
+	if !acc.Wait {
+		return acc, err
+	}
+
+	return acc, acc.Pause(paths...)
+}
+
+// MaxConcurrentSearches returns how many searches the keys can still start together,
+// 0 if the keys info is not available. Only the first key is used without Rotate.
+func (r *IntelXKeyRing) MaxConcurrentSearches() int {
+	accounts := r.Accounts
+	if !r.Rotate {
+		accounts = accounts[:1]
+	}
+
+	total := 0
+	for _, acc := range accounts {
+		acc.mutex.Lock()
+		if acc.Info != nil && acc.Info.MaxConcurrentSearches > 0 {
+			free := acc.Info.MaxConcurrentSearches - acc.Info.SearchesActive
+			if free < 1 {
+				free = 1
+			}
+			total += free
+		}
+		acc.mutex.Unlock()
+	}
+
+	return total
+}
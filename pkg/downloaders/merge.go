@@ -0,0 +1,138 @@
+package downloaders
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/helviojunior/intelparser/internal/tools"
+	"github.com/helviojunior/intelparser/pkg/ixapi"
+	"github.com/helviojunior/intelparser/pkg/log"
+	"gorm.io/gorm"
+)
+
+// IntelXMerger merges the downloads of several terms into one ZIP file (and info database),
+// keeping only once the items found by more than one term
+type IntelXMerger struct {
+	ZipFile string
+
+	dbName     string
+	conn       *gorm.DB
+	tempFolder string
+	terms      map[string]string // system id -> first term with the item
+	files      int
+	mutex      sync.Mutex
+}
+
+func NewIntelXMerger(outZipFile string) (*IntelXMerger, error) {
+	tempFolder, err := tools.CreateDir(tools.TempFileName("", "intelparser_", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	dbName := filepath.Join(tempFolder, "info.sqlite3")
+	c, err := newInfoDb(dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IntelXMerger{
+		ZipFile:    outZipFile,
+		dbName:     dbName,
+		conn:       c,
+		tempFolder: tempFolder,
+		terms:      map[string]string{},
+		mutex:      sync.Mutex{},
+	}, nil
+}
+
+// Add moves the search results and files of the downloader to the merged folder.
+// The items already added by another term are counted as Shared and skipped.
+func (m *IntelXMerger) Add(dwn *IntelXDownloader) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var records []ixapi.SearchResult
+	if err := dwn.conn.Find(&records).Error; err != nil {
+		return err
+	}
+
+	claimed := map[string]bool{}
+	for _, rec := range records {
+		id := strings.ToLower(rec.SystemID)
+		if term, ok := m.terms[id]; ok {
+			log.Debug("Item already downloaded by another term", "did", id, "term", term)
+			dwn.setStatus(func(st *IntelXDownloaderStatus) { st.Shared++ })
+			continue
+		}
+
+		// New ids at the merged database, the tags and relations are linked again on create
+		loadItemRelations(dwn.conn, &rec)
+		rec.ID = 0
+		for i := range rec.Tags {
+			rec.Tags[i].ID = 0
+		}
+		for i := range rec.Relations {
+			rec.Relations[i].ID = 0
+		}
+		if err := m.conn.Create(&rec).Error; err != nil {
+			return err
+		}
+		m.terms[id] = dwn.Term
+		claimed[id] = true
+	}
+
+	entries, err := os.ReadDir(dwn.tempFolder)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		id := strings.ToLower(strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())))
+		if e.IsDir() || !claimed[id] {
+			continue
+		}
+
+		if err := tools.MoveFile(filepath.Join(dwn.tempFolder, e.Name()), filepath.Join(m.tempFolder, e.Name())); err != nil {
+			return err
+		}
+		m.files++
+	}
+
+	log.Debug("Term merged", "term", dwn.Term, "items", len(claimed), "shared", dwn.Status().Shared)
+	return nil
+}
+
+// Items returns the count of unique items merged
+func (m *IntelXMerger) Items() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return len(m.terms)
+}
+
+// Close writes the Info.csv and the ZIP file with the merged items, removing the temp folder
+func (m *IntelXMerger) Close() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	defer tools.RemoveFolder(m.tempFolder)
+
+	if len(m.terms) == 0 {
+		log.Warn("No result to merge")
+		return nil
+	}
+
+	log.Info("Writting merged Info.csv")
+	if err := writeInfoCsv(m.dbName, m.tempFolder); err != nil {
+		return err
+	}
+
+	log.Info("Compressing merged files")
+	if err := compressFolder(m.tempFolder, m.ZipFile); err != nil {
+		return err
+	}
+
+	log.Info("Leaks saved", "items", len(m.terms), "files", m.files, "zip", m.ZipFile)
+	return nil
+}
@@ -0,0 +1,188 @@
+package downloaders
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/helviojunior/intelparser/pkg/ixapi"
+	"github.com/helviojunior/intelparser/pkg/log"
+	"github.com/helviojunior/intelparser/pkg/models"
+)
+
+// IntelXPhonebookTarget maps the --target names to the IntelX phonebook targets
+var IntelXPhonebookTarget = map[string]int{
+	"all":     ixapi.PhonebookTargetAll,
+	"domains": ixapi.PhonebookTargetDomains,
+	"emails":  ixapi.PhonebookTargetEmails,
+	"urls":    ixapi.PhonebookTargetURLs,
+}
+
+// IntelXPhonebook lists the emails, domains and URLs known by IntelX for a term
+type IntelXPhonebook struct {
+	Term     string
+	ProxyURL string // Proxy to use
+	APIURL   string // Empty for the default endpoint
+
+	// Search filters
+	DateFrom   time.Time // zero for all dates
+	DateTo     time.Time // zero for now
+	Buckets    []string
+	Media      int
+	Sort       int
+	Target     int
+	MaxResults int
+
+	// Account pauses the search when the credits are exhausted, if its Wait is set
+	Account *IntelXAccount
+
+	apiKey string
+	ctx    context.Context
+}
+
+func NewIntelXPhonebook(term string, apiKey string) *IntelXPhonebook {
+	return &IntelXPhonebook{
+		Term:       term,
+		Buckets:    []string{},
+		Sort:       ixapi.SortXScoreDesc,
+		Target:     ixapi.PhonebookTargetAll,
+		MaxResults: 10000,
+		apiKey:     apiKey,
+		ctx:        context.Background(),
+	}
+}
+
+// Search queries the phonebook, returning the selectors without duplicates
+func (pb *IntelXPhonebook) Search() ([]ixapi.PhonebookSelector, error) {
+	logger := log.With("term", pb.Term)
+
+	api := ixapi.IntelligenceXAPI{
+		ProxyURL: pb.ProxyURL,
+	}
+	api.Init(pb.APIURL, pb.apiKey)
+
+	request := ixapi.PhonebookSearchRequest{
+		IntelligentSearchRequest: ixapi.IntelligentSearchRequest{
+			Term:       pb.Term,
+			Buckets:    pb.Buckets,
+			MaxResults: pb.MaxResults,
+			Sort:       pb.Sort,
+			Media:      pb.Media,
+		},
+		Target: pb.Target,
+	}
+
+	// Both dates are required if set
+	if !pb.DateFrom.IsZero() || !pb.DateTo.IsZero() {
+		to := pb.DateTo
+		if to.IsZero() {
+			to = time.Now().UTC()
+		}
+		request.DateFrom = pb.DateFrom.Format("2006-01-02 15:04:05")
+		request.DateTo = to.Format("2006-01-02 15:04:05")
+	}
+
+	log.Info("Quering IntelX Phonebook", "term", pb.Term)
+	_, selectors, selectorInvalid, err := api.PhonebookSearch(pb.ctx, request, ixapi.DefaultWaitSortTime, ixapi.DefaultTimeoutGetResults)
+	for limitReached(pb.Account, err) {
+		if pb.Account.Pause(IntelXPathPhonebook) != nil {
+			break
+		}
+		_, selectors, selectorInvalid, err = api.PhonebookSearch(pb.ctx, request, ixapi.DefaultWaitSortTime, ixapi.DefaultTimeoutGetResults)
+	}
+	if err != nil && selectorInvalid {
+		logger.Error("Invalid input selector. Please specify a strong selector")
+		log.Warn(textSupportedSelectors)
+		return nil, err
+	} else if err != nil {
+		logger.Error("Error querying results", "err", err)
+		return nil, err
+	}
+
+	unique := map[string]bool{}
+	result := []ixapi.PhonebookSelector{}
+	for _, s := range selectors {
+		s.Selectorvalue = strings.Trim(s.Selectorvalue, " \r\n\t")
+		key := strings.ToLower(s.Selectorvalue)
+		if key == "" || unique[key] {
+			continue
+		}
+		unique[key] = true
+		result = append(result, s)
+	}
+
+	logger.Debug("Phonebook results", "qty", len(selectors), "unique", len(result))
+	return result, nil
+}
+
+// ToFile converts the selectors to a result with the emails and URLs, domains are stored as URLs
+func (pb *IntelXPhonebook) ToFile(selectors []ixapi.PhonebookSelector) *models.File {
+	now := time.Now()
+	values := []string{}
+	for _, s := range selectors {
+		values = append(values, s.Selectorvalue)
+	}
+	sort.Strings(values)
+	content := strings.Join(values, "\n")
+
+	h := sha1.New()
+	h.Write([]byte(strings.ToLower(pb.Term) + "\n" + content))
+
+	name := "phonebook_" + strings.ToLower(strings.Trim(pb.Term, " "))
+	file := &models.File{
+		Provider:    "IntelX",
+		FilePath:    "intelx_phonebook/" + name + ".txt",
+		FileName:    name + ".txt",
+		Name:        "Phonebook: " + pb.Term,
+		Date:        now,
+		Bucket:      "Phonebook",
+		MediaType:   "Phonebook",
+		IndexedAt:   now,
+		Size:        uint(len(content)),
+		MIMEType:    "text/plain",
+		Fingerprint: hex.EncodeToString(h.Sum(nil)),
+		Content:     content,
+		SearchTerm:  pb.Term,
+		Emails:      []models.Email{},
+		URLs:        []models.URL{},
+	}
+
+	for _, s := range selectors {
+		switch s.Selectortype {
+		case ixapi.SelectorTypeEmail:
+			m, err := mail.ParseAddress(s.Selectorvalue)
+			if err != nil {
+				log.Debug("Invalid email selector", "value", s.Selectorvalue, "err", err)
+				continue
+			}
+			file.Emails = append(file.Emails, models.Email{
+				Time:   now,
+				Domain: strings.ToLower(strings.SplitN(m.Address, "@", 2)[1]),
+				Email:  m.Address,
+			})
+		case ixapi.SelectorTypeDomain:
+			file.URLs = append(file.URLs, models.URL{
+				Time:   now,
+				Domain: strings.ToLower(s.Selectorvalue),
+				Url:    s.Selectorvalue,
+			})
+		case ixapi.SelectorTypeURL:
+			domain := ""
+			if u, err := url.Parse(s.Selectorvalue); err == nil {
+				domain = strings.ToLower(u.Hostname())
+			}
+			file.URLs = append(file.URLs, models.URL{
+				Time:   now,
+				Domain: domain,
+				Url:    s.Selectorvalue,
+			})
+		}
+	}
+
+	return file
+}
@@ -0,0 +1,153 @@
+package downloaders
+
+import (
+	"strings"
+	"time"
+
+	"github.com/helviojunior/intelparser/pkg/database"
+	"github.com/helviojunior/intelparser/pkg/ixapi"
+	"github.com/helviojunior/intelparser/pkg/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IntelXWatch is the monitoring state of a search term, stored at the control database
+type IntelXWatch struct {
+	ID       uint      `json:"id" gorm:"primarykey"`
+	Term     string    `json:"term" gorm:"uniqueIndex"`
+	LastDate time.Time `json:"last_date"` // Date of the newest item seen
+	LastRun  time.Time `json:"last_run"`
+	Items    int       `json:"items"`
+
+	Seen []IntelXWatchItem `json:"seen" gorm:"foreignKey:WatchID;constraint:OnDelete:CASCADE"`
+}
+
+func (IntelXWatch) TableName() string {
+	return "intelx_watch"
+}
+
+// IntelXWatchItem is an item (system id) already downloaded for the term
+type IntelXWatchItem struct {
+	ID       uint      `json:"id" gorm:"primarykey"`
+	WatchID  uint      `json:"watch_id" gorm:"uniqueIndex:idx_watch_item"`
+	SystemID string    `json:"system_id" gorm:"uniqueIndex:idx_watch_item"`
+	Date     time.Time `json:"date"`
+}
+
+func (IntelXWatchItem) TableName() string {
+	return "intelx_watch_item"
+}
+
+// watchConnection opens the control database with the watch tables
+func (dwn *IntelXDownloader) watchConnection() (*gorm.DB, error) {
+	c, err := database.Connection(dwn.StateDbURI, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.AutoMigrate(
+		&IntelXWatch{},
+		&IntelXWatchItem{},
+	); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// LoadState restricts the search to the items newer than the last run of the term
+func (dwn *IntelXDownloader) LoadState() error {
+	c, err := dwn.watchConnection()
+	if err != nil {
+		return err
+	}
+
+	var watch IntelXWatch
+	res := c.Where("term = ?", watchTerm(dwn.Term)).Limit(1).Find(&watch)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		log.Debug("No watch state for the term, searching all items", "term", dwn.Term)
+		return nil
+	}
+
+	var ids []string
+	if err := c.Model(&IntelXWatchItem{}).Where("watch_id = ?", watch.ID).Pluck("system_id", &ids).Error; err != nil {
+		return err
+	}
+	for _, id := range ids {
+		dwn.seen[id] = true
+	}
+
+	// One day back, as the items of the last date may be indexed later.
+	// Items already seen are ignored.
+	from := watch.LastDate.AddDate(0, 0, -1)
+	if from.After(dwn.DateFrom) {
+		dwn.DateFrom = from
+	}
+
+	log.Info("Searching items since the last run", "term", dwn.Term, "last_run", watch.LastRun.Format(time.RFC3339), "date_from", dwn.DateFrom.Format("2006-01-02"), "seen", len(ids))
+	return nil
+}
+
+// SaveState stores the items downloaded at this run as seen, with the ones sharing
+// their storage id. The others (export limit or errors) are searched again at the next run
+func (dwn *IntelXDownloader) SaveState() error {
+	c, err := dwn.watchConnection()
+	if err != nil {
+		return err
+	}
+
+	var items []ixapi.SearchResult
+	if err := dwn.conn.Model(&ixapi.SearchResult{}).Select("system_id", "date").
+		Where("downloaded = ? OR storage_id IN (?)", true, dwn.conn.Model(&ixapi.SearchResult{}).Select("storage_id").Where("downloaded = ? AND storage_id <> ''", true)).
+		Find(&items).Error; err != nil {
+		return err
+	}
+
+	var watch IntelXWatch
+	if err := c.Where(IntelXWatch{Term: watchTerm(dwn.Term)}).FirstOrCreate(&watch).Error; err != nil {
+		return err
+	}
+
+	seen := []IntelXWatchItem{}
+	for _, it := range items {
+		id := strings.ToLower(it.SystemID)
+		if id == "" || dwn.seen[id] {
+			continue
+		}
+		if it.Date.After(watch.LastDate) {
+			watch.LastDate = it.Date
+		}
+		seen = append(seen, IntelXWatchItem{WatchID: watch.ID, SystemID: id, Date: it.Date})
+	}
+
+	if len(seen) > 0 {
+		if err := c.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(seen, 200).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, it := range seen {
+		dwn.seen[it.SystemID] = true
+	}
+
+	var count int64
+	if err := c.Model(&IntelXWatchItem{}).Where("watch_id = ?", watch.ID).Count(&count).Error; err != nil {
+		return err
+	}
+
+	watch.LastRun = time.Now()
+	watch.Items = int(count)
+	return c.Model(&watch).Select("last_date", "last_run", "items").Updates(&watch).Error
+}
+
+// Seen returns true if the item was downloaded at a previous run of the term
+func (dwn *IntelXDownloader) Seen(systemID string) bool {
+	return dwn.seen[strings.ToLower(systemID)]
+}
+
+func watchTerm(term string) string {
+	return strings.ToLower(strings.Trim(term, " "))
+}
@@ -20,6 +20,7 @@ import (
     "net/http"
     "net/url"
     "strconv"
+    "sync/atomic"
     "strings"
     "time"
     "os"
@@ -31,6 +32,10 @@ import (
 )
 
 const defaultAPIURL = "https://2.intelx.io/"
+const freeAPIURL = "https://free.intelx.io/"
+
+// APIURLs are the known API endpoints. The API keys are valid only at the endpoint of their license.
+var APIURLs = []string{defaultAPIURL, freeAPIURL}
 const publicAPIKey = "00000000-0000-0000-0000-000000000000"
 
 // Sort orders
@@ -42,6 +47,21 @@ const (
     SortDateDesc   = 4 // Date descending = Newest first
 )
 
+// Phonebook search targets
+const (
+    PhonebookTargetAll     = 0 // All selector types
+    PhonebookTargetDomains = 1 // Domains only
+    PhonebookTargetEmails  = 2 // Email addresses only
+    PhonebookTargetURLs    = 3 // URLs only
+)
+
+// Phonebook selector types
+const (
+    SelectorTypeEmail  = 1 // Email address
+    SelectorTypeDomain = 2 // Domain
+    SelectorTypeURL    = 3 // URL
+)
+
 
 // IntelligenceXAPI holds all information for communicating with the Intelligence X API.
 // Call Init() first.
@@ -58,6 +78,8 @@ type IntelligenceXAPI struct {
     // one client for the session
     Client              http.Client
     RetryAttempts       int // in case of underlying transport failure
+    RateLimitRetries    int           // in case of rate limit (HTTP 429)
+    RateLimitBackoff    time.Duration // first wait of the rate limit exponential backoff
     UserAgent           string
     HTTPMaxResponseSize int64
 
@@ -71,6 +93,8 @@ func (api *IntelligenceXAPI) Init(URL string, Key string) {
     api.SetAPIKey(URL, Key)
 
     api.RetryAttempts = 1
+    api.RateLimitRetries = 5
+    api.RateLimitBackoff = 2 * time.Second
     api.HTTPMaxResponseSize = 1000 * 1024 * 1024 // 1000 MB
     api.WriteCounter = &WriteCounter{}
 
@@ -124,16 +148,26 @@ func (api *IntelligenceXAPI) Init(URL string, Key string) {
 
 // WriteCounter counts the number of bytes written to it. It implements to the io.Writer interface
 // and we can pass this into io.TeeReader() which will report progress on each write cycle.
+// The counter is read by other goroutines while downloading, use Bytes to read it.
 type WriteCounter struct {
     Total uint64
 }
 
 func (wc *WriteCounter) Write(p []byte) (int, error) {
     n := len(p)
-    wc.Total += uint64(n)
+    atomic.AddUint64(&wc.Total, uint64(n))
     return n, nil
 }
 
+// Bytes returns the number of bytes written so far
+func (wc *WriteCounter) Bytes() uint64 {
+    return atomic.LoadUint64(&wc.Total)
+}
+
+func (wc *WriteCounter) Reset() {
+    atomic.StoreUint64(&wc.Total, 0)
+}
+
 // SetAPIKey sets the API URL and Key. URL and Key may be empty to use defaults.
 func (api *IntelligenceXAPI) SetAPIKey(URL string, Key string) {
     if URL == "" {
@@ -213,6 +247,88 @@ func (api *IntelligenceXAPI) SearchTerminate(ctx context.Context, searchID uuid.
     return api.httpRequestGet2(ctx, "intelligent/search/terminate"+request)
 }
 
+// AuthenticateInfo returns the capabilities of the API key: allowed buckets and the remaining credits per API path
+func (api *IntelligenceXAPI) AuthenticateInfo(ctx context.Context) (info AuthenticateInfo, err error) {
+    err = api.httpRequestGet(ctx, "authenticate/info", &info)
+
+    return info, err
+}
+
+// PhonebookSearchStart starts a phonebook search, listing the selectors (emails, domains, URLs) related to the term
+func (api *IntelligenceXAPI) PhonebookSearchStart(ctx context.Context, Input PhonebookSearchRequest) (searchID uuid.UUID, selectorInvalid bool, err error) {
+    response := IntelligentSearchResponse{}
+
+    if err = api.httpRequestPost(ctx, "phonebook/search", Input, &response); err != nil {
+        return
+    }
+
+    switch response.Status {
+    case 1:
+        return searchID, false, errors.New("Invalid Term")
+    case 2:
+        return searchID, false, errors.New("Error Max Concurrent Searches")
+    }
+
+    return response.ID, response.SoftSelectorWarning, nil
+}
+
+// PhonebookSearchGetResults returns phonebook results
+// Status: 0 = Success with results (continue), 1 = No more results available (this response might still have results), 2 = Search ID not found, 3 = No results yet available keep trying, 4 = Error
+func (api *IntelligenceXAPI) PhonebookSearchGetResults(ctx context.Context, searchID uuid.UUID, Limit int) (selectors []PhonebookSelector, status int, err error) {
+    request := "?id=" + searchID.String() + "&limit=" + strconv.Itoa(Limit) + "&offset=-1"
+    response := PhonebookSearchResult{}
+
+    if err = api.httpRequestGet(ctx, "phonebook/search/result"+request, &response); err != nil {
+        return nil, 4, err
+    }
+
+    return response.Selectors, response.Status, nil
+}
+
+// PhonebookSearchGetResultsAll returns all phonebook results up to Limit and up to the given Timeout.
+// Unless the underlying API requests report and error, no error will be returned. Deadline exceeded is treated as no error.
+func (api *IntelligenceXAPI) PhonebookSearchGetResultsAll(ctx context.Context, searchID uuid.UUID, Limit int, Timeout time.Duration) (selectors []PhonebookSelector, err error) {
+    var lastStatus int
+
+    newContext, cancel := context.WithDeadline(ctx, time.Now().Add(Timeout))
+    defer cancel()
+
+    for {
+        var selectorsNew []PhonebookSelector
+        currentLimit := Limit - len(selectors)
+        selectorsNew, lastStatus, err = api.PhonebookSearchGetResults(newContext, searchID, currentLimit)
+
+        if err != nil && (strings.Contains(err.Error(), context.Canceled.Error()) || strings.Contains(err.Error(), context.DeadlineExceeded.Error())) {
+            lastStatus = 5
+            break
+        } else if err != nil {
+            return selectors, err
+        }
+
+        if len(selectorsNew) > 0 {
+            selectors = append(selectors, selectorsNew...)
+        }
+
+        if len(selectors) >= Limit {
+            break
+        }
+
+        // Status: 0 = Success with results (continue), 1 = No more results available (this response might still have results), 2 = Search ID not found, 3 = No results yet available keep trying, 4 = Error
+        if lastStatus != 0 && lastStatus != 3 {
+            break
+        }
+
+        // wait 250 ms before querying the results again
+        time.Sleep(time.Millisecond * 250)
+    }
+
+    if lastStatus != 4 {
+        err = nil
+    }
+
+    return selectors, err
+}
+
 // FilePreview loads the preview of an item. Previews are always capped at 1000 characters.
 func (api *IntelligenceXAPI) FilePreview(ctx context.Context, item *Item) (text string, err error) {
     // Request: GET /file/preview?c=[Content Type]&m=[Media Type]&f=[Target Format]&sid=[Storage Identifier]&b=[Bucket]&e=[0|1]
@@ -259,7 +375,7 @@ func (api *IntelligenceXAPI) DownloadZip(ctx context.Context, searchID uuid.UUID
     }
 
     // Create our progress reporter and pass it to be used alongside our writer
-    api.WriteCounter = &WriteCounter{}
+    api.WriteCounter.Reset()
     if _, err = io.Copy(out, io.TeeReader(response.Body, api.WriteCounter)); err != nil {
         out.Close()
         if errors.Is(err, context.DeadlineExceeded) {
@@ -22,6 +22,12 @@ import (
 
 )
 
+// ErrDailyLimit is returned when the credits of the API key are exhausted (HTTP 402)
+var ErrDailyLimit = errors.New("Daily limits exceeded")
+
+// ErrRateLimit is returned when the API keeps rejecting the requests by rate limit (HTTP 429)
+var ErrRateLimit = errors.New("Too many requests, rate limit exceeded")
+
 // httpRequestPost makes a HTTP POST request and returns JSON data.
 func (api *IntelligenceXAPI) httpRequestPost(ctx context.Context, Function string, DataIn interface{}, DataOut interface{}) (err error) {
 
@@ -106,7 +112,11 @@ func (api *IntelligenceXAPI) httpRequestGet2(ctx context.Context, Function strin
 // httpRequest makes a HTTP request to the API. If err is nil, response must be closed by the caller.
 func (api *IntelligenceXAPI) httpRequest(ctx context.Context, Function, Method string, Data []byte, ContentType string) (response *http.Response, err error) {
 
-	api.Client.Timeout = api.HTTPTimeout
+	// Restore the timeout removed by DownloadZip, only when changed as the client is shared by the download threads
+	if api.Client.Timeout != api.HTTPTimeout {
+		api.Client.Timeout = api.HTTPTimeout
+	}
+	limited := 0
 	for n := 0; ; n++ {
 
 		var req *http.Request
@@ -138,6 +148,13 @@ func (api *IntelligenceXAPI) httpRequest(ctx context.Context, Function, Method s
 			time.Sleep(time.Millisecond * 200)
 		}
 
+		// rate limited: back off and repeat the request, it does not count as a retry attempt
+		if err == nil && api.rateLimitBackoff(ctx, response, limited) {
+			limited++
+			n--
+			continue
+		}
+
 		// normal access mode: return if success, max retry attempts
 		if err == nil || n >= api.RetryAttempts {
 			return response, err
@@ -149,6 +166,7 @@ func (api *IntelligenceXAPI) httpRequest(ctx context.Context, Function, Method s
 // httpRequest makes a HTTP request to the API. If err is nil, response must be closed by the caller.
 func (api *IntelligenceXAPI) httpRequest2(Function, Method string, Data []byte, ContentType string) (response *http.Response, err error) {
 
+	limited := 0
 	for n := 0; ; n++ {
 
 		var req *http.Request
@@ -180,6 +198,13 @@ func (api *IntelligenceXAPI) httpRequest2(Function, Method string, Data []byte,
 			time.Sleep(time.Millisecond * 200)
 		}
 
+		// rate limited: back off and repeat the request, it does not count as a retry attempt
+		if err == nil && api.rateLimitBackoff(context.Background(), response, limited) {
+			limited++
+			n--
+			continue
+		}
+
 		// normal access mode: return if success, max retry attempts
 		if err == nil || n >= api.RetryAttempts {
 			return response, err
@@ -187,6 +212,33 @@ func (api *IntelligenceXAPI) httpRequest2(Function, Method string, Data []byte,
 	}
 }
 
+// rateLimitBackoff waits before repeating a request rejected by rate limit (HTTP 429), using the Retry-After header
+// or an exponential backoff. It returns false if the response is not rate limited or the retries were exhausted.
+func (api *IntelligenceXAPI) rateLimitBackoff(ctx context.Context, response *http.Response, attempt int) bool {
+	if response.StatusCode != http.StatusTooManyRequests || attempt >= api.RateLimitRetries {
+		return false
+	}
+
+	wait := api.RateLimitBackoff << uint(attempt)
+	if s, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && s > 0 {
+		wait = time.Duration(s) * time.Second
+	}
+	if wait > time.Minute {
+		wait = time.Minute
+	}
+
+	io.Copy(ioutil.Discard, response.Body) // required for using keep-alive
+	response.Body.Close()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(wait):
+	}
+
+	return true
+}
+
 // apiStatusToError translates the HTTP status code returned by services into a Go error
 func (api *IntelligenceXAPI) apiStatusToError(StatusCode int) (err error) {
 
@@ -203,8 +255,10 @@ func (api *IntelligenceXAPI) apiStatusToError(StatusCode int) (err error) {
 		return errors.New("Internal API error")
 	case http.StatusNotImplemented:
 		return errors.New("Not implemented by API")
-	case 402:
-		return errors.New("Daily limits exceeded")
+	case http.StatusPaymentRequired:
+		return ErrDailyLimit
+	case http.StatusTooManyRequests:
+		return ErrRateLimit
 	}
 
 	return errors.New("Unknown API error, returned HTTP status " + strconv.Itoa(StatusCode))
@@ -14,6 +14,7 @@ import (
 
     "time"
     "path/filepath"
+    "strings"
     "github.com/gofrs/uuid"
 
     "gorm.io/gorm"
@@ -40,6 +41,43 @@ type IntelligentSearchResponse struct {
     Status              int       `json:"status"`              // Status of the search: 0 = Success (ID valid), 1 = Invalid Term, 2 = Error Max Concurrent Searches
 }
 
+// PhonebookSearchRequest is the information from the human for the phonebook search.
+type PhonebookSearchRequest struct {
+    IntelligentSearchRequest
+    Target int `json:"target"` // Target: 0 = all, 1 = Domains, 2 = Emails, 3 = URLs
+}
+
+// PhonebookSelector is a selector (email, domain, URL...) returned by the phonebook search
+type PhonebookSelector struct {
+    Selectortype  int    `json:"selectortype"`  // Type: 1 = Email address, 2 = Domain, 3 = URL, see SelectorTypeX
+    Selectortypeh string `json:"selectortypeh"` // Type, human friendly
+    Selectorvalue string `json:"selectorvalue"` // The selector
+}
+
+// PhonebookSearchResult contains the phonebook result selectors
+type PhonebookSearchResult struct {
+    Selectors []PhonebookSelector `json:"selectors"` // The result selectors
+    Status    int                 `json:"status"`    // Status: 0 = Success with results, 1 = No more results available, 2 = Search ID not found, 3 = No results yet available keep trying
+}
+
+// AuthenticateInfo contains the capabilities of the API key
+type AuthenticateInfo struct {
+    Buckets               []string              `json:"buckets"`               // Allowed buckets
+    BucketsH              []string              `json:"bucketsh"`              // Allowed buckets, human friendly
+    Redacted              bool                  `json:"redacted"`              // Whether the results are redacted
+    Paths                 map[string]PathCredit `json:"paths"`                 // Credits per API path, e.g. "/intelligent/search"
+    SearchesActive        int                   `json:"searchesactive"`        // Searches currently running
+    MaxConcurrentSearches int                   `json:"maxconcurrentsearches"` // Max concurrent searches
+}
+
+// PathCredit contains the credits of an API path
+type PathCredit struct {
+    Path        string `json:"path"`
+    Credit      int    `json:"credit"`      // Remaining credits
+    CreditMax   int    `json:"creditmax"`   // Credits after the reset
+    CreditReset int    `json:"creditreset"` // Credit reset interval, in seconds
+}
+
 // Tag classifies the items data
 type Tag struct {
     ID    uint   `json:"id" gorm:"primarykey"`
@@ -171,6 +209,7 @@ type SearchResult struct {
     BucketH      string                 `json:"bucketh"`      // Human friendly bucket name
     Group        string                 `json:"group"`        // File Group
     IndexFile    string                 `json:"indexfile"`    // Index file ID
+    Term         string                 `json:"term"`         // Search term that listed the record, set by the downloader
 }
 
 func (SearchResult) TableName() string {
@@ -187,6 +226,11 @@ func (sr *SearchResult) GetCsv() *CsvItem {
         Type        : sr.TypeH,
         Size        : sr.Size,
         SystemID    : sr.SystemID,
+        StorageID   : sr.StorageID,
+        XScore      : sr.XScore,
+        Language    : strings.Join(sr.GetTags(TagLanguage), ","),
+        Relations   : strings.Join(sr.GetRelations(), ";"),
+        SearchTerm  : sr.Term,
     }
 }
 
@@ -206,4 +250,9 @@ type CsvItem struct {
     Type    string         `json:"type"`
     Size    int64          `json:"size"` 
     SystemID string        `json:"system id"`
+    StorageID string       `json:"storage id"`
+    XScore  int            `json:"xscore"`
+    Language string        `json:"language"`  // ISO 639-1 languages, comma-separated
+    Relations string       `json:"relations"` // Related items, "relation:system id" semicolon-separated
+    SearchTerm string      `json:"search term"`
 }
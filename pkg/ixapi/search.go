@@ -12,6 +12,8 @@ import (
     "context"
     "time"
     "errors"
+    "strconv"
+    "strings"
 
 
     "github.com/gofrs/uuid"
@@ -82,6 +84,60 @@ func (api *IntelligenceXAPI) SearchWithDates(ctx context.Context, Selector strin
     return searchID, records, selectorInvalid, nil
 }
 
+// SearchWithRequest starts a search with all the request options (dates, buckets, media...) and queries all results.
+// Request.MaxResults is used as the limit of results to query per bucket.
+func (api *IntelligenceXAPI) SearchWithRequest(ctx context.Context, Request IntelligentSearchRequest, WaitSort, TimeoutGetResults time.Duration) (searchID *uuid.UUID, records []SearchResult, selectorInvalid bool, err error) {
+
+    // make the search
+    sID, selectorInvalid, err := api.SearchStartAdvanced(ctx, Request)
+    if err != nil {
+        return nil, nil, false, err
+    }
+
+    searchID = &sID
+
+    if selectorInvalid {
+        return searchID, nil, selectorInvalid, errors.New("Invalid Term")
+    }
+
+    // give some time for sorting
+    time.Sleep(WaitSort)
+
+    records, err = api.SearchGetResultsAll(ctx, *searchID, Request.MaxResults, TimeoutGetResults)
+    if err != nil {
+        return nil, nil, false, err
+    }
+
+    return searchID, records, selectorInvalid, nil
+}
+
+// PhonebookSearch starts a phonebook search with all the request options and queries all selectors.
+// Request.MaxResults is used as the limit of selectors to query.
+func (api *IntelligenceXAPI) PhonebookSearch(ctx context.Context, Request PhonebookSearchRequest, WaitSort, TimeoutGetResults time.Duration) (searchID *uuid.UUID, selectors []PhonebookSelector, selectorInvalid bool, err error) {
+
+    // make the search
+    sID, selectorInvalid, err := api.PhonebookSearchStart(ctx, Request)
+    if err != nil {
+        return nil, nil, false, err
+    }
+
+    searchID = &sID
+
+    if selectorInvalid {
+        return searchID, nil, selectorInvalid, errors.New("Invalid Term")
+    }
+
+    // give some time for sorting
+    time.Sleep(WaitSort)
+
+    selectors, err = api.PhonebookSearchGetResultsAll(ctx, *searchID, Request.MaxResults, TimeoutGetResults)
+    if err != nil {
+        return nil, nil, false, err
+    }
+
+    return searchID, selectors, selectorInvalid, nil
+}
+
 // GetTag gets a tags value for the first occurrence. Empty if not found.
 func (item *Item) GetTag(Class int16) (Value string) {
     if item.Tags == nil {
@@ -97,9 +153,53 @@ func (item *Item) GetTag(Class int16) (Value string) {
     return ""
 }
 
+// GetTags gets all values of the tag class, e.g. the languages of the item. Empty if not found.
+func (item *Item) GetTags(Class int16) (Values []string) {
+    Values = []string{}
+    for _, tag := range item.Tags {
+        if tag.Class == Class && tag.Value != "" {
+            Values = append(Values, tag.Value)
+        }
+    }
+
+    return Values
+}
+
 // TagLanguage is ISO 639-1 defined
 const TagLanguage = 0
 
+// Relationship types between items, see Relationship.Relation
+const (
+    RelationNone      = 0 // No relation
+    RelationContainer = 1 // The target item contains the item (parent)
+    RelationContained = 2 // The target item is contained in the item (child)
+    RelationDuplicate = 3 // The target item has the same data
+    RelationRedirect  = 4 // The item redirects to the target item
+)
+
+// RelationNames are the human friendly relationship types
+var RelationNames = map[int]string{
+    RelationNone:      "none",
+    RelationContainer: "parent",
+    RelationContained: "child",
+    RelationDuplicate: "duplicate",
+    RelationRedirect:  "redirect",
+}
+
+// GetRelations returns the related items as "relation:system id" pairs, e.g. "parent:<system id>"
+func (item *Item) GetRelations() (Values []string) {
+    Values = []string{}
+    for _, r := range item.Relations {
+        name, ok := RelationNames[r.Relation]
+        if !ok {
+            name = strconv.Itoa(r.Relation)
+        }
+        Values = append(Values, name + ":" + strings.ToLower(r.Target))
+    }
+
+    return Values
+}
+
 // SimhashCompareItems compares 2 items for data equalness and returns the hamming distance. The closer to 0 the more equal they are.
 // Never compare Simhashes directly because with different content types and even on the same type with different encoding they use different algorithms.
 func SimhashCompareItems(Item1, Item2 *Item) uint8 {
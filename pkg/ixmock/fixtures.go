@@ -0,0 +1,256 @@
+package ixmock
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/helviojunior/intelparser/pkg/ixapi"
+)
+
+// MetadataFile is the optional file of the fixtures folder with the metadata of the items
+const MetadataFile = "items.json"
+
+// Metadata overrides the defaults of a fixture item. File is the path relative to the fixtures folder.
+type Metadata struct {
+	File      string               `json:"file"`
+	Name      string               `json:"name"`
+	Date      string               `json:"date"` // "2006-01-02 15:04:05" or RFC3339
+	Bucket    string               `json:"bucket"`
+	Media     int                  `json:"media"`
+	Type      int                  `json:"type"`
+	XScore    int                  `json:"xscore"`
+	SystemID  string               `json:"systemid"`
+	Tags      []ixapi.Tag          `json:"tags"`
+	Relations []ixapi.Relationship `json:"relations"`
+}
+
+// Human friendly names of the media types served by default
+var mediaNames = map[int]string{
+	1:  "Paste Document",
+	9:  "Web Page",
+	15: "PDF Document",
+	16: "Word Document",
+	17: "Excel Document",
+	22: "ZIP Archive",
+	24: "Text File",
+	32: "CSV File",
+}
+
+var (
+	reEmail = regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`)
+	reURL   = regexp.MustCompile(`https?://[^\s"'<>]+`)
+)
+
+// fixture is an item served by the mock, the data is read from Path when needed
+type fixture struct {
+	Record ixapi.SearchResult
+	Path   string
+}
+
+// loadFixtures reads every file of the folder as an item, applying the metadata of items.json
+func loadFixtures(folder string) ([]*fixture, error) {
+	meta := map[string]Metadata{}
+	data, err := os.ReadFile(filepath.Join(folder, MetadataFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	} else if err == nil {
+		list := []Metadata{}
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", MetadataFile, err)
+		}
+		for _, m := range list {
+			meta[filepath.ToSlash(filepath.Clean(m.File))] = m
+		}
+	}
+
+	fixtures := []*fixture{}
+	err = filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(folder, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == MetadataFile {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		fx, err := newFixture(path, rel, info, meta[rel])
+		if err != nil {
+			return fmt.Errorf("fixture %s: %w", rel, err)
+		}
+		fixtures = append(fixtures, fx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fixtures, nil
+}
+
+// newFixture builds the search record of the file. The system id is derived from the
+// relative path, so it is the same across runs, and the storage id is the SHA-512 of the data.
+func newFixture(path string, rel string, info fs.FileInfo, meta Metadata) (*fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha512.Sum512(data)
+	record := ixapi.SearchResult{
+		Item: ixapi.Item{
+			SystemID:  uuid.NewV5(uuid.NamespaceURL, rel).String(),
+			StorageID: hex.EncodeToString(hash[:]),
+			InStore:   true,
+			Size:      info.Size(),
+			Type:      1,
+			Media:     24,
+			Added:     info.ModTime().UTC(),
+			Date:      info.ModTime().UTC(),
+			Name:      rel,
+			XScore:    50,
+			Bucket:    "leaks.public",
+			Filename:  filepath.Base(rel),
+			Tags:      meta.Tags,
+			Relations: meta.Relations,
+		},
+	}
+
+	if meta.Name != "" {
+		record.Name = meta.Name
+	}
+	if meta.Bucket != "" {
+		record.Bucket = meta.Bucket
+	}
+	if meta.Media != 0 {
+		record.Media = meta.Media
+	}
+	if meta.Type != 0 {
+		record.Type = meta.Type
+	}
+	if meta.XScore != 0 {
+		record.XScore = meta.XScore
+	}
+	if meta.SystemID != "" {
+		if _, err := uuid.FromString(meta.SystemID); err != nil {
+			return nil, fmt.Errorf("invalid systemid: %w", err)
+		}
+		record.SystemID = meta.SystemID
+	}
+	if meta.Date != "" {
+		dt, err := parseDate(meta.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date: %w", err)
+		}
+		record.Date = dt
+	}
+
+	record.BucketH = bucketName(record.Bucket)
+	record.MediaH = mediaNames[record.Media]
+	if record.MediaH == "" {
+		record.MediaH = fmt.Sprintf("Media %d", record.Media)
+	}
+	record.TypeH = "Binary"
+	if record.Type == 1 {
+		record.TypeH = "Text"
+	}
+	record.AccessLevelH = "Public"
+
+	return &fixture{Record: record, Path: path}, nil
+}
+
+// Read returns the data of the item
+func (fx *fixture) Read() ([]byte, error) {
+	return os.ReadFile(fx.Path)
+}
+
+// Match returns true if the term is at the name or at the data of the item, ignoring the case.
+// A leading wildcard (e.g. *.example.com) matches any subdomain.
+func (fx *fixture) Match(term string) bool {
+	term = strings.TrimPrefix(strings.ToLower(term), "*")
+	if strings.Contains(strings.ToLower(fx.Record.Name), term) {
+		return true
+	}
+
+	data, err := fx.Read()
+	if err != nil {
+		return false
+	}
+
+	return strings.Contains(strings.ToLower(string(data)), term)
+}
+
+// Selectors extracts the emails, domains and URLs of the item data related to the term
+func (fx *fixture) Selectors(term string) []ixapi.PhonebookSelector {
+	data, err := fx.Read()
+	if err != nil {
+		return nil
+	}
+
+	term = strings.TrimPrefix(strings.ToLower(term), "*")
+	selectors := []ixapi.PhonebookSelector{}
+	add := func(t int, th string, v string) {
+		if strings.Contains(strings.ToLower(v), term) {
+			selectors = append(selectors, ixapi.PhonebookSelector{Selectortype: t, Selectortypeh: th, Selectorvalue: v})
+		}
+	}
+
+	for _, e := range reEmail.FindAllString(string(data), -1) {
+		add(ixapi.SelectorTypeEmail, "Email Address", e)
+		add(ixapi.SelectorTypeDomain, "Domain", strings.ToLower(strings.SplitN(e, "@", 2)[1]))
+	}
+	for _, u := range reURL.FindAllString(string(data), -1) {
+		add(ixapi.SelectorTypeURL, "URL", u)
+		host := strings.SplitN(strings.SplitN(u, "://", 2)[1], "/", 2)[0]
+		add(ixapi.SelectorTypeDomain, "Domain", strings.ToLower(strings.SplitN(host, ":", 2)[0]))
+	}
+
+	return selectors
+}
+
+// bucketName returns the human friendly bucket name, e.g. leaks.public -> Leaks » Public
+func bucketName(bucket string) string {
+	parts := strings.Split(bucket, ".")
+	for i, p := range parts {
+		if p != "" {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+
+	return strings.Join(parts, " » ")
+}
+
+// parseDate parses the dates of the metadata and of the search requests
+func parseDate(s string) (time.Time, error) {
+	var err error
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02", time.RFC3339} {
+		var dt time.Time
+		if dt, err = time.Parse(layout, s); err == nil {
+			return dt, nil
+		}
+	}
+
+	return time.Time{}, err
+}
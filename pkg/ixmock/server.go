@@ -0,0 +1,514 @@
+// Package ixmock is a local mock of the IntelX API, serving the items of a fixtures folder.
+// It implements the subset of endpoints used by ixapi, so the downloaders and the parsers
+// can be exercised end to end without network access or API credits.
+package ixmock
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/helviojunior/intelparser/pkg/ixapi"
+	"github.com/helviojunior/intelparser/pkg/log"
+)
+
+// DefaultMaxResults is the max results per bucket when the search request has none
+const DefaultMaxResults = 1000
+
+// Paths consuming credits, reported by authenticate/info
+var creditPaths = []string{
+	"/intelligent/search",
+	"/intelligent/search/export",
+	"/phonebook/search",
+	"/file/read",
+	"/file/preview",
+}
+
+// search is a running search. The first result request returns status 3 (no results yet)
+// to exercise the polling of the clients.
+type search struct {
+	Records   []ixapi.SearchResult
+	Selectors []ixapi.PhonebookSelector
+	Offset    int
+	Polled    bool
+}
+
+// Server is the IntelX API mock
+type Server struct {
+	Fixtures string // Fixtures folder
+	APIKey   string // Accepted API key, empty to accept any key
+	Credits  int    // Credits per path, the requests fail with HTTP 402 when exhausted
+
+	items    []*fixture
+	searches map[uuid.UUID]*search
+	credits  map[string]int
+	mutex    sync.Mutex
+}
+
+// NewServer loads the items of the fixtures folder
+func NewServer(fixtures string, credits int) (*Server, error) {
+	items, err := loadFixtures(fixtures)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &Server{
+		Fixtures: fixtures,
+		Credits:  credits,
+		items:    items,
+		searches: map[uuid.UUID]*search{},
+		credits:  map[string]int{},
+		mutex:    sync.Mutex{},
+	}
+	for _, p := range creditPaths {
+		srv.credits[p] = credits
+	}
+
+	return srv, nil
+}
+
+// Items returns the count of items served
+func (srv *Server) Items() int {
+	return len(srv.items)
+}
+
+// Handler returns the HTTP handler of the API endpoints
+func (srv *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authenticate/info", srv.authenticateInfo)
+	mux.HandleFunc("/intelligent/search", srv.searchStart)
+	mux.HandleFunc("/intelligent/search/result", srv.searchResult)
+	mux.HandleFunc("/intelligent/search/terminate", srv.searchTerminate)
+	mux.HandleFunc("/intelligent/search/export", srv.searchExport)
+	mux.HandleFunc("/phonebook/search", srv.phonebookStart)
+	mux.HandleFunc("/phonebook/search/result", srv.phonebookResult)
+	mux.HandleFunc("/file/preview", srv.filePreview)
+	mux.HandleFunc("/file/read", srv.fileRead)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("IntelX mock request", "method", r.Method, "uri", r.URL.RequestURI())
+
+		key := r.Header.Get("x-key")
+		if key == "" {
+			key = r.URL.Query().Get("k")
+		}
+		if key == "" || (srv.APIKey != "" && !strings.EqualFold(key, srv.APIKey)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// ListenAndServe serves the API at the address (e.g. 127.0.0.1:8080)
+func (srv *Server) ListenAndServe(addr string) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return server.ListenAndServe()
+}
+
+// consume takes a credit of the path, writing HTTP 402 if there are no credits left
+func (srv *Server) consume(w http.ResponseWriter, path string) bool {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	if srv.credits[path] <= 0 {
+		w.WriteHeader(http.StatusPaymentRequired)
+		return false
+	}
+	srv.credits[path]--
+
+	return true
+}
+
+func (srv *Server) authenticateInfo(w http.ResponseWriter, r *http.Request) {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	info := ixapi.AuthenticateInfo{
+		Buckets:               []string{},
+		BucketsH:              []string{},
+		Paths:                 map[string]ixapi.PathCredit{},
+		SearchesActive:        len(srv.searches),
+		MaxConcurrentSearches: 100,
+	}
+
+	buckets := map[string]bool{}
+	for _, fx := range srv.items {
+		if !buckets[fx.Record.Bucket] {
+			buckets[fx.Record.Bucket] = true
+			info.Buckets = append(info.Buckets, fx.Record.Bucket)
+			info.BucketsH = append(info.BucketsH, fx.Record.BucketH)
+		}
+	}
+
+	for p, c := range srv.credits {
+		info.Paths[p] = ixapi.PathCredit{Path: p, Credit: c, CreditMax: srv.Credits, CreditReset: 86400}
+	}
+
+	writeJSON(w, info)
+}
+
+// find returns the items matching the request filters, sorted and limited per bucket
+func (srv *Server) find(request ixapi.IntelligentSearchRequest) []*fixture {
+	var from, to time.Time
+	if request.DateFrom != "" && request.DateTo != "" {
+		from, _ = parseDate(request.DateFrom)
+		to, _ = parseDate(request.DateTo)
+	}
+
+	buckets := map[string]bool{}
+	for _, b := range request.Buckets {
+		buckets[strings.ToLower(b)] = true
+	}
+
+	found := []*fixture{}
+	for _, fx := range srv.items {
+		if len(buckets) > 0 && !buckets[strings.ToLower(fx.Record.Bucket)] {
+			continue
+		}
+		if request.Media != 0 && fx.Record.Media != request.Media {
+			continue
+		}
+		if !from.IsZero() && (fx.Record.Date.Before(from) || fx.Record.Date.After(to)) {
+			continue
+		}
+		if fx.Match(request.Term) {
+			found = append(found, fx)
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		a, b := found[i].Record, found[j].Record
+		switch request.Sort {
+		case ixapi.SortXScoreAsc:
+			return a.XScore < b.XScore
+		case ixapi.SortXScoreDesc:
+			return a.XScore > b.XScore
+		case ixapi.SortDateAsc:
+			return a.Date.Before(b.Date)
+		case ixapi.SortDateDesc:
+			return a.Date.After(b.Date)
+		}
+		return false
+	})
+
+	max := request.MaxResults
+	if max <= 0 {
+		max = DefaultMaxResults
+	}
+
+	perBucket := map[string]int{}
+	result := []*fixture{}
+	for _, fx := range found {
+		if perBucket[fx.Record.Bucket] >= max {
+			continue
+		}
+		perBucket[fx.Record.Bucket]++
+		result = append(result, fx)
+	}
+
+	return result
+}
+
+// start registers a new search, answering with its id
+func (srv *Server) start(w http.ResponseWriter, s *search) {
+	id, _ := uuid.NewV4()
+
+	srv.mutex.Lock()
+	srv.searches[id] = s
+	srv.mutex.Unlock()
+
+	writeJSON(w, ixapi.IntelligentSearchResponse{ID: id, Status: 0})
+}
+
+// poll returns the next page of the search, with the IntelX result status:
+// 0 = more results available, 1 = no more results, 2 = search id not found, 3 = no results yet
+func (srv *Server) poll(r *http.Request, count func(s *search) int) (s *search, offset int, end int, status int) {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	id, err := uuid.FromString(r.URL.Query().Get("id"))
+	if err != nil {
+		return nil, 0, 0, 2
+	}
+
+	s, ok := srv.searches[id]
+	if !ok {
+		return nil, 0, 0, 2
+	}
+
+	if !s.Polled {
+		s.Polled = true
+		return s, 0, 0, 3
+	}
+
+	total := count(s)
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+
+	offset = s.Offset
+	end = offset + limit
+	if end >= total {
+		end = total
+		status = 1
+	}
+	s.Offset = end
+
+	return s, offset, end, status
+}
+
+func (srv *Server) searchStart(w http.ResponseWriter, r *http.Request) {
+	request := ixapi.IntelligentSearchRequest{}
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&request) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if strings.Trim(request.Term, " *") == "" {
+		writeJSON(w, ixapi.IntelligentSearchResponse{Status: 1})
+		return
+	}
+
+	if !srv.consume(w, "/intelligent/search") {
+		return
+	}
+
+	s := &search{Records: []ixapi.SearchResult{}}
+	for _, fx := range srv.find(request) {
+		s.Records = append(s.Records, fx.Record)
+	}
+
+	srv.start(w, s)
+}
+
+func (srv *Server) searchResult(w http.ResponseWriter, r *http.Request) {
+	s, offset, end, status := srv.poll(r, func(s *search) int { return len(s.Records) })
+
+	result := ixapi.IntelligentSearchResult{Records: []ixapi.SearchResult{}, Status: status}
+	if s != nil {
+		result.Records = s.Records[offset:end]
+	}
+
+	writeJSON(w, result)
+}
+
+func (srv *Server) searchTerminate(w http.ResponseWriter, r *http.Request) {
+	if id, err := uuid.FromString(r.URL.Query().Get("id")); err == nil {
+		srv.mutex.Lock()
+		delete(srv.searches, id)
+		srv.mutex.Unlock()
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// searchExport writes a ZIP with the Info.csv and the data of the search items, named <system id><ext>
+func (srv *Server) searchExport(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(r.URL.Query().Get("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	srv.mutex.Lock()
+	s, ok := srv.searches[id]
+	srv.mutex.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if !srv.consume(w, "/intelligent/search/export") {
+		return
+	}
+
+	records := s.Records
+	if limit, err := strconv.Atoi(r.URL.Query().Get("l")); err == nil && limit > 0 && limit < len(records) {
+		records = records[:limit]
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"Search.zip\"")
+
+	zw := zip.NewWriter(w)
+	defer zw.Close()
+
+	info, err := zw.Create("Info.csv")
+	if err != nil {
+		return
+	}
+	cw := csv.NewWriter(info)
+	cw.Write([]string{"Name", "Date", "Bucket", "Media", "Content", "Type", "Size", "System ID"})
+	for _, rec := range records {
+		c := rec.GetCsv()
+		cw.Write([]string{c.Name, c.Date, c.Bucket, c.Media, c.Content, c.Type, strconv.FormatInt(c.Size, 10), c.SystemID})
+	}
+	cw.Flush()
+
+	for _, rec := range records {
+		fx := srv.item(rec.StorageID)
+		if fx == nil {
+			continue
+		}
+
+		data, err := fx.Read()
+		if err != nil {
+			log.Debug("Error reading fixture", "file", fx.Path, "err", err)
+			continue
+		}
+
+		f, err := zw.Create(rec.SystemID + rec.GetExtension())
+		if err != nil {
+			return
+		}
+		f.Write(data)
+	}
+}
+
+func (srv *Server) phonebookStart(w http.ResponseWriter, r *http.Request) {
+	request := ixapi.PhonebookSearchRequest{}
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&request) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if strings.Trim(request.Term, " *") == "" {
+		writeJSON(w, ixapi.IntelligentSearchResponse{Status: 1})
+		return
+	}
+
+	if !srv.consume(w, "/phonebook/search") {
+		return
+	}
+
+	// The phonebook lists the selectors, the max results applies to them and not to the items
+	max := request.MaxResults
+	request.MaxResults = len(srv.items)
+
+	unique := map[string]bool{}
+	s := &search{Selectors: []ixapi.PhonebookSelector{}}
+	for _, fx := range srv.find(request.IntelligentSearchRequest) {
+		for _, sel := range fx.Selectors(request.Term) {
+			key := strconv.Itoa(sel.Selectortype) + strings.ToLower(sel.Selectorvalue)
+			if unique[key] || !phonebookTarget(request.Target, sel.Selectortype) {
+				continue
+			}
+			unique[key] = true
+			s.Selectors = append(s.Selectors, sel)
+		}
+	}
+	if max > 0 && len(s.Selectors) > max {
+		s.Selectors = s.Selectors[:max]
+	}
+
+	srv.start(w, s)
+}
+
+func (srv *Server) phonebookResult(w http.ResponseWriter, r *http.Request) {
+	s, offset, end, status := srv.poll(r, func(s *search) int { return len(s.Selectors) })
+
+	result := ixapi.PhonebookSearchResult{Selectors: []ixapi.PhonebookSelector{}, Status: status}
+	if s != nil {
+		result.Selectors = s.Selectors[offset:end]
+	}
+
+	writeJSON(w, result)
+}
+
+// filePreview returns the first lines (l) of the item, capped at 1000 characters
+func (srv *Server) filePreview(w http.ResponseWriter, r *http.Request) {
+	data, ok := srv.read(w, r.URL.Query().Get("sid"), "/file/preview")
+	if !ok {
+		return
+	}
+
+	if lines, err := strconv.Atoi(r.URL.Query().Get("l")); err == nil && lines > 0 {
+		if parts := strings.SplitAfter(string(data), "\n"); len(parts) > lines {
+			data = []byte(strings.Join(parts[:lines], ""))
+		}
+	}
+	if len(data) > 1000 {
+		data = data[:1000]
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(data)
+}
+
+func (srv *Server) fileRead(w http.ResponseWriter, r *http.Request) {
+	data, ok := srv.read(w, r.URL.Query().Get("storageid"), "/file/read")
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(data)
+}
+
+// read returns the data of the item with the storage id, writing HTTP 404 if not found
+func (srv *Server) read(w http.ResponseWriter, storageID string, path string) ([]byte, bool) {
+	fx := srv.item(storageID)
+	if fx == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return nil, false
+	}
+
+	if !srv.consume(w, path) {
+		return nil, false
+	}
+
+	data, err := fx.Read()
+	if err != nil {
+		log.Debug("Error reading fixture", "file", fx.Path, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return data, true
+}
+
+// item returns the item with the storage id, nil if not found
+func (srv *Server) item(storageID string) *fixture {
+	for _, fx := range srv.items {
+		if storageID != "" && strings.EqualFold(fx.Record.StorageID, storageID) {
+			return fx
+		}
+	}
+
+	return nil
+}
+
+func phonebookTarget(target int, selectorType int) bool {
+	switch target {
+	case ixapi.PhonebookTargetDomains:
+		return selectorType == ixapi.SelectorTypeDomain
+	case ixapi.PhonebookTargetEmails:
+		return selectorType == ixapi.SelectorTypeEmail
+	case ixapi.PhonebookTargetURLs:
+		return selectorType == ixapi.SelectorTypeURL
+	}
+
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Debug("Error writing response", "err", err)
+	}
+}
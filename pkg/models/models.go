@@ -30,13 +30,30 @@ type File struct {
 
 	Content 		  	  string 	`json:"content"`
 
+	// Provider metadata (e.g. IntelX), empty if not available
+	XScore 				  int 		`json:"xscore"`      //Relevance, 0-100
+	Language 			  string 	`json:"language"`    //ISO 639-1 languages, comma-separated
+	StorageID 			  string 	`json:"storage_id"`
+	Relations 			  string 	`json:"relations"`   //Related items, "relation:provider id" semicolon-separated (e.g. parent:<id>)
+	SearchTerm 			  string 	`json:"search_term"` //Search term that found the file
+
 	// Failed flag set if the result should be considered failed
 	Failed       		  bool   	`json:"failed"`
 	FailedReason 		  string 	`json:"failed_reason"`
+	FailedCode 			  string 	`json:"failed_code" gorm:"index"`
+
+	// Source of the failed file, used to re-queue it
+	SourcePath 			  string 	`json:"source_path"`
+	SourceArchive 		  string 	`json:"source_archive"`
+	Parser 				  string 	`json:"parser"`
 
 	Credentials []Credential `json:"credentials" gorm:"constraint:OnDelete:CASCADE"`
 	Emails      []Email      `json:"emails" gorm:"constraint:OnDelete:CASCADE"`
 	URLs        []URL        `json:"urls" gorm:"constraint:OnDelete:CASCADE"`
+	Secrets     []Secret     `json:"secrets" gorm:"constraint:OnDelete:CASCADE"`
+	PIIs        []PII        `json:"pii" gorm:"constraint:OnDelete:CASCADE"`
+	Wallets     []Wallet     `json:"wallets" gorm:"constraint:OnDelete:CASCADE"`
+	Cookies     []Cookie     `json:"cookies" gorm:"constraint:OnDelete:CASCADE"`
 
 }
 
@@ -62,6 +79,9 @@ type Email struct {
 	Domain		string      `json:"domain"`
 	Email       string      `json:"email"`
 
+	// Extra fields of structured records (JSON object)
+	Attributes  string      `json:"attributes"`
+
 	NearText    string 		`json:"near_text"`
 }
 
@@ -84,6 +104,80 @@ type Credential struct {
 	Severity    int 	    `json:"severity"`
 	Entropy     float32     `json:"entropy"`
 
+	// Extra fields of structured records (JSON object)
+	Attributes  string      `json:"attributes"`
+
+	NearText    string 		`json:"near_text"`
+}
+
+type Secret struct {
+	ID       uint `json:"id" gorm:"primarykey"`
+	FileID   uint `json:"file_id" gorm:"index:idx_secret"`
+
+	Rule        string      `json:"rule"`
+	Time        time.Time   `json:"time"`
+
+	Type        string      `json:"type"`
+	Value       string      `json:"value"`
+
+	Severity    int 	    `json:"severity"`
+	Entropy     float32     `json:"entropy"`
+
+	NearText    string 		`json:"near_text"`
+}
+
+type PII struct {
+	ID       uint `json:"id" gorm:"primarykey"`
+	FileID   uint `json:"file_id" gorm:"index:idx_pii"`
+
+	Rule        string      `json:"rule"`
+	Time        time.Time   `json:"time"`
+
+	Type        string      `json:"type"`   //cpf, cnpj, credit-card, iban, phone, ssn
+	Value       string      `json:"value"`  //Normalized value
+	Detail      string      `json:"detail"` //Card brand, IBAN/Phone country...
+	Validated   bool        `json:"validated"`
+
+	NearText    string 		`json:"near_text"`
+}
+
+type Wallet struct {
+	ID       uint `json:"id" gorm:"primarykey"`
+	FileID   uint `json:"file_id" gorm:"index:idx_wallet"`
+
+	Rule        string      `json:"rule"`
+	Time        time.Time   `json:"time"`
+
+	Type        string      `json:"type"`   //btc-address, btc-wif, eth-address, xmr-address, bip39-mnemonic
+	Value       string      `json:"value"`
+	Detail      string      `json:"detail"` //Address kind, checksum type...
+	Validated   bool        `json:"validated"`
+
+	Severity    int 	    `json:"severity"`
+
+	NearText    string 		`json:"near_text"`
+}
+
+type Cookie struct {
+	ID       uint `json:"id" gorm:"primarykey"`
+	FileID   uint `json:"file_id" gorm:"index:idx_cookie"`
+
+	Rule        string      `json:"rule"`
+	Time        time.Time   `json:"time"`     //Cookie creation time
+
+	Browser     string      `json:"browser"`  //chrome, firefox, netscape...
+	Domain      string      `json:"domain"`
+	Path        string      `json:"path"`
+	Name        string      `json:"name"`
+	Value       string      `json:"value"`
+	Expires     time.Time   `json:"expires"`  //Zero for session cookies
+
+	Secure      bool        `json:"secure"`
+	HttpOnly    bool        `json:"http_only"`
+	Session     bool        `json:"session"`  //Session/authentication cookie (SSO, session id...)
+
+	Severity    int 	    `json:"severity"`
+
 	NearText    string 		`json:"near_text"`
 }
 
@@ -127,6 +221,10 @@ type Finding struct {
     Credential Credential
     Email Email
     Url URL
+    SecretData Secret
+    PIIData PII
+    WalletData Wallet
+    CookieData Cookie
 }
 
 
@@ -145,6 +243,11 @@ func (file File) Clone() *File {
 		MIMEType 			: file.MIMEType,
 		Fingerprint 		: file.Fingerprint,
 		Content 			: file.Content,
+		XScore 				: file.XScore,
+		Language 			: file.Language,
+		StorageID 			: file.StorageID,
+		Relations 			: file.Relations,
+		SearchTerm 			: file.SearchTerm,
 
 		//Credentials 		: make([]Credential{}),
 		//Emails 				: make([]Email{}),
@@ -169,6 +272,16 @@ func (file File) MarshalJSON() ([]byte, error) {
 		MIMEType    		  string    `json:"mime_type"`
 		Fingerprint	    	  string   	`json:"fingerprint"`
 		Content 			  string   	`json:"content,omitempty"`
+		XScore 				  int   	`json:"xscore,omitempty"`
+		Language 			  string   	`json:"language,omitempty"`
+		StorageID 			  string   	`json:"storage_id,omitempty"`
+		Relations 			  string   	`json:"relations,omitempty"`
+		SearchTerm 			  string   	`json:"search_term,omitempty"`
+
+		Secrets 			  []Secret 	`json:"secrets,omitempty"`
+		PIIs 				  []PII 	`json:"pii,omitempty"`
+		Wallets 			  []Wallet 	`json:"wallets,omitempty"`
+		Cookies 			  []Cookie 	`json:"cookies,omitempty"`
 
 	}{
 		Provider 			: file.Provider,
@@ -184,6 +297,15 @@ func (file File) MarshalJSON() ([]byte, error) {
 		MIMEType 			: file.MIMEType,
 		Fingerprint			: file.Fingerprint,
 		Content			 	: file.Content,
+		XScore 				: file.XScore,
+		Language 			: file.Language,
+		StorageID 			: file.StorageID,
+		Relations 			: file.Relations,
+		SearchTerm 			: file.SearchTerm,
+		Secrets 			: file.Secrets,
+		PIIs 				: file.PIIs,
+		Wallets 			: file.Wallets,
+		Cookies 			: file.Cookies,
 	})
 }
 
@@ -201,6 +323,7 @@ func (cred Credential) MarshalJSON() ([]byte, error) {
 		UrlDomain			  string    `json:"url_domain,omitempty"`
 		Severity	    	  int   	`json:"severity"`
 		Entropy  	    	  float32  	`json:"entropy"`
+		Attributes	    	  string   	`json:"attributes,omitempty"`
 		NearText	    	  string   	`json:"near_text"`
 
 	}{
@@ -214,11 +337,119 @@ func (cred Credential) MarshalJSON() ([]byte, error) {
 		UrlDomain			: strings.ToLower(cred.UrlDomain),
 		Severity 			: cred.Severity,
 		Entropy 			: cred.Entropy,
+		Attributes 			: cred.Attributes,
 		NearText 			: cred.NearText,
 	})
 }
 
 
+/* Custom Marshaller for Secret */
+func (sec Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Rule                  string    `json:"rule"`
+		Time 	              string    `json:"time"`
+		Type 		    	  string   	`json:"type"`
+		Value 		    	  string   	`json:"value"`
+		Severity	    	  int   	`json:"severity"`
+		Entropy  	    	  float32  	`json:"entropy"`
+		NearText	    	  string   	`json:"near_text"`
+
+	}{
+		Rule 				: sec.Rule,
+		Time 	    		: sec.Time.Format(time.RFC3339),
+		Type 				: sec.Type,
+		Value 				: sec.Value,
+		Severity 			: sec.Severity,
+		Entropy 			: sec.Entropy,
+		NearText 			: sec.NearText,
+	})
+}
+
+/* Custom Marshaller for PII */
+func (p PII) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Rule                  string    `json:"rule"`
+		Time 	              string    `json:"time"`
+		Type 		    	  string   	`json:"type"`
+		Value 		    	  string   	`json:"value"`
+		Detail 		    	  string   	`json:"detail,omitempty"`
+		Validated 	    	  bool   	`json:"validated"`
+		NearText	    	  string   	`json:"near_text"`
+
+	}{
+		Rule 				: p.Rule,
+		Time 	    		: p.Time.Format(time.RFC3339),
+		Type 				: p.Type,
+		Value 				: p.Value,
+		Detail 				: p.Detail,
+		Validated 			: p.Validated,
+		NearText 			: p.NearText,
+	})
+}
+
+/* Custom Marshaller for Wallet */
+func (w Wallet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Rule                  string    `json:"rule"`
+		Time 	              string    `json:"time"`
+		Type 		    	  string   	`json:"type"`
+		Value 		    	  string   	`json:"value"`
+		Detail 		    	  string   	`json:"detail,omitempty"`
+		Validated 	    	  bool   	`json:"validated"`
+		Severity	    	  int   	`json:"severity"`
+		NearText	    	  string   	`json:"near_text"`
+
+	}{
+		Rule 				: w.Rule,
+		Time 	    		: w.Time.Format(time.RFC3339),
+		Type 				: w.Type,
+		Value 				: w.Value,
+		Detail 				: w.Detail,
+		Validated 			: w.Validated,
+		Severity 			: w.Severity,
+		NearText 			: w.NearText,
+	})
+}
+
+/* Custom Marshaller for Cookie */
+func (c Cookie) MarshalJSON() ([]byte, error) {
+	expires := ""
+	if !c.Expires.IsZero() {
+		expires = c.Expires.Format(time.RFC3339)
+	}
+
+	return json.Marshal(&struct {
+		Rule                  string    `json:"rule"`
+		Time 	              string    `json:"time"`
+		Browser 	    	  string   	`json:"browser,omitempty"`
+		Domain 		    	  string   	`json:"domain"`
+		Path 		    	  string   	`json:"path"`
+		Name 		    	  string   	`json:"name"`
+		Value 		    	  string   	`json:"value"`
+		Expires 	    	  string   	`json:"expires,omitempty"`
+		Secure 		    	  bool   	`json:"secure"`
+		HttpOnly 	    	  bool   	`json:"http_only"`
+		Session 	    	  bool   	`json:"session"`
+		Severity	    	  int   	`json:"severity"`
+		NearText	    	  string   	`json:"near_text"`
+
+	}{
+		Rule 				: c.Rule,
+		Time 	    		: c.Time.Format(time.RFC3339),
+		Browser 			: c.Browser,
+		Domain 				: strings.ToLower(c.Domain),
+		Path 				: c.Path,
+		Name 				: c.Name,
+		Value 				: c.Value,
+		Expires 			: expires,
+		Secure 				: c.Secure,
+		HttpOnly 			: c.HttpOnly,
+		Session 			: c.Session,
+		Severity 			: c.Severity,
+		NearText 			: c.NearText,
+	})
+}
+
 /* Custom Marshaller for URL */
 func (u URL) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
@@ -241,12 +472,14 @@ func (eml Email) MarshalJSON() ([]byte, error) {
 		Time 	              string    `json:"time"`
 		Domain   	    	  string   	`json:"domain"`
 		Email 		    	  string   	`json:"email"`
+		Attributes	    	  string   	`json:"attributes,omitempty"`
 		NearText	    	  string   	`json:"near_text"`
 
 	}{
 		Time 	    		: eml.Time.Format(time.RFC3339),
 		Domain 				: strings.ToLower(eml.Domain),
 		Email 				: strings.ToLower(eml.Email),
+		Attributes 			: eml.Attributes,
 		NearText 			: eml.NearText,
 	})
 }
@@ -258,6 +491,30 @@ func (cred Credential) CalcHash(additional_data string) string {
 	return hash
 }
 
+func (sec Secret) CalcHash(additional_data string) string {
+	var hash string
+	_calcHash(&hash, additional_data, sec.Time, sec.Rule, sec.Type, sec.Value)
+	return hash
+}
+
+func (p PII) CalcHash(additional_data string) string {
+	var hash string
+	_calcHash(&hash, additional_data, p.Time, p.Type, p.Value)
+	return hash
+}
+
+func (w Wallet) CalcHash(additional_data string) string {
+	var hash string
+	_calcHash(&hash, additional_data, w.Time, w.Type, w.Value)
+	return hash
+}
+
+func (c Cookie) CalcHash(additional_data string) string {
+	var hash string
+	_calcHash(&hash, additional_data, c.Time, c.Domain, c.Path, c.Name, c.Value)
+	return hash
+}
+
 func (u URL) CalcHash(additional_data string) string {
 	var hash string
 	_calcHash(&hash, additional_data, u.Time, u.Url)
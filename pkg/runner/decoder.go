@@ -2,16 +2,33 @@ package runner
 
 import (
     "bytes"
+    "compress/flate"
+    "compress/gzip"
+    "compress/zlib"
     "encoding/base64"
+    "encoding/hex"
     "fmt"
+    "html"
+    "io"
     "regexp"
+    "sort"
+    "strconv"
     "unicode"
+    "unicode/utf16"
+    "unicode/utf8"
 
     //logger "github.com/helviojunior/intelparser/pkg/log"
 )
 
 var b64LikelyChars [128]byte
 var b64Regexp = regexp.MustCompile(`[\w/+-]{16,}={0,3}`)
+var hexRegexp = regexp.MustCompile(`\b[0-9a-fA-F]{16,}\b`)
+var percentRegexp = regexp.MustCompile(`(?:%[0-9a-fA-F]{2})+`)
+var unicodeRegexp = regexp.MustCompile(`(?:\\u[0-9a-fA-F]{4}|\\x[0-9a-fA-F]{2})+`)
+var htmlRegexp = regexp.MustCompile(`(?i)(?:&(?:[a-z][a-z0-9]{1,31}|#[0-9]{1,7}|#x[0-9a-f]{1,6});|<br\s*/?>)+`)
+var htmlBreakRegexp = regexp.MustCompile(`(?i)<br\s*/?>`)
+var qpRegexp = regexp.MustCompile(`[^\s=]*(?:=[0-9A-F]{2}|=\r?\n)(?:=[0-9A-F]{2}|=\r?\n|[^\s=])*`)
+var qpEscapeRegexp = regexp.MustCompile(`=[0-9A-F]{2}`)
 var decoders = []func(string) ([]byte, error){
     base64.StdEncoding.DecodeString,
     base64.RawURLEncoding.DecodeString,
@@ -24,6 +41,43 @@ func init() {
     }
 }
 
+// defaultMaxInflateBytes limits the size of inflated compressed segments
+const defaultMaxInflateBytes = 10 * 1000000
+
+// SegmentDecoder finds and decodes one kind of encoded value.
+// Decode must return nil when the value can not be decoded.
+// Printable results are used as is, binary results are inflated when
+// compressed (gzip, zlib or deflate) or discarded.
+type SegmentDecoder struct {
+    // The type of encoding, used at the finding tags (decoded:<encoding>)
+    Encoding string
+
+    // Regex used to locate candidate segments
+    Regex *regexp.Regexp
+
+    // Decode the candidate segment
+    Decode func(string) []byte
+}
+
+// decodedValue is a cached decoding result
+type decodedValue struct {
+    value    string
+    encoding string
+}
+
+// DefaultSegmentDecoders returns the built-in decoders in precedence order.
+// When segments of different decoders overlap the first one wins.
+func DefaultSegmentDecoders() []SegmentDecoder {
+    return []SegmentDecoder{
+        { Encoding: "percent", Regex: percentRegexp, Decode: decodePercent },
+        { Encoding: "unicode", Regex: unicodeRegexp, Decode: decodeUnicodeEscapes },
+        { Encoding: "html", Regex: htmlRegexp, Decode: decodeHtmlEntities },
+        { Encoding: "quoted-printable", Regex: qpRegexp, Decode: decodeQuotedPrintable },
+        { Encoding: "hex", Regex: hexRegexp, Decode: decodeHex },
+        { Encoding: "base64", Regex: b64Regexp, Decode: decodeValue },
+    }
+}
+
 // EncodedSegment represents a portion of text that is encoded in some way.
 // `decode` supports recusive decoding and can result in "segment trees".
 // There can be multiple segments in the original text, so each can be thought
@@ -133,16 +187,28 @@ func (s EncodedSegment) tags() []string {
 
 // Decoder decodes various types of data in place
 type Decoder struct {
-    decodedMap map[string]string
+    decodedMap map[string]decodedValue
+    segmentDecoders []SegmentDecoder
+
+    // MaxInflateBytes limits the size of inflated compressed segments,
+    // bigger payloads are discarded
+    MaxInflateBytes int64
 }
 
 // NewDecoder creates a default decoder struct
 func NewDecoder() *Decoder {
     return &Decoder{
-        decodedMap: make(map[string]string),
+        decodedMap: make(map[string]decodedValue),
+        segmentDecoders: DefaultSegmentDecoders(),
+        MaxInflateBytes: defaultMaxInflateBytes,
     }
 }
 
+// AddSegmentDecoder registers an additional decoder with the lowest precedence
+func (d *Decoder) AddSegmentDecoder(sd SegmentDecoder) {
+    d.segmentDecoders = append(d.segmentDecoders, sd)
+}
+
 // decode returns the data with the values decoded in-place
 func (d *Decoder) decode(data string, parentSegments []EncodedSegment) (string, []EncodedSegment) {
     segments := d.findEncodedSegments(data, parentSegments)
@@ -164,6 +230,19 @@ func (d *Decoder) decode(data string, parentSegments []EncodedSegment) (string,
     return data, segments
 }
 
+// decodeAll returns the value decoded up to maxDepth passes, used to compare
+// the values found at different decoding passes
+func (d *Decoder) decodeAll(data string, maxDepth int) string {
+    segments := []EncodedSegment{}
+    for i := 0; i < maxDepth; i++ {
+        if data, segments = d.decode(data, segments); len(segments) == 0 {
+            break
+        }
+    }
+
+    return data
+}
+
 // findEncodedSegments finds the encoded segments in the data and updates the
 // segment tree for this pass
 func (d *Decoder) findEncodedSegments(data string, parentSegments []EncodedSegment) []EncodedSegment {
@@ -171,51 +250,63 @@ func (d *Decoder) findEncodedSegments(data string, parentSegments []EncodedSegme
         return []EncodedSegment{}
     }
 
-    matchIndices := b64Regexp.FindAllStringIndex(data, -1)
-    if matchIndices == nil {
-        return []EncodedSegment{}
-    }
+    segments := []EncodedSegment{}
 
-    segments := make([]EncodedSegment, 0, len(matchIndices))
+    for _, sd := range d.segmentDecoders {
+        matchIndices := sd.Regex.FindAllStringIndex(data, -1)
 
-    // Keeps up with offsets from the text changing size as things are decoded
-    decodedShift := 0
+    MatchLoop:
+        for _, matchIndex := range matchIndices {
+            // Skip values already claimed by a decoder with higher precedence
+            for _, s := range segments {
+                if matchIndex[0] < s.relativeEnd && matchIndex[1] > s.relativeStart {
+                    continue MatchLoop
+                }
+            }
 
-    for _, matchIndex := range matchIndices {
-        encodedValue := data[matchIndex[0]:matchIndex[1]]
+            encodedValue := data[matchIndex[0]:matchIndex[1]]
+            key := sd.Encoding + ":" + encodedValue
 
-        if !isLikelyB64(encodedValue) {
-            d.decodedMap[encodedValue] = ""
-            continue
-        }
+            decoded, alreadyDecoded := d.decodedMap[key]
 
-        decodedValue, alreadyDecoded := d.decodedMap[encodedValue]
+            // We haven't decoded this yet, so go ahead and decode it
+            if !alreadyDecoded {
+                decoded = d.decodeSegment(sd, encodedValue)
+                d.decodedMap[key] = decoded
+            }
 
-        // We haven't decoded this yet, so go ahead and decode it
-        if !alreadyDecoded {
-            decodedValue = decodeValue(encodedValue)
-            d.decodedMap[encodedValue] = decodedValue
-        }
+            // Skip this segment because there was nothing to check
+            if len(decoded.value) == 0 || decoded.value == encodedValue {
+                continue
+            }
 
-        // Skip this segment because there was nothing to check
-        if len(decodedValue) == 0 {
-            continue
+            segments = append(segments, EncodedSegment{
+                relativeStart: matchIndex[0],
+                relativeEnd:   matchIndex[1],
+                absoluteStart: matchIndex[0],
+                absoluteEnd:   matchIndex[1],
+                decodedValue:  decoded.value,
+                encoding:      decoded.encoding,
+            })
         }
+    }
 
-        // Create a segment for the encoded data
-        segment := EncodedSegment{
-            relativeStart: matchIndex[0],
-            relativeEnd:   matchIndex[1],
-            absoluteStart: matchIndex[0],
-            absoluteEnd:   matchIndex[1],
-            decodedStart:  matchIndex[0] + decodedShift,
-            decodedEnd:    matchIndex[0] + decodedShift + len(decodedValue),
-            decodedValue:  decodedValue,
-            encoding:      "base64",
-        }
+    sort.Slice(segments, func(i, j int) bool {
+        return segments[i].relativeStart < segments[j].relativeStart
+    })
+
+    // Keeps up with offsets from the text changing size as things are decoded
+    decodedShift := 0
+
+    for i := range segments {
+        segment := &segments[i]
+        encodedLen := segment.relativeEnd - segment.relativeStart
+
+        segment.decodedStart = segment.relativeStart + decodedShift
+        segment.decodedEnd = segment.decodedStart + len(segment.decodedValue)
 
         // Shift decoded start and ends based on size changes
-        decodedShift += len(decodedValue) - len(encodedValue)
+        decodedShift += len(segment.decodedValue) - encodedLen
 
         // Adjust the absolute position of segments contained in parent segments
         for _, parentSegment := range parentSegments {
@@ -228,23 +319,229 @@ func (d *Decoder) findEncodedSegments(data string, parentSegments []EncodedSegme
         }
 
         //logger.Debugf("segment found: %#v", segment)
-        segments = append(segments, segment)
     }
 
     return segments
 }
 
+// decodeSegment decodes the value and inflates compressed binary results.
+// The returned encoding is the decoder chain (e.g. base64,gzip)
+func (d *Decoder) decodeSegment(sd SegmentDecoder, encodedValue string) decodedValue {
+    data := sd.Decode(encodedValue)
+    if len(data) == 0 {
+        return decodedValue{}
+    }
+
+    if isPrintable(data) {
+        return decodedValue{ value: string(data), encoding: sd.Encoding }
+    }
+
+    // Raw deflate has no magic, so it is only tried on base64 payloads
+    rawDeflate := sd.Encoding == "base64"
+    if inflated, compression := inflate(data, d.MaxInflateBytes, rawDeflate); len(inflated) > 0 && isPrintable(inflated) {
+        return decodedValue{ value: string(inflated), encoding: sd.Encoding + "," + compression }
+    }
+
+    return decodedValue{}
+}
+
+// inflate detects gzip, zlib or raw deflate data and decompresses it up to maxSize bytes.
+// Payloads bigger than maxSize are discarded. Raw deflate is only tried when rawDeflate
+// is set and must end exactly at the end of the data, as it has no magic or checksum.
+func inflate(data []byte, maxSize int64, rawDeflate bool) ([]byte, string) {
+    var reader io.ReadCloser
+    var compression string
+    var err error
+
+    if maxSize <= 0 {
+        return nil, ""
+    }
+
+    src := bytes.NewReader(data)
+
+    switch {
+    case len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b:
+        compression = "gzip"
+        reader, err = gzip.NewReader(src)
+    case len(data) > 2 && data[0] & 0x0f == 0x08 && (uint16(data[0]) << 8 | uint16(data[1])) % 31 == 0:
+        compression = "zlib"
+        reader, err = zlib.NewReader(src)
+    case rawDeflate && len(data) > 2 && (data[0] >> 1) & 0x03 != 0x03:
+        // Valid block type (stored, fixed or dynamic huffman)
+        compression = "deflate"
+        reader = flate.NewReader(src)
+    default:
+        return nil, ""
+    }
+    if err != nil {
+        return nil, ""
+    }
+    defer reader.Close()
+
+    inflated, err := io.ReadAll(io.LimitReader(reader, maxSize + 1))
+    if err != nil || int64(len(inflated)) > maxSize {
+        return nil, ""
+    }
+
+    // The final deflate block must be the end of the data, random bytes
+    // are often decoded as a short stream followed by garbage
+    if compression == "deflate" && src.Len() > 0 {
+        return nil, ""
+    }
+
+    return inflated, compression
+}
+
 // decoders tries a list of decoders and returns the first successful one
-func decodeValue(encodedValue string) string {
+func decodeValue(encodedValue string) []byte {
+    if !isLikelyB64(encodedValue) {
+        return nil
+    }
+
+    var binary []byte
     for _, decoder := range decoders {
         decodedValue, err := decoder(encodedValue)
+        if err != nil || len(decodedValue) == 0 {
+            continue
+        }
+
+        if isASCII(decodedValue) {
+            return decodedValue
+        }
 
-        if err == nil && len(decodedValue) > 0 && isASCII(decodedValue) {
-            return string(decodedValue)
+        // Keep the first binary result, it may be compressed
+        if binary == nil {
+            binary = decodedValue
         }
     }
 
-    return ""
+    return binary
+}
+
+// decodeHex decodes hex strings
+func decodeHex(encodedValue string) []byte {
+    if len(encodedValue) % 2 != 0 {
+        return nil
+    }
+
+    decodedValue, err := hex.DecodeString(encodedValue)
+    if err != nil {
+        return nil
+    }
+
+    return decodedValue
+}
+
+// decodePercent decodes a sequence of URL percent encoded bytes
+func decodePercent(encodedValue string) []byte {
+    decodedValue := make([]byte, 0, len(encodedValue) / 3)
+    for i := 0; i + 2 < len(encodedValue); i += 3 {
+        b, err := strconv.ParseUint(encodedValue[i + 1:i + 3], 16, 8)
+        if err != nil {
+            return nil
+        }
+        decodedValue = append(decodedValue, byte(b))
+    }
+
+    return decodedValue
+}
+
+// decodeUnicodeEscapes decodes \uXXXX (including surrogate pairs) and \xNN escapes
+func decodeUnicodeEscapes(encodedValue string) []byte {
+    var decodedValue bytes.Buffer
+    for i := 0; i < len(encodedValue); {
+        if encodedValue[i + 1] == 'x' {
+            b, _ := strconv.ParseUint(encodedValue[i + 2:i + 4], 16, 8)
+            decodedValue.WriteByte(byte(b))
+            i += 4
+            continue
+        }
+
+        r1, _ := strconv.ParseUint(encodedValue[i + 2:i + 6], 16, 16)
+        i += 6
+        r := rune(r1)
+        if utf16.IsSurrogate(r) && i + 6 <= len(encodedValue) && encodedValue[i + 1] == 'u' {
+            if r2, err := strconv.ParseUint(encodedValue[i + 2:i + 6], 16, 16); err == nil {
+                if dr := utf16.DecodeRune(r, rune(r2)); dr != unicode.ReplacementChar {
+                    r = dr
+                    i += 6
+                }
+            }
+        }
+        decodedValue.WriteRune(r)
+    }
+
+    return decodedValue.Bytes()
+}
+
+// decodeHtmlEntities decodes named and numeric HTML entities and <br> tags
+func decodeHtmlEntities(encodedValue string) []byte {
+    return []byte(html.UnescapeString(htmlBreakRegexp.ReplaceAllString(encodedValue, "\n")))
+}
+
+// decodeQuotedPrintable decodes quoted-printable words, joining soft line breaks.
+// Words with soft line breaks only are ignored as they are most likely base64 padding.
+// Without a soft line break at least two escaped non-ASCII bytes (e.g. =C3=A9) are
+// required, so query strings like a=20&b=3D are not taken as quoted-printable.
+func decodeQuotedPrintable(encodedValue string) []byte {
+    if !qpEscapeRegexp.MatchString(encodedValue) {
+        return nil
+    }
+
+    softBreaks := 0
+    nonASCII := 0
+    decodedValue := make([]byte, 0, len(encodedValue))
+    for i := 0; i < len(encodedValue); i++ {
+        c := encodedValue[i]
+        if c != '=' {
+            decodedValue = append(decodedValue, c)
+            continue
+        }
+
+        // Soft line break
+        if i + 1 < len(encodedValue) && (encodedValue[i + 1] == '\n' || encodedValue[i + 1] == '\r') {
+            i++
+            if encodedValue[i] == '\r' && i + 1 < len(encodedValue) && encodedValue[i + 1] == '\n' {
+                i++
+            }
+            softBreaks++
+            continue
+        }
+
+        if i + 3 > len(encodedValue) {
+            return nil
+        }
+        b, err := strconv.ParseUint(encodedValue[i + 1:i + 3], 16, 8)
+        if err != nil {
+            return nil
+        }
+        if b > unicode.MaxASCII {
+            nonASCII++
+        }
+        decodedValue = append(decodedValue, byte(b))
+        i += 2
+    }
+
+    if softBreaks == 0 && nonASCII < 2 {
+        return nil
+    }
+
+    return decodedValue
+}
+
+// isPrintable checks for valid UTF-8 text without control chars other than \t, \r and \n
+func isPrintable(b []byte) bool {
+    if !utf8.Valid(b) {
+        return false
+    }
+
+    for i := 0; i < len(b); i++ {
+        if b[i] < ' ' && b[i] != '\t' && b[i] != '\r' && b[i] != '\n' {
+            return false
+        }
+    }
+
+    return true
 }
 
 func isASCII(b []byte) bool {
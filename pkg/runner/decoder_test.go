@@ -0,0 +1,210 @@
+package runner
+
+import (
+    "bytes"
+    "compress/flate"
+    "compress/gzip"
+    "compress/zlib"
+    "encoding/base64"
+    "encoding/hex"
+    "io"
+    "log/slog"
+    "math/rand"
+    "strings"
+    "testing"
+)
+
+func TestSegmentDecoders(t *testing.T) {
+    tests := []struct {
+        name    string
+        decode  func(string) []byte
+        encoded string
+        want    string
+    }{
+        {"percent", decodePercent, "%61%40%62", "a@b"},
+        {"percent invalid", decodePercent, "%6G", ""},
+        {"unicode", decodeUnicodeEscapes, `\u0061\u00e9\x40`, "aé@"},
+        {"unicode surrogate pair", decodeUnicodeEscapes, `\ud83d\ude00`, "\U0001F600"},
+        {"html", decodeHtmlEntities, "&lt;a&gt;&#64;&#x41;<br/>", "<a>@A\n"},
+        {"hex", decodeHex, hex.EncodeToString([]byte("password123")), "password123"},
+        {"hex odd size", decodeHex, "abc", ""},
+        {"base64", decodeValue, base64.StdEncoding.EncodeToString([]byte("user:pass@example.com")), "user:pass@example.com"},
+        {"base64 url", decodeValue, base64.RawURLEncoding.EncodeToString([]byte("user:pass@example.com?")), "user:pass@example.com?"},
+    }
+
+    for _, tt := range tests {
+        if got := string(tt.decode(tt.encoded)); got != tt.want {
+            t.Errorf("%s: decode(%q) = %q, want %q", tt.name, tt.encoded, got, tt.want)
+        }
+    }
+}
+
+func TestDecodeQuotedPrintable(t *testing.T) {
+    tests := []struct {
+        name    string
+        encoded string
+        want    string
+    }{
+        {"non-ASCII escapes", "Jos=C3=A9", "José"},
+        {"soft line break", "senha=3D=\r\nabc", "senha=abc"},
+        {"query string", "a=20&b=3D", ""},
+        {"single non-ASCII escape", "caf=E9", ""},
+        {"base64 padding", "YWJj=\n", ""},
+        {"truncated escape", "Jos=C3=A", ""},
+    }
+
+    for _, tt := range tests {
+        if got := string(decodeQuotedPrintable(tt.encoded)); got != tt.want {
+            t.Errorf("%s: decodeQuotedPrintable(%q) = %q, want %q", tt.name, tt.encoded, got, tt.want)
+        }
+    }
+}
+
+func TestDecoderDecode(t *testing.T) {
+    gz := bytes.Buffer{}
+    w := gzip.NewWriter(&gz)
+    io.WriteString(w, "admin:Sup3rS3cret@intranet")
+    w.Close()
+
+    tests := []struct {
+        name     string
+        data     string
+        want     string
+        encoding string
+    }{
+        {"percent", "url=http://host/?u=a%40b.com", "url=http://host/?u=a@b.com", "percent"},
+        {"base64 gzip", "blob " + base64.StdEncoding.EncodeToString(gz.Bytes()), "blob admin:Sup3rS3cret@intranet", "base64,gzip"},
+        {"query string is not quoted-printable", "http://host/?a=20&b=3D", "http://host/?a=20&b=3D", ""},
+    }
+
+    for _, tt := range tests {
+        got, segments := NewDecoder().decode(tt.data, []EncodedSegment{})
+        if got != tt.want {
+            t.Errorf("%s: decode(%q) = %q, want %q", tt.name, tt.data, got, tt.want)
+        }
+
+        encoding := ""
+        if len(segments) > 0 {
+            encoding = segments[0].encoding
+        }
+        if encoding != tt.encoding {
+            t.Errorf("%s: encoding = %q, want %q", tt.name, encoding, tt.encoding)
+        }
+    }
+}
+
+func TestDecodeAll(t *testing.T) {
+    // base64 of a percent encoded value
+    encoded := base64.StdEncoding.EncodeToString([]byte("mailto:user%40example.com?id=1234"))
+    if got := NewDecoder().decodeAll(encoded, 3); got != "mailto:user@example.com?id=1234" {
+        t.Errorf("decodeAll(%q) = %q", encoded, got)
+    }
+}
+
+func TestDetectDecodedDuplicates(t *testing.T) {
+    run, err := NewRunner(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, *NewDefaultOptions(), nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    findings := run.Detect(Fragment{Raw: "see https://example.com/login?user=admin%40example.com for details"})
+
+    urls := []string{}
+    for _, f := range findings {
+        if strings.HasPrefix(f.Secret, "https://") {
+            urls = append(urls, f.Secret)
+        }
+    }
+
+    if len(urls) != 1 || !strings.Contains(urls[0], "admin@example.com") {
+        t.Errorf("URL findings = %q, want only the decoded URL", urls)
+    }
+}
+
+// An unrelated encoded occurrence of the same value keeps the plain finding
+func TestDetectDecodedDuplicatesLocation(t *testing.T) {
+    run, err := NewRunner(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, *NewDefaultOptions(), nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    findings := run.Detect(Fragment{Raw: "contact admin@example.com\nother line\ntoken dXNlcj1hZG1pbkBleGFtcGxlLmNvbQ==\n"})
+
+    lines := []int{}
+    for _, f := range findings {
+        if f.Secret == "admin@example.com" {
+            lines = append(lines, f.StartLine)
+        }
+    }
+
+    if len(lines) != 2 || lines[0] == lines[1] {
+        t.Errorf("e-mail findings at lines %v, want the plain and the decoded ones", lines)
+    }
+}
+
+func TestInflate(t *testing.T) {
+    plain := []byte(strings.Repeat("user=admin password=Sup3rS3cret ", 4))
+
+    gz := bytes.Buffer{}
+    gw := gzip.NewWriter(&gz)
+    gw.Write(plain)
+    gw.Close()
+
+    zl := bytes.Buffer{}
+    zw := zlib.NewWriter(&zl)
+    zw.Write(plain)
+    zw.Close()
+
+    df := bytes.Buffer{}
+    fw, _ := flate.NewWriter(&df, flate.BestCompression)
+    fw.Write(plain)
+    fw.Close()
+
+    tests := []struct {
+        name        string
+        data        []byte
+        rawDeflate  bool
+        maxSize     int64
+        compression string
+    }{
+        {"gzip", gz.Bytes(), false, 1000, "gzip"},
+        {"zlib", zl.Bytes(), false, 1000, "zlib"},
+        {"raw deflate", df.Bytes(), true, 1000, "deflate"},
+        {"raw deflate not allowed", df.Bytes(), false, 1000, ""},
+        {"raw deflate with trailing data", append(append([]byte{}, df.Bytes()...), 0x41, 0x42), true, 1000, ""},
+        {"truncated gzip", gz.Bytes()[:gz.Len() - 10], false, 1000, ""},
+        {"bigger than the limit", gz.Bytes(), false, 10, ""},
+        {"inflating disabled", gz.Bytes(), false, 0, ""},
+    }
+
+    for _, tt := range tests {
+        inflated, compression := inflate(tt.data, tt.maxSize, tt.rawDeflate)
+        if compression != tt.compression {
+            t.Errorf("%s: compression = %q, want %q", tt.name, compression, tt.compression)
+        }
+        if tt.compression != "" && !bytes.Equal(inflated, plain) {
+            t.Errorf("%s: inflated = %q", tt.name, inflated)
+        }
+    }
+}
+
+func TestInflateRandomData(t *testing.T) {
+    // Random binary payloads must not be taken as raw deflate
+    rnd := rand.New(rand.NewSource(1))
+    d := NewDecoder()
+    sd := SegmentDecoder{Encoding: "base64"}
+
+    accepted := 0
+    for i := 0; i < 1000; i++ {
+        data := make([]byte, 16 + rnd.Intn(64))
+        rnd.Read(data)
+        sd.Decode = func(string) []byte { return data }
+        if v := d.decodeSegment(sd, "x"); v.value != "" {
+            accepted++
+        }
+    }
+
+    if accepted > 0 {
+        t.Errorf("%d of 1000 random payloads were decoded", accepted)
+    }
+}
@@ -0,0 +1,347 @@
+package runner
+
+import (
+    "archive/zip"
+    "encoding/xml"
+    "errors"
+    "io"
+    "path"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// errNotDocument is returned for binary files without a text extractor
+var errNotDocument = errors.New("not a supported document")
+
+// documentMaxBytes limits the decompressed data (zip entries, PDF streams) of a
+// document without size limit, so zip bombs are not inflated without bound
+const documentMaxBytes = 200 * 1000000
+
+// documentTextExtractor extracts the plain text of binary document formats
+// (OOXML, ODF and PDF), so the detection may run on them. The output is
+// truncated at maxBytes.
+type documentTextExtractor struct {
+    maxBytes int64
+    text     strings.Builder
+}
+
+// extractDocumentText returns the plain text of a docx/xlsx/pptx, ODF (odt/ods/odp)
+// or PDF file. errNotDocument is returned for any other file type.
+func extractDocumentText(file_path string, mime string, maxBytes int64) (string, error) {
+    ex := &documentTextExtractor{ maxBytes: maxBytes }
+
+    var err error
+    switch {
+    case mime == "application/pdf":
+        err = ex.pdf(file_path)
+    case mime == "application/zip" || strings.HasPrefix(mime, "application/vnd.openxmlformats-officedocument.") ||
+        strings.HasPrefix(mime, "application/vnd.oasis.opendocument."):
+        err = ex.zipDocument(file_path)
+    default:
+        err = errNotDocument
+    }
+
+    if err != nil {
+        return "", err
+    }
+
+    return ex.text.String(), nil
+}
+
+// full returns true when the output reached the size limit
+func (ex *documentTextExtractor) full() bool {
+    return ex.maxBytes > 0 && int64(ex.text.Len()) >= ex.maxBytes
+}
+
+func (ex *documentTextExtractor) write(s string) {
+    if ex.full() {
+        return
+    }
+    ex.text.WriteString(s)
+}
+
+// newLine ends the current line, ignoring empty ones
+func (ex *documentTextExtractor) newLine() {
+    s := ex.text.String()
+    if len(s) > 0 && s[len(s) - 1] != '\n' {
+        ex.write("\n")
+    }
+}
+
+// zipDocument handles the zip based formats, the document type is identified by the entries
+func (ex *documentTextExtractor) zipDocument(file_path string) error {
+    zr, err := zip.OpenReader(file_path)
+    if err != nil {
+        return err
+    }
+    defer zr.Close()
+
+    entries := map[string]*zip.File{}
+    for _, f := range zr.File {
+        entries[f.Name] = f
+    }
+
+    // Entries are sorted by name length first, so sheet2.xml comes before sheet10.xml
+    matching := func(prefix string) []*zip.File {
+        files := []*zip.File{}
+        for _, f := range zr.File {
+            if strings.HasPrefix(f.Name, prefix) && strings.HasSuffix(f.Name, ".xml") && path.Dir(f.Name) == path.Dir(prefix + "x") {
+                files = append(files, f)
+            }
+        }
+        sort.Slice(files, func(i, j int) bool {
+            if len(files[i].Name) != len(files[j].Name) {
+                return len(files[i].Name) < len(files[j].Name)
+            }
+            return files[i].Name < files[j].Name
+        })
+        return files
+    }
+
+    parts := []*zip.File{}
+    var textElement string
+    switch {
+    case entries["word/document.xml"] != nil:
+        textElement = "t"
+        parts = append(parts, entries["word/document.xml"])
+        for _, p := range []string{"word/header", "word/footer", "word/footnotes", "word/endnotes", "word/comments"} {
+            parts = append(parts, matching(p)...)
+        }
+
+    case entries["xl/workbook.xml"] != nil:
+        return ex.xlsx(entries, matching("xl/worksheets/sheet"))
+
+    case entries["ppt/presentation.xml"] != nil:
+        textElement = "t"
+        parts = append(matching("ppt/slides/slide"), matching("ppt/notesSlides/notesSlide")...)
+
+    case entries["content.xml"] != nil && entries["mimetype"] != nil:
+        // ODF, all the char data of the body is text
+        parts = append(parts, entries["content.xml"])
+
+    default:
+        return errNotDocument
+    }
+
+    for _, p := range parts {
+        if ex.full() {
+            break
+        }
+        if err := ex.xmlPart(p, textElement); err != nil {
+            return err
+        }
+        ex.newLine()
+    }
+
+    return nil
+}
+
+// openPart opens a zip entry limiting the uncompressed size
+func (ex *documentTextExtractor) openPart(f *zip.File) (io.ReadCloser, io.Reader, error) {
+    rc, err := f.Open()
+    if err != nil {
+        return nil, nil, err
+    }
+
+    // XML markup is much larger than the text
+    limit := int64(documentMaxBytes)
+    if ex.maxBytes > 0 {
+        limit = ex.maxBytes * 10
+    }
+    return rc, io.LimitReader(rc, limit), nil
+}
+
+// xmlPart writes the text of an OOXML/ODF XML entry. When textElement is set only
+// its char data is text (w:t, a:t), otherwise all char data is used (ODF).
+// Paragraphs, breaks and table rows end lines and table cells are tab separated.
+func (ex *documentTextExtractor) xmlPart(f *zip.File, textElement string) error {
+    rc, r, err := ex.openPart(f)
+    if err != nil {
+        return err
+    }
+    defer rc.Close()
+
+    dec := xml.NewDecoder(r)
+    dec.Strict = false
+    inText := textElement == ""
+    cells := 0
+    tabStops := 0
+
+    for !ex.full() {
+        tok, err := dec.Token()
+        if err != nil {
+            // EOF or malformed content, keep the text extracted so far
+            return nil
+        }
+
+        switch t := tok.(type) {
+        case xml.StartElement:
+            switch t.Name.Local {
+            case textElement:
+                inText = true
+            case "tc", "table-cell":
+                cells++
+            case "tabs":
+                // OOXML paragraph tab stops
+                tabStops++
+            case "tab":
+                if tabStops == 0 {
+                    ex.write("\t")
+                }
+            case "br", "cr", "line-break":
+                ex.write("\n")
+            case "s":
+                // ODF <text:s text:c="n"/> repeated spaces
+                n := 1
+                for _, a := range t.Attr {
+                    if a.Name.Local == "c" {
+                        if c, err := strconv.Atoi(a.Value); err == nil && c > 0 && c < 100 {
+                            n = c
+                        }
+                    }
+                }
+                ex.write(strings.Repeat(" ", n))
+            }
+
+        case xml.EndElement:
+            switch t.Name.Local {
+            case textElement:
+                inText = textElement == ""
+            case "p", "h":
+                // Paragraphs inside table cells are space separated
+                if cells > 0 {
+                    ex.write(" ")
+                } else {
+                    ex.newLine()
+                }
+            case "tr", "table-row":
+                ex.newLine()
+            case "tc", "table-cell":
+                if cells > 0 {
+                    cells--
+                }
+                ex.write("\t")
+            case "tabs":
+                if tabStops > 0 {
+                    tabStops--
+                }
+            }
+
+        case xml.CharData:
+            if inText {
+                ex.write(string(t))
+            }
+        }
+    }
+
+    return nil
+}
+
+// xlsx writes the worksheet rows as lines with tab separated cells
+func (ex *documentTextExtractor) xlsx(entries map[string]*zip.File, sheets []*zip.File) error {
+    sharedStrings := []string{}
+    if f := entries["xl/sharedStrings.xml"]; f != nil {
+        rc, r, err := ex.openPart(f)
+        if err != nil {
+            return err
+        }
+
+        dec := xml.NewDecoder(r)
+        dec.Strict = false
+        var si strings.Builder
+        inText := false
+        for {
+            tok, err := dec.Token()
+            if err != nil {
+                break
+            }
+            switch t := tok.(type) {
+            case xml.StartElement:
+                switch t.Name.Local {
+                case "si":
+                    si.Reset()
+                case "t":
+                    inText = true
+                }
+            case xml.EndElement:
+                switch t.Name.Local {
+                case "si":
+                    sharedStrings = append(sharedStrings, si.String())
+                case "t":
+                    inText = false
+                }
+            case xml.CharData:
+                if inText {
+                    si.Write(t)
+                }
+            }
+        }
+        rc.Close()
+    }
+
+    for _, sheet := range sheets {
+        if ex.full() {
+            break
+        }
+
+        rc, r, err := ex.openPart(sheet)
+        if err != nil {
+            return err
+        }
+
+        dec := xml.NewDecoder(r)
+        dec.Strict = false
+        cellType := ""
+        inValue := false
+        cells := 0
+        for !ex.full() {
+            tok, err := dec.Token()
+            if err != nil {
+                break
+            }
+            switch t := tok.(type) {
+            case xml.StartElement:
+                switch t.Name.Local {
+                case "row":
+                    cells = 0
+                case "c":
+                    cellType = ""
+                    for _, a := range t.Attr {
+                        if a.Name.Local == "t" {
+                            cellType = a.Value
+                        }
+                    }
+                    if cells > 0 {
+                        ex.write("\t")
+                    }
+                    cells++
+                case "v", "t":
+                    inValue = true
+                }
+            case xml.EndElement:
+                switch t.Name.Local {
+                case "row":
+                    ex.newLine()
+                case "v", "t":
+                    inValue = false
+                }
+            case xml.CharData:
+                if !inValue {
+                    continue
+                }
+                value := string(t)
+                if cellType == "s" {
+                    if i, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && i >= 0 && i < len(sharedStrings) {
+                        value = sharedStrings[i]
+                    }
+                }
+                ex.write(value)
+            }
+        }
+        rc.Close()
+        ex.newLine()
+    }
+
+    return nil
+}
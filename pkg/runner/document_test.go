@@ -0,0 +1,284 @@
+package runner
+
+import (
+    "archive/zip"
+    "bytes"
+    "compress/zlib"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+// writePdf writes a PDF file with the objects numbered from 1
+func writePdf(t *testing.T, objects ...string) string {
+    var sb strings.Builder
+    sb.WriteString("%PDF-1.4\n")
+    for i, o := range objects {
+        fmt.Fprintf(&sb, "%d 0 obj\n%s\nendobj\n", i + 1, o)
+    }
+    sb.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+
+    p := filepath.Join(t.TempDir(), "test.pdf")
+    if err := os.WriteFile(p, []byte(sb.String()), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    return p
+}
+
+func pdfContentStream(dict string, content string) string {
+    return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(content), content)
+}
+
+func zlibCompress(data string) string {
+    var buf bytes.Buffer
+    w := zlib.NewWriter(&buf)
+    w.Write([]byte(data))
+    w.Close()
+    return buf.String()
+}
+
+func TestExtractDocumentTextPdf(t *testing.T) {
+    catalog := "<< /Type /Catalog /Pages 2 0 R >>"
+    pages := "<< /Type /Pages /Kids [3 0 R] /Count 1 >>"
+    page := "<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>"
+    font := "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>"
+    content := "BT /F1 12 Tf 72 712 Td (user@example.com:secret) Tj ET"
+
+    tests := []struct {
+        name    string
+        objects []string
+        want    string
+    }{
+        {"plain", []string{ catalog, pages, page, pdfContentStream("", content), font }, "user@example.com:secret"},
+        {"flate", []string{ catalog, pages, page, pdfContentStream("/Filter /FlateDecode", zlibCompress(content)), font }, "user@example.com:secret"},
+        {"hex", []string{ catalog, pages, page, pdfContentStream("/Filter /ASCIIHexDecode", fmt.Sprintf("%x>", content)), font }, "user@example.com:secret"},
+        {"wrong length", []string{ catalog, pages, page, "<< /Length 9999 >>\nstream\n" + content + "\nendstream", font }, "user@example.com:secret"},
+        {"negative length", []string{ catalog, pages, page, "<< /Length -40 >>\nstream\n" + content + "\nendstream", font }, "user@example.com:secret"},
+        {"fractional length", []string{ catalog, pages, page, "<< /Length 3.5 >>\nstream\n" + content + "\nendstream", font }, "user@example.com:secret"},
+    }
+
+    for _, tt := range tests {
+        text, err := extractDocumentText(writePdf(t, tt.objects...), "application/pdf", 0)
+        if err != nil {
+            t.Errorf("%s: extractDocumentText() error = %v", tt.name, err)
+            continue
+        }
+        if !strings.Contains(text, tt.want) {
+            t.Errorf("%s: extractDocumentText() = %q, want to contain %q", tt.name, text, tt.want)
+        }
+    }
+}
+
+func TestExtractDocumentTextPdfObjectStream(t *testing.T) {
+    page := "<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 6 0 R >> >> >>"
+    font := "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>"
+    header := fmt.Sprintf("5 0 6 %d ", len(page) + 1)
+    objStm := header + page + " " + font
+    content := "BT /F1 12 Tf (inside object stream) Tj ET"
+
+    tests := []struct {
+        name  string
+        dict  string
+        want  bool
+    }{
+        {"valid", fmt.Sprintf("/Type /ObjStm /N 2 /First %d", len(header)), true},
+        {"negative first", "/Type /ObjStm /N 2 /First -10", false},
+        {"fractional first", "/Type /ObjStm /N 2 /First 2.5", false},
+        {"huge first", "/Type /ObjStm /N 2 /First 1e300", false},
+        {"huge count", fmt.Sprintf("/Type /ObjStm /N 1e300 /First %d", len(header)), true},
+    }
+
+    for _, tt := range tests {
+        p := writePdf(t,
+            "<< /Type /Catalog /Pages 2 0 R >>",
+            "<< /Type /Pages /Kids [5 0 R] /Count 1 >>",
+            pdfContentStream(tt.dict, objStm),
+            pdfContentStream("", content),
+        )
+        text, err := extractDocumentText(p, "application/pdf", 0)
+        if err != nil {
+            t.Errorf("%s: extractDocumentText() error = %v", tt.name, err)
+            continue
+        }
+        if got := strings.Contains(text, "inside object stream"); got != tt.want {
+            t.Errorf("%s: extractDocumentText() = %q, want text %v", tt.name, text, tt.want)
+        }
+    }
+}
+
+// Crafted object stream offsets must not panic
+func TestExtractDocumentTextPdfObjectStreamOffsets(t *testing.T) {
+    for _, offsets := range []string{ "3 -100 ", "3 -1.5 ", "-3 0 ", "3 99999 ", "3 1e300 ", "3 ", "" } {
+        p := writePdf(t,
+            "<< /Type /Catalog /Pages 2 0 R >>",
+            "<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+            pdfContentStream(fmt.Sprintf("/Type /ObjStm /N 1 /First %d", len(offsets)), offsets + "<< /Type /Page >>"),
+        )
+        if _, err := extractDocumentText(p, "application/pdf", 0); err != nil {
+            t.Errorf("%q: extractDocumentText() error = %v", offsets, err)
+        }
+    }
+}
+
+func TestPdfInt(t *testing.T) {
+    tests := []struct {
+        value interface{}
+        want  int
+        ok    bool
+    }{
+        {float64(10), 10, true},
+        {float64(0), 0, true},
+        {float64(-1), 0, false},
+        {2.5, 0, false},
+        {1e300, 0, false},
+        {pdfName("Length"), 0, false},
+        {nil, 0, false},
+    }
+
+    for _, tt := range tests {
+        got, ok := pdfInt(tt.value)
+        if got != tt.want || ok != tt.ok {
+            t.Errorf("pdfInt(%v) = %d, %v, want %d, %v", tt.value, got, ok, tt.want, tt.ok)
+        }
+    }
+}
+
+// writeZip writes a zip file with the entries
+func writeZip(t *testing.T, entries map[string]string) string {
+    p := filepath.Join(t.TempDir(), "test.zip")
+    f, err := os.Create(p)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+
+    zw := zip.NewWriter(f)
+    for name, data := range entries {
+        w, err := zw.Create(name)
+        if err != nil {
+            t.Fatal(err)
+        }
+        w.Write([]byte(data))
+    }
+    if err := zw.Close(); err != nil {
+        t.Fatal(err)
+    }
+    return p
+}
+
+func TestExtractDocumentTextZip(t *testing.T) {
+    tests := []struct {
+        name    string
+        entries map[string]string
+        want    []string
+        err     error
+    }{
+        {
+            "docx",
+            map[string]string{
+                "word/document.xml": `<w:document xmlns:w="w"><w:body><w:p><w:r><w:t>admin:</w:t></w:r><w:r><w:t>P@ssw0rd</w:t></w:r></w:p></w:body></w:document>`,
+                "word/footer1.xml": `<w:ftr xmlns:w="w"><w:p><w:r><w:t>footer text</w:t></w:r></w:p></w:ftr>`,
+            },
+            []string{"admin:P@ssw0rd", "footer text"},
+            nil,
+        },
+        {
+            "xlsx",
+            map[string]string{
+                "xl/workbook.xml": `<workbook/>`,
+                "xl/sharedStrings.xml": `<sst><si><t>user@example.com</t></si><si><t>secret</t></si></sst>`,
+                "xl/worksheets/sheet1.xml": `<worksheet><sheetData><row><c t="s"><v>0</v></c><c t="s"><v>1</v></c><c><v>42</v></c></row></sheetData></worksheet>`,
+            },
+            []string{"user@example.com", "secret", "42"},
+            nil,
+        },
+        {
+            "pptx",
+            map[string]string{
+                "ppt/presentation.xml": `<p:presentation xmlns:p="p"/>`,
+                "ppt/slides/slide1.xml": `<p:sld xmlns:p="p" xmlns:a="a"><a:p><a:r><a:t>slide one</a:t></a:r></a:p></p:sld>`,
+            },
+            []string{"slide one"},
+            nil,
+        },
+        {
+            "odt",
+            map[string]string{
+                "mimetype": "application/vnd.oasis.opendocument.text",
+                "content.xml": `<office:document-content xmlns:office="o" xmlns:text="t"><office:body><text:p>odf text</text:p></office:body></office:document-content>`,
+            },
+            []string{"odf text"},
+            nil,
+        },
+        {
+            "plain zip",
+            map[string]string{ "readme.txt": "text" },
+            nil,
+            errNotDocument,
+        },
+    }
+
+    for _, tt := range tests {
+        text, err := extractDocumentText(writeZip(t, tt.entries), "application/zip", 0)
+        if err != tt.err {
+            t.Errorf("%s: extractDocumentText() error = %v, want %v", tt.name, err, tt.err)
+            continue
+        }
+        for _, w := range tt.want {
+            if !strings.Contains(text, w) {
+                t.Errorf("%s: extractDocumentText() = %q, want to contain %q", tt.name, text, w)
+            }
+        }
+    }
+}
+
+func TestExtractDocumentTextMaxBytes(t *testing.T) {
+    p := writeZip(t, map[string]string{
+        "word/document.xml": `<w:document xmlns:w="w"><w:body><w:p><w:r><w:t>` + strings.Repeat("a", 1000) + `</w:t></w:r></w:p></w:body></w:document>`,
+    })
+    text, err := extractDocumentText(p, "application/zip", 100)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(text) > 1000 || len(text) < 100 {
+        t.Errorf("extractDocumentText() returned %d bytes, want the output truncated near 100", len(text))
+    }
+}
+
+func TestExtractDocumentTextUnsupported(t *testing.T) {
+    if _, err := extractDocumentText("unused", "image/png", 0); err != errNotDocument {
+        t.Errorf("extractDocumentText() error = %v, want %v", err, errNotDocument)
+    }
+}
+
+// The zip entries are limited even without --max-target-megabytes (zip bombs)
+func TestOpenPartLimit(t *testing.T) {
+    zr, err := zip.OpenReader(writeZip(t, map[string]string{ "word/document.xml": "<w:document/>" }))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer zr.Close()
+
+    for _, tt := range []struct {
+        maxBytes int64
+        want     int64
+    }{
+        {0, documentMaxBytes},
+        {100, 1000},
+    } {
+        ex := &documentTextExtractor{ maxBytes: tt.maxBytes }
+        rc, r, err := ex.openPart(zr.File[0])
+        if err != nil {
+            t.Fatal(err)
+        }
+        rc.Close()
+
+        lr, ok := r.(*io.LimitedReader)
+        if !ok || lr.N != tt.want {
+            t.Errorf("openPart() with maxBytes %d = %T, want a limit of %d", tt.maxBytes, r, tt.want)
+        }
+    }
+}
@@ -1,5 +1,9 @@
 package runner
 
+import (
+    "github.com/helviojunior/intelparser/pkg/models"
+)
+
 // Location represents a location in a file
 type Location struct {
     startLine      int
@@ -77,4 +81,13 @@ func location(fragment Fragment, matchIndex []int) Location {
         location.endLineIndex = end + i
     }
     return location
-}
\ No newline at end of file
+}
+// findingsOverlap checks if the locations (line and column) of two findings overlap
+func findingsOverlap(a, b models.Finding) bool {
+    return !positionBefore(a.EndLine, a.EndColumn, b.StartLine, b.StartColumn) &&
+        !positionBefore(b.EndLine, b.EndColumn, a.StartLine, a.StartColumn)
+}
+
+func positionBefore(line1, column1, line2, column2 int) bool {
+    return line1 < line2 || (line1 == line2 && column1 < column2)
+}
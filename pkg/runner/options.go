@@ -64,6 +64,20 @@ type Parser struct {
     NearTextSize int
 
     StoreNearText bool
+
+    // Files bigger than it (in megabytes) are skipped, 0 to disable
+    MaxTargetMegaBytes int
+
+    // Compressed payloads inflating bigger than it (in megabytes) are skipped, 0 to disable inflating
+    MaxInflateMegaBytes int
+
+    // Enable optional rulesets
+    Secrets bool
+    PII bool
+    Crypto bool
+
+    // Keep the PII findings that could not be validated
+    PIIUnvalidated bool
 }
 
 // NewDefaultOptions returns Options with some default values
@@ -74,6 +88,8 @@ func NewDefaultOptions() *Options {
             Threads:          6,
             NearTextSize:     50,
             StoreNearText:    false,
+            MaxTargetMegaBytes: 200,
+            MaxInflateMegaBytes: 10,
         },
         Logging: Logging{
             Debug:         true,
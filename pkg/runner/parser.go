@@ -1,7 +1,9 @@
 package runner
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	//"bufio"
 	//"bytes"
 	//"io"
@@ -12,6 +14,10 @@ import (
 type FileItem struct {
 	RealPath     string
 	VirtualPath  string
+	// Driver is the registered parser name the file is routed to (parse auto)
+	Driver       string
+	// Archive is the ZIP file the file was extracted from, if any
+	Archive      string
 }
 
 // ChromeNotFoundError signals that chrome is not available
@@ -23,6 +29,79 @@ func (e ParserNotFoundError) Error() string {
 	return fmt.Sprintf("parser not found: %v", e.Err)
 }
 
+// Reason codes of the failed and skipped files, stored at the control database
+const (
+	FailureParse       = "parse_error"
+	FailureUnsupported = "unsupported_mime"
+	FailureSizeLimit   = "size_limit"
+	FailureNotIndexed  = "not_indexed"
+	FailurePermission  = "permission"
+	FailureNotFound    = "not_found"
+	FailureNoParser    = "no_parser"
+)
+
+// FailureCodes lists the valid reason codes
+var FailureCodes = []string{
+	FailureParse,
+	FailureUnsupported,
+	FailureSizeLimit,
+	FailureNotIndexed,
+	FailurePermission,
+	FailureNotFound,
+	FailureNoParser,
+}
+
+// FileError signals a file that could not be parsed, with its reason code.
+// Skipped files are counted as ignored instead of failed.
+type FileError struct {
+	Code    string
+	Skipped bool
+	Err     error
+}
+
+func (e FileError) Error() string {
+	return e.Err.Error()
+}
+
+func (e FileError) Unwrap() error {
+	return e.Err
+}
+
+// NewFileError returns a failure error with the reason code
+func NewFileError(code string, err error) error {
+	return FileError{Code: code, Err: err}
+}
+
+// NewSkipError returns an error to skip the file with the reason code
+func NewSkipError(code string, reason string) error {
+	return FileError{Code: code, Skipped: true, Err: errors.New(reason)}
+}
+
+// FailureCode returns the reason code of a ParseFile error
+func FailureCode(err error) string {
+	var fe FileError
+	if errors.As(err, &fe) {
+		return fe.Code
+	}
+	var pe ParserNotFoundError
+	if errors.As(err, &pe) {
+		return FailureNoParser
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		return FailurePermission
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return FailureNotFound
+	}
+	return FailureParse
+}
+
+// IsSkipped returns true if the ParseFile error only skips the file
+func IsSkipped(err error) bool {
+	var fe FileError
+	return errors.As(err, &fe) && fe.Skipped
+}
+
 // Parser is the interface file drivers will implement.
 type ParserDriver interface {
 	ParseFile(runner *Runner, file FileItem) (*models.File, error)
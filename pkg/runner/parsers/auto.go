@@ -0,0 +1,102 @@
+package parsers
+
+import (
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/helviojunior/intelparser/pkg/models"
+	"github.com/helviojunior/intelparser/pkg/runner"
+)
+
+// AutoParser is a driver that routes each file to the registered driver
+// named at the file item, or sniffed from the file content
+type AutoParser struct {
+	// options for the Runner to consider
+	options runner.Options
+	// logger
+	log *slog.Logger
+	// driver instances by name, created on the first use
+	drivers map[string]runner.ParserDriver
+	//
+	driversMutex sync.Mutex
+}
+
+// NewAuto returns a new AutoParser instance
+func NewAuto(logger *slog.Logger, opts runner.Options) (*AutoParser, error) {
+	return &AutoParser{
+		options:      opts,
+		log:          logger,
+		drivers:      map[string]runner.ParserDriver{},
+		driversMutex: sync.Mutex{},
+	}, nil
+}
+
+// Driver returns the instance of the registered driver
+func (run *AutoParser) Driver(name string) (runner.ParserDriver, error) {
+	run.driversMutex.Lock()
+	defer run.driversMutex.Unlock()
+
+	if d, ok := run.drivers[name]; ok {
+		return d, nil
+	}
+
+	reg := Lookup(name)
+	if reg == nil {
+		return nil, runner.ParserNotFoundError{Err: errors.New(name)}
+	}
+
+	d, err := reg.New(run.log, run.options)
+	if err != nil {
+		return nil, err
+	}
+	run.drivers[name] = d
+
+	return d, nil
+}
+
+func (run *AutoParser) ParseFile(thisRunner *runner.Runner, file runner.FileItem) (*models.File, error) {
+	logger := run.log.With("file", filepath.Base(file.RealPath))
+
+	// The runner flags the result of a failed file
+	failed := &models.File{
+		FilePath: file.VirtualPath,
+		FileName: filepath.Base(file.RealPath),
+		Name: file.VirtualPath,
+		Date: time.Now(),
+		IndexedAt: time.Now(),
+	}
+
+	if file.Driver == "" {
+		input, err := NewInput(file.RealPath)
+		if err != nil {
+			return failed, err
+		}
+		reg := Detect(input)
+		if reg == nil {
+			logger.Debug("No parser for the file, ignoring...")
+			return failed, runner.NewSkipError(runner.FailureNoParser, "No parser for the file")
+		}
+		file.Driver = reg.Name
+	}
+
+	d, err := run.Driver(file.Driver)
+	if err != nil {
+		return failed, err
+	}
+
+	logger.Debug("Routing file", "parser", file.Driver)
+	return d.ParseFile(thisRunner, file)
+}
+
+func (run *AutoParser) Close() {
+	run.log.Debug("closing auto parser context")
+
+	run.driversMutex.Lock()
+	defer run.driversMutex.Unlock()
+	for _, d := range run.drivers {
+		d.Close()
+	}
+}
@@ -0,0 +1,663 @@
+package parsers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/glebarez/sqlite"
+	"github.com/helviojunior/intelparser/pkg/database"
+	"github.com/helviojunior/intelparser/pkg/models"
+	"github.com/helviojunior/intelparser/pkg/runner"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// sessionCookieRegexp matches the cookie names used for sessions and authentication (SSO, SAML, JWT...)
+var sessionCookieRegexp = regexp.MustCompile(`(?i)(sess|sid|auth|token|sso|saml|jwt|login|estsauth|fedauth|rtfa|keycloak|okta|shib|^__host-|^__secure-)`)
+
+// chromeEpochOffset is the seconds between 1601-01-01 (Chrome/WebKit epoch) and 1970-01-01
+const chromeEpochOffset = 11644473600
+
+func init() {
+	Register(&Registration{
+		Name: "browser",
+		Short: "Parse browser credential stores (stealer logs and forensic exports)",
+		Long: `
+Parse browser artifacts found in stealer logs and forensic images.
+
+Supported formats:
+- Chrome/Chromium/Edge SQLite: Login Data, Cookies and Web Data (autofill)
+- Firefox: logins.json (decrypted entries), cookies.sqlite and formhistory.sqlite
+- Netscape cookie files (cookies.txt)
+- JSON cookie exports (EditThisCookie and stealer formats)
+
+Saved logins are recorded as credentials with the origin URL and the creation
+time. Cookies are recorded with domain, name and expiration, session and
+authentication cookies (SSO, SAML, JWT...) are flagged with high severity.
+Values still encrypted by the browser are not recovered.
+
+Other text files (e.g. stealer Passwords.txt) are parsed with the regular rules.
+`,
+		Example: `
+   - intelparser parse browser -p ~/Desktop/stealer_logs/
+   - intelparser parse browser -p "~/Desktop/Default/Login Data"
+   - intelparser parse browser -p ~/Desktop/cookies.txt --write-elastic --write-elasticsearch-uri "http://127.0.0.1:9200/intelparser"
+`,
+		PathUsage: "A browser store file or a path with browser profile(s)/stealer log(s).",
+		Extensions: []string{"", ".txt", ".json", ".sqlite", ".sqlite3", ".db"},
+		New: func(logger *slog.Logger, opts runner.Options) (runner.ParserDriver, error) {
+			return NewBrowser(logger, opts)
+		},
+		Sniff: sniffBrowser,
+	})
+}
+
+// BrowserParser is a driver that parses browser credential stores exported
+// by stealers and forensic tools: Chrome/Chromium SQLite databases (Login Data,
+// Cookies, Web Data), Firefox files (logins.json, cookies.sqlite, formhistory.sqlite),
+// Netscape cookie files and JSON cookie exports
+type BrowserParser struct {
+	// options for the Runner to consider
+	options runner.Options
+	// logger
+	log *slog.Logger
+	// Record field mapping
+	mapping *FieldMapping
+	//
+	conn *gorm.DB
+}
+
+// NewBrowser returns a new BrowserParser instance
+func NewBrowser(logger *slog.Logger, opts runner.Options) (*BrowserParser, error) {
+	var conn *gorm.DB
+	var err error
+	conn, err = database.Connection(opts.Writer.GlobalDbURI, true, false)
+	if err != nil {
+		logger.Debug("Error connecting to the database", "conn", opts.Writer.GlobalDbURI, "err", err)
+		conn = nil
+	}
+
+	return &BrowserParser{
+		options: opts,
+		log:     logger,
+		mapping: DefaultFieldMapping(),
+		conn:    conn,
+	}, nil
+}
+
+func (run *BrowserParser) ParseFile(thisRunner *runner.Runner, file runner.FileItem) (*models.File, error) {
+	logger := run.log.With("file", filepath.Base(file.RealPath))
+
+	result, err := newLocalFile(file, "Browser")
+	if err != nil {
+		return result, err
+	}
+
+	if alreadyParsed(run.conn, result.FileName, result.Fingerprint) {
+		logger.Debug("[File already parsed]")
+		return nil, nil
+	}
+
+	f, err := os.Open(file.RealPath)
+	if err != nil {
+		return result, err
+	}
+	br := bufio.NewReaderSize(f, 64 * 1024)
+	head, _ := br.Peek(64 * 1024)
+	f.Close()
+
+	parsed := false
+	switch {
+	case bytes.HasPrefix(head, []byte("SQLite format 3\x00")):
+		parsed, err = run.parseSqlite(thisRunner, result, logger)
+	case len(bytes.TrimLeft(head, " \r\n\t\xef\xbb\xbf")) > 0 && strings.ContainsRune("[{", rune(bytes.TrimLeft(head, " \r\n\t\xef\xbb\xbf")[0])):
+		parsed, err = run.parseJson(thisRunner, result, logger)
+	case isNetscapeCookies(head):
+		parsed, err = run.parseNetscape(thisRunner, result, logger)
+	}
+
+	if err != nil {
+		return result, err
+	}
+
+	if !parsed {
+		// Not a browser store (e.g. stealer Passwords.txt), use the regular rules
+		logger.Debug("Browser store not identified, using text rules")
+		err = thisRunner.DetectFile(result)
+	}
+
+	result.FilePath = file.VirtualPath
+	return result, err
+}
+
+func (run *BrowserParser) Close() {
+	run.log.Debug("closing Browser parser context")
+}
+
+// parseSqlite reads the known tables of Chrome and Firefox databases
+func (run *BrowserParser) parseSqlite(thisRunner *runner.Runner, result *models.File, logger *slog.Logger) (bool, error) {
+	conn, err := gorm.Open(sqlite.Open("file:" + result.FilePath + "?mode=ro"), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		return false, err
+	}
+	if db, err := conn.DB(); err == nil {
+		defer db.Close()
+	}
+
+	var tables []string
+	if err := conn.Raw("SELECT name FROM sqlite_master WHERE type = 'table'").Scan(&tables).Error; err != nil {
+		return false, err
+	}
+
+	parsed := false
+	for _, table := range tables {
+		var handler func(row map[string]interface{})
+
+		switch strings.ToLower(table) {
+		case "logins":
+			// Chrome Login Data
+			handler = func(row map[string]interface{}) {
+				run.addLogin(thisRunner, result, "Chrome", []RecordField{
+					{ Name: "origin_url", Value: rowString(row, "origin_url") },
+					{ Name: "action_url", Value: rowString(row, "action_url") },
+					{ Name: "username", Value: rowString(row, "username_value") },
+					{ Name: "password", Value: rowSecret(row, "password_value") },
+					{ Name: "last_used", Value: formatTime(chromeTime(rowInt(row, "date_last_used"))) },
+				}, chromeTime(rowInt(row, "date_created")))
+			}
+
+		case "cookies":
+			// Chrome Cookies
+			handler = func(row map[string]interface{}) {
+				// Session cookies have no expiration (zero)
+				expires := chromeTime(rowInt(row, "expires_utc"))
+				run.addCookie(thisRunner, result, models.Cookie{
+					Browser     : "chrome",
+					Domain      : rowString(row, "host_key"),
+					Path        : rowString(row, "path"),
+					Name        : rowString(row, "name"),
+					Value       : firstNonEmpty(rowString(row, "value"), rowSecret(row, "encrypted_value")),
+					Expires     : expires,
+					Secure      : rowInt(row, "is_secure") == 1 || rowInt(row, "secure") == 1,
+					HttpOnly    : rowInt(row, "is_httponly") == 1 || rowInt(row, "httponly") == 1,
+				}, chromeTime(rowInt(row, "creation_utc")))
+			}
+
+		case "moz_cookies":
+			// Firefox cookies.sqlite
+			handler = func(row map[string]interface{}) {
+				run.addCookie(thisRunner, result, models.Cookie{
+					Browser     : "firefox",
+					Domain      : rowString(row, "host"),
+					Path        : rowString(row, "path"),
+					Name        : rowString(row, "name"),
+					Value       : rowString(row, "value"),
+					Expires     : epochTime(rowInt(row, "expiry")),
+					Secure      : rowInt(row, "issecure") == 1,
+					HttpOnly    : rowInt(row, "ishttponly") == 1,
+				}, epochTime(rowInt(row, "creationtime")))
+			}
+
+		case "autofill":
+			// Chrome Web Data
+			handler = func(row map[string]interface{}) {
+				run.addAutofill(thisRunner, result, rowString(row, "name"), rowString(row, "value"), epochTime(rowInt(row, "date_created")))
+			}
+
+		case "moz_formhistory":
+			// Firefox formhistory.sqlite
+			handler = func(row map[string]interface{}) {
+				run.addAutofill(thisRunner, result, rowString(row, "fieldname"), rowString(row, "value"), epochTime(rowInt(row, "firstused")))
+			}
+		}
+
+		if handler == nil {
+			continue
+		}
+
+		logger.Debug("Reading browser table", "table", table)
+		if err := eachRow(conn, table, handler); err != nil {
+			logger.Debug("Error reading browser table", "table", table, "err", err)
+			continue
+		}
+		parsed = true
+	}
+
+	return parsed, nil
+}
+
+// parseJson reads Firefox logins.json and JSON cookie exports (EditThisCookie and stealer formats)
+func (run *BrowserParser) parseJson(thisRunner *runner.Runner, result *models.File, logger *slog.Logger) (bool, error) {
+	data, err := os.ReadFile(result.FilePath)
+	if err != nil {
+		return false, err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), &doc); err != nil {
+		return false, nil
+	}
+
+	var items []interface{}
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		if logins, ok := v["logins"].([]interface{}); ok {
+			// Firefox logins.json, only the entries decrypted by the stealer have the username/password
+			encrypted := 0
+			for _, l := range logins {
+				login, ok := l.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if jsonString(login, "username") == "" && jsonString(login, "password") == "" {
+					encrypted++
+					continue
+				}
+				run.addLogin(thisRunner, result, "Firefox", []RecordField{
+					{ Name: "origin_url", Value: jsonString(login, "hostname") },
+					{ Name: "action_url", Value: jsonString(login, "formSubmitURL") },
+					{ Name: "username", Value: jsonString(login, "username") },
+					{ Name: "password", Value: jsonString(login, "password") },
+					{ Name: "last_used", Value: formatTime(epochTime(jsonInt(login, "timeLastUsed"))) },
+				}, epochTime(jsonInt(login, "timeCreated")))
+			}
+			logger.Debug("Firefox logins parsed", "logins", len(logins), "encrypted", encrypted)
+			return true, nil
+		}
+		if cookies, ok := v["cookies"].([]interface{}); ok {
+			items = cookies
+		}
+	case []interface{}:
+		items = v
+	}
+
+	cookies := 0
+	for _, c := range items {
+		cookie, ok := c.(map[string]interface{})
+		if !ok || jsonString(cookie, "name") == "" || (jsonString(cookie, "domain") == "" && jsonString(cookie, "host") == "") {
+			continue
+		}
+
+		expires := time.Time{}
+		if session, ok := cookie["session"].(bool); !ok || !session {
+			expires = epochTime(jsonInt(cookie, "expirationDate", "expires", "expiry"))
+		}
+		run.addCookie(thisRunner, result, models.Cookie{
+			Browser     : "json",
+			Domain      : firstNonEmpty(jsonString(cookie, "domain"), jsonString(cookie, "host")),
+			Path        : jsonString(cookie, "path"),
+			Name        : jsonString(cookie, "name"),
+			Value       : jsonString(cookie, "value"),
+			Expires     : expires,
+			Secure      : jsonBool(cookie, "secure"),
+			HttpOnly    : jsonBool(cookie, "httpOnly"),
+		}, result.Date)
+		cookies++
+	}
+
+	return cookies > 0, nil
+}
+
+// parseNetscape reads the cookies.txt format (domain, subdomains, path, secure, expiry, name, value)
+func (run *BrowserParser) parseNetscape(thisRunner *runner.Runner, result *models.File, logger *slog.Logger) (bool, error) {
+	f, err := os.Open(result.FilePath)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	cookies := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024 * 1024), 16 * 1024 * 1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := false
+		if strings.HasPrefix(line, "#HttpOnly_") {
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+			httpOnly = true
+		}
+
+		fields, ok := netscapeFields(line)
+		if !ok {
+			continue
+		}
+
+		expiry, _ := strconv.ParseInt(strings.Trim(fields[4], " "), 10, 64)
+		nearText := ""
+		if run.options.Parser.StoreNearText {
+			nearText = line
+		}
+		run.addCookie(thisRunner, result, models.Cookie{
+			Browser     : "netscape",
+			Domain      : fields[0],
+			Path        : fields[2],
+			Name        : fields[5],
+			Value       : fields[6],
+			Expires     : epochTime(expiry),
+			Secure      : strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly    : httpOnly,
+			NearText    : nearText,
+		}, result.Date)
+		cookies++
+	}
+
+	logger.Debug("Netscape cookies parsed", "cookies", cookies)
+	return cookies > 0, scanner.Err()
+}
+
+// addLogin records a saved login, the credential time is the login creation time
+func (run *BrowserParser) addLogin(thisRunner *runner.Runner, result *models.File, browser string, record []RecordField, created time.Time) {
+	record = append(record, RecordField{ Name: "browser", Value: browser })
+
+	finding, _, ok := run.mapping.Finding("Browser » " + browser + " Login", record)
+	if !ok {
+		return
+	}
+
+	if run.options.Parser.StoreNearText {
+		nearText := make([]string, 0, len(record))
+		for _, f := range record {
+			if f.Value != "" && f.Name != "password" {
+				nearText = append(nearText, f.Name + ": " + f.Value)
+			}
+		}
+		finding.Credential.NearText = strings.Join(nearText, "\n")
+		finding.Email.NearText = finding.Credential.NearText
+	}
+
+	if created.IsZero() {
+		created = result.Date
+	}
+	thisRunner.AddFinding(result, finding, created)
+}
+
+// addCookie records a cookie. Session and authentication cookies are high severity
+func (run *BrowserParser) addCookie(thisRunner *runner.Runner, result *models.File, cookie models.Cookie, created time.Time) {
+	if cookie.Name == "" {
+		return
+	}
+
+	cookie.Session = sessionCookieRegexp.MatchString(cookie.Name)
+	cookie.Severity = 30
+	if cookie.Session {
+		cookie.Severity = 100
+	}
+
+	// The near text repeats the cookie value, it is stored only with --store-neartext
+	if !run.options.Parser.StoreNearText {
+		cookie.NearText = ""
+	} else if cookie.NearText == "" {
+		expires := "0"
+		if !cookie.Expires.IsZero() {
+			expires = strconv.FormatInt(cookie.Expires.Unix(), 10)
+		}
+		cookie.NearText = strings.Join([]string{
+			cookie.Domain, "TRUE", cookie.Path, strings.ToUpper(strconv.FormatBool(cookie.Secure)), expires, cookie.Name, cookie.Value,
+		}, "\t")
+	}
+
+	if created.IsZero() {
+		created = result.Date
+	}
+	thisRunner.AddFinding(result, models.Finding{
+		RuleID: "Browser » Cookie",
+		CookieData: cookie,
+	}, created)
+}
+
+// addAutofill runs the detection rules on a form field value (e-mails, PII, secrets...)
+func (run *BrowserParser) addAutofill(thisRunner *runner.Runner, result *models.File, name string, value string, created time.Time) {
+	if strings.Trim(value, " \r\n\t") == "" {
+		return
+	}
+	if created.IsZero() {
+		created = result.Date
+	}
+
+	for _, finding := range thisRunner.DetectString(name + ": " + value) {
+		thisRunner.AddFinding(result, finding, created)
+	}
+}
+
+// isNetscapeCookies checks if most of the sample lines are Netscape cookie lines
+func isNetscapeCookies(sample []byte) bool {
+	lines := strings.Split(string(sample), "\n")
+	if len(lines) > 1 {
+		// The last line may be truncated
+		lines = lines[:len(lines) - 1]
+	}
+
+	var cookies, other int
+	for _, l := range lines {
+		l = strings.TrimRight(l, "\r")
+		if strings.HasPrefix(l, "#HttpOnly_") {
+			l = strings.TrimPrefix(l, "#HttpOnly_")
+		} else if strings.HasPrefix(l, "#") || strings.Trim(l, " \t") == "" {
+			continue
+		}
+		if _, ok := netscapeFields(l); ok {
+			cookies++
+		} else {
+			other++
+		}
+	}
+
+	return cookies > 0 && cookies >= other
+}
+
+func netscapeFields(line string) ([]string, bool) {
+	fields := strings.Split(line, "\t")
+	if len(fields) < 7 || fields[0] == "" || fields[5] == "" {
+		return nil, false
+	}
+	for _, i := range []int{1, 3} {
+		if !strings.EqualFold(fields[i], "TRUE") && !strings.EqualFold(fields[i], "FALSE") {
+			return nil, false
+		}
+	}
+	if len(fields) > 7 {
+		// Tabs inside the value
+		fields[6] = strings.Join(fields[6:], "\t")
+	}
+	return fields[:7], true
+}
+
+// eachRow calls the handler with each table row, keyed by the lower case column names
+func eachRow(conn *gorm.DB, table string, handler func(row map[string]interface{})) error {
+	rows, err := conn.Raw(fmt.Sprintf("SELECT * FROM \"%s\"", strings.ReplaceAll(table, "\"", "\"\""))).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, c := range columns {
+			row[strings.ToLower(c)] = values[i]
+		}
+		handler(row)
+	}
+
+	return rows.Err()
+}
+
+func rowString(row map[string]interface{}, key string) string {
+	switch v := row[key].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// rowSecret returns a password/cookie value stored as a BLOB. Values still
+// encrypted (DPAPI, v10/v11/v20 AES-GCM) are discarded
+func rowSecret(row map[string]interface{}, key string) string {
+	value := rowString(row, key)
+	if value == "" {
+		return ""
+	}
+	if strings.HasPrefix(value, "v10") || strings.HasPrefix(value, "v11") || strings.HasPrefix(value, "v20") ||
+		strings.HasPrefix(value, "\x01\x00\x00\x00\xd0\x8c\x9d\xdf") || !isPrintableText(value) {
+		return ""
+	}
+	return value
+}
+
+func rowInt(row map[string]interface{}, key string) int64 {
+	switch v := row[key].(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	case string:
+		i, _ := strconv.ParseInt(v, 10, 64)
+		return i
+	case []byte:
+		i, _ := strconv.ParseInt(string(v), 10, 64)
+		return i
+	}
+	return 0
+}
+
+func jsonString(obj map[string]interface{}, key string) string {
+	switch v := obj[key].(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func jsonInt(obj map[string]interface{}, keys ...string) int64 {
+	for _, key := range keys {
+		switch v := obj[key].(type) {
+		case float64:
+			return int64(v)
+		case string:
+			if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+func jsonBool(obj map[string]interface{}, key string) bool {
+	switch v := obj[key].(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	case float64:
+		return v != 0
+	}
+	return false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// chromeTime converts the microseconds since 1601-01-01 used by Chrome
+func chromeTime(v int64) time.Time {
+	if v <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(v / 1000000 - chromeEpochOffset, (v % 1000000) * 1000).UTC()
+}
+
+// epochTime converts an Unix epoch in seconds, milliseconds or microseconds
+func epochTime(v int64) time.Time {
+	switch {
+	case v <= 0:
+		return time.Time{}
+	case v > 100000000000000:
+		return time.UnixMicro(v).UTC()
+	case v > 100000000000:
+		return time.UnixMilli(v).UTC()
+	default:
+		return time.Unix(v, 0).UTC()
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func isPrintableText(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if unicode.IsControl(r) && r != '\t' && r != '\r' && r != '\n' {
+			return false
+		}
+	}
+	return true
+}
+
+// sniffBrowser handles the SQLite stores, logins.json and cookie exports. The
+// folders are not claimed, so the other files of browser profiles/stealer logs
+// (e.g. dumps and archives) are sniffed on their own
+func sniffBrowser(input Input) int {
+	if input.IsDir {
+		return 0
+	}
+
+	switch {
+	case input.Name == "logins.json":
+		return 90
+	case bytes.HasPrefix(input.Head, []byte("SQLite format 3\x00")):
+		return 80
+	case isNetscapeCookies(input.Head):
+		return 80
+	case strings.Contains(input.Name, "cookie") && (input.Ext == ".json" || input.Ext == ".txt"):
+		return 60
+	}
+
+	return 0
+}
@@ -0,0 +1,58 @@
+package parsers
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/helviojunior/intelparser/pkg/models"
+)
+
+// The cookie near text repeats the value, it is stored only with --store-neartext
+func TestBrowserCookiesNearText(t *testing.T) {
+	netscape := "# Netscape HTTP Cookie File\n" +
+		".corp.org\tTRUE\t/\tTRUE\t1735689600\tsessionid\tabc123session\n" +
+		"#HttpOnly_.corp.org\tTRUE\t/\tFALSE\t0\tlang\tpt-BR\n"
+	json := `[{"domain": ".corp.org", "path": "/", "name": "sessionid", "value": "abc123session", "secure": true}]`
+
+	tests := []struct {
+		name          string
+		file          string
+		data          string
+		storeNearText bool
+	}{
+		{"netscape", "cookies.txt", netscape, false},
+		{"netscape with near text", "cookies.txt", netscape, true},
+		{"json", "cookies.json", json, false},
+		{"json with near text", "cookies.json", json, true},
+	}
+
+	for _, tt := range tests {
+		opts := testOptions(t)
+		opts.Parser.StoreNearText = tt.storeNearText
+		driver, err := NewBrowser(slog.New(slog.NewTextHandler(io.Discard, nil)), *opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		results, result := parseTestFile(t, driver, opts, tt.file, tt.data)
+		cookies := []models.Cookie{}
+		for _, r := range append(results, result) {
+			cookies = append(cookies, r.Cookies...)
+		}
+		if len(cookies) == 0 {
+			t.Errorf("%s: no cookies found", tt.name)
+			continue
+		}
+
+		for _, c := range cookies {
+			if tt.storeNearText && !strings.Contains(c.NearText, c.Value) {
+				t.Errorf("%s: %s near text = %q, want to contain the value", tt.name, c.Name, c.NearText)
+			}
+			if !tt.storeNearText && c.NearText != "" {
+				t.Errorf("%s: %s near text = %q, want empty", tt.name, c.Name, c.NearText)
+			}
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package parsers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log/slog"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/helviojunior/intelparser/internal/tools"
+	"github.com/helviojunior/intelparser/pkg/database"
+	"github.com/helviojunior/intelparser/pkg/models"
+	"github.com/helviojunior/intelparser/pkg/runner"
+	"github.com/spf13/pflag"
+	"gorm.io/gorm"
+)
+
+// csvDelimiters are the delimiters checked when sniffing the file
+var csvDelimiters = []rune{',', ';', '\t', '|'}
+
+// csvSampleRows is the number of rows used to sniff the delimiter and infer column roles
+const csvSampleRows = 50
+
+// csvExtensions are the CSV/TSV dump file extensions
+var csvExtensions = []string{".csv", ".tsv"}
+
+// csvDelimiterFlag is the --delimiter flag
+var csvDelimiterFlag string
+
+func init() {
+	Register(&Registration{
+		Name: "csv",
+		Short: "Parse CSV/TSV database dumps",
+		Long: `
+Parse CSV/TSV database dumps mapping each row to credentials and e-mails.
+
+The delimiter (comma, semicolon, tab or pipe) is sniffed from the file content.
+Column roles are inferred from the header names (user, login, usuario, email,
+password, senha, password_hash, url, created_at...) or, for files without
+header, from the value shapes (e-mails, URLs and dates). The original row is
+stored as near text (with --store-neartext) and the created_at like column is
+used as credential time.
+`,
+		Example: `
+   - intelparser parse csv -p ~/Desktop/users.csv
+   - intelparser parse csv -p ~/Desktop/dumps/ --mapping ~/Desktop/mapping.json
+   - intelparser parse csv -p ~/Desktop/users.txt --delimiter tab
+`,
+		PathUsage: "A CSV/TSV file or a path with CSV/TSV file(s).",
+		Extensions: csvExtensions,
+		Flags: func(flags *pflag.FlagSet) {
+			mappingFlags(flags)
+			flags.StringVar(&csvDelimiterFlag, "delimiter", "", "CSV column delimiter (default: sniffed from the file content)")
+		},
+		New: func(logger *slog.Logger, opts runner.Options) (runner.ParserDriver, error) {
+			mapping, err := flagFieldMapping()
+			if err != nil {
+				return nil, err
+			}
+			delimiter, err := ParseDelimiter(csvDelimiterFlag)
+			if err != nil {
+				return nil, err
+			}
+			return NewCsv(logger, opts, mapping, delimiter)
+		},
+		Sniff: sniffCsv,
+	})
+}
+
+// CsvParser is a driver that parses CSV/TSV database dumps mapping
+// the row columns to credentials and e-mails
+type CsvParser struct {
+	// options for the Runner to consider
+	options runner.Options
+	// logger
+	log *slog.Logger
+	// Record field mapping
+	mapping *FieldMapping
+	// Forced delimiter, 0 to sniff
+	delimiter rune
+	//
+	conn *gorm.DB
+}
+
+// NewCsv returns a new CsvParser instance. A nil mapping uses the auto detection one
+// and a zero delimiter sniffs it from each file.
+func NewCsv(logger *slog.Logger, opts runner.Options, mapping *FieldMapping, delimiter rune) (*CsvParser, error) {
+	var conn *gorm.DB
+	var err error
+	conn, err = database.Connection(opts.Writer.GlobalDbURI, true, false)
+	if err != nil {
+		logger.Debug("Error connecting to the database", "conn", opts.Writer.GlobalDbURI, "err", err)
+		conn = nil
+	}
+
+	if mapping == nil {
+		mapping = DefaultFieldMapping()
+	}
+
+	return &CsvParser{
+		options: opts,
+		log:     logger,
+		mapping: mapping,
+		delimiter: delimiter,
+		conn:    conn,
+	}, nil
+}
+
+func (run *CsvParser) ParseFile(thisRunner *runner.Runner, file runner.FileItem) (*models.File, error) {
+	logger := run.log.With("file", filepath.Base(file.RealPath))
+
+	result, err := newLocalFile(file, "CSV")
+	if err != nil {
+		return result, err
+	}
+
+	if alreadyParsed(run.conn, result.FileName, result.Fingerprint) {
+		logger.Debug("[File already parsed]")
+		return nil, nil
+	}
+
+	f, err := os.Open(file.RealPath)
+	if err != nil {
+		return result, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReaderSize(f, 1024 * 1024)
+	if b, err := br.Peek(3); err == nil && string(b) == "\xef\xbb\xbf" {
+		br.Discard(3)
+	}
+
+	delimiter := run.delimiter
+	if delimiter == 0 {
+		sample, _ := br.Peek(256 * 1024)
+		delimiter = sniffDelimiter(sample)
+	}
+	logger.Debug("CSV delimiter", "delimiter", string(delimiter))
+
+	reader := csv.NewReader(br)
+	reader.Comma = delimiter
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+
+	// Read the sample rows to check the header or infer the columns by the value shapes
+	sample := [][]string{}
+	for len(sample) < csvSampleRows {
+		row, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			if _, ok := err.(*csv.ParseError); ok {
+				continue
+			}
+			return result, err
+		}
+		sample = append(sample, row)
+	}
+
+	if len(sample) == 0 {
+		return nil, nil
+	}
+
+	header := sample[0]
+	if run.mapping.HasRoles(header) {
+		sample = sample[1:]
+	} else {
+		header = inferHeader(run.mapping, sample)
+		if !run.mapping.HasRoles(header) {
+			// Not a credentials table, use the regular rules
+			logger.Debug("Column roles not found, using text rules")
+			err = thisRunner.DetectFile(result)
+			result.FilePath = file.VirtualPath
+			return result, err
+		}
+	}
+	logger.Debug("CSV columns", "header", header)
+
+	// The records are written every recordFlushRows
+	chunks := &recordChunks{
+		runner: thisRunner,
+		log: logger,
+		newChunk: func(_ string, n int) *models.File {
+			return recordResult(result, file.VirtualPath, n)
+		},
+	}
+	defer chunks.flush()
+
+	records := 0
+	handle := func(row []string) {
+		record := make([]RecordField, 0, len(row))
+		for i, v := range row {
+			name := "col" + strconv.Itoa(i + 1)
+			if i < len(header) && strings.Trim(header[i], " \r\n\t") != "" {
+				name = header[i]
+			}
+			record = append(record, RecordField{ Name: name, Value: v })
+		}
+
+		finding, t, ok := run.mapping.Finding("CSV » Record", record)
+		if !ok {
+			return
+		}
+
+		// The original row is the context of the finding
+		if run.options.Parser.StoreNearText {
+			line := strings.Join(row, string(delimiter))
+			finding.Credential.NearText = line
+			finding.Email.NearText = line
+		}
+
+		if t.IsZero() {
+			t = result.Date
+		}
+		records++
+		chunks.add("", finding, t)
+	}
+
+	for _, row := range sample {
+		handle(row)
+	}
+
+	for {
+		row, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			if _, ok := err.(*csv.ParseError); ok {
+				logger.Debug("Invalid CSV row", "err", err)
+				continue
+			}
+			return result, err
+		}
+		handle(row)
+	}
+
+	logger.Debug("CSV records parsed", "records", records, "results", chunks.chunks)
+	result.FilePath = file.VirtualPath
+
+	return result, nil
+}
+
+func (run *CsvParser) Close() {
+	run.log.Debug("closing CSV parser context")
+}
+
+// inferHeader builds a header from the value shapes of the sample rows.
+// E-mail, URL and date columns are detected by the values and the first
+// unknown column after the e-mail one is handled as the password.
+func inferHeader(fm *FieldMapping, sample [][]string) []string {
+	columns := 0
+	for _, row := range sample {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+
+	header := make([]string, columns)
+	email := -1
+	for c := 0; c < columns; c++ {
+		var emails, urls, dates, numbers, total int
+		for _, row := range sample {
+			if c >= len(row) {
+				continue
+			}
+			v := strings.Trim(row[c], " \r\n\t")
+			if v == "" {
+				continue
+			}
+			total++
+
+			if _, err := strconv.ParseFloat(v, 64); err == nil {
+				numbers++
+			}
+			if strings.Contains(v, "@") {
+				if _, err := mail.ParseAddress(v); err == nil {
+					emails++
+				}
+			}
+			if strings.Contains(v, "://") {
+				urls++
+			}
+			if _, ok := tools.ParseTime(v); ok {
+				dates++
+			}
+		}
+
+		header[c] = "col" + strconv.Itoa(c + 1)
+		if total == 0 {
+			continue
+		}
+
+		switch {
+		case emails * 2 > total && email == -1:
+			header[c] = firstOrDefault(fm.Email, "email")
+			email = c
+		case urls * 2 > total:
+			header[c] = firstOrDefault(fm.Url, "url")
+		case dates * 2 > total && numbers * 2 <= total:
+			header[c] = firstOrDefault(fm.Time, "created_at")
+		case email != -1 && c == email + 1 && numbers * 2 <= total:
+			header[c] = firstOrDefault(fm.Password, "password")
+		}
+	}
+
+	return header
+}
+
+// sniffDelimiter returns the delimiter with the most constant non zero field count
+// across the sample lines
+func sniffDelimiter(sample []byte) rune {
+	lines := strings.Split(strings.ReplaceAll(string(sample), "\r\n", "\n"), "\n")
+
+	// The last line may be truncated
+	if len(lines) > 1 {
+		lines = lines[:len(lines) - 1]
+	}
+	if len(lines) > csvSampleRows {
+		lines = lines[:csvSampleRows]
+	}
+
+	best := ','
+	bestScore := 0
+	for _, d := range csvDelimiters {
+		counts := map[int]int{}
+		for _, l := range lines {
+			if strings.Trim(l, " \t") == "" {
+				continue
+			}
+			counts[strings.Count(l, string(d))]++
+		}
+
+		// Score is how many lines have the most common (non zero) count
+		for n, lc := range counts {
+			if n > 0 && lc > bestScore {
+				best = d
+				bestScore = lc
+			}
+		}
+	}
+
+	return best
+}
+
+func firstOrDefault(values []string, def string) string {
+	if len(values) > 0 {
+		return values[0]
+	}
+	return def
+}
+
+// ParseDelimiter converts the delimiter command line option (e.g. ",", "tab", "\t") to a rune
+func ParseDelimiter(s string) (rune, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return 0, nil
+	case "tab", "\\t", "\t":
+		return '\t', nil
+	}
+
+	r := []rune(s)
+	if len(r) != 1 || r[0] == '"' || r[0] == '\r' || r[0] == '\n' {
+		return 0, errors.New("invalid delimiter " + s)
+	}
+	return r[0], nil
+}
+
+// sniffCsv handles the files with CSV extension, or delimited text whose
+// header has credential column names
+func sniffCsv(input Input) int {
+	if input.IsDir {
+		return 0
+	}
+
+	if tools.SliceHasStr(csvExtensions, input.Ext) {
+		return 50
+	}
+
+	line, _, _ := strings.Cut(strings.TrimPrefix(string(input.Head), "\xef\xbb\xbf"), "\n")
+	delimiter := sniffDelimiter(input.Head)
+	if strings.ContainsRune(line, delimiter) && DefaultFieldMapping().HasRoles(strings.Split(strings.TrimRight(line, "\r"), string(delimiter))) {
+		return 55
+	}
+
+	return 0
+}
@@ -0,0 +1,150 @@
+package parsers
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSniffDelimiter(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample string
+		want   rune
+	}{
+		{"comma", "email,password,created_at\na@b.com,123,2024-01-01\nc@d.com,456,2024-01-02\n", ','},
+		{"semicolon with commas in values", "email;name;password\na@b.com;Silva, Joao;123\nc@d.com;Souza, Ana;456\n", ';'},
+		{"tab", "user\tpass\nadmin\t123\nroot\ttoor\n", '\t'},
+		{"pipe", "id|email|senha\n1|a@b.com|123\n2|c@d.com|456\n", '|'},
+		{"crlf", "email;senha\r\na@b.com;123\r\nc@d.com;456\r\n", ';'},
+		{"truncated last line", "a|b|c\n1|2|3\n4|5|6\n7,8", '|'},
+		{"no delimiter", "just some text\nwithout columns\n", ','},
+	}
+
+	for _, tt := range tests {
+		if got := sniffDelimiter([]byte(tt.sample)); got != tt.want {
+			t.Errorf("%s: sniffDelimiter() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseDelimiter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  rune
+		err   bool
+	}{
+		{"", 0, false},
+		{",", ',', false},
+		{";", ';', false},
+		{"tab", '\t', false},
+		{"TAB", '\t', false},
+		{"\\t", '\t', false},
+		{"|", '|', false},
+		{"\"", 0, true},
+		{"ab", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDelimiter(tt.value)
+		if got != tt.want || (err != nil) != tt.err {
+			t.Errorf("ParseDelimiter(%q) = %q, %v, want %q, error %v", tt.value, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestInferHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample [][]string
+		want   []string
+	}{
+		{
+			"email, password and date",
+			[][]string{
+				{"1", "a@b.com", "s3cret", "2024-01-01 10:00:00"},
+				{"2", "c@d.com", "hunter2", "2024-01-02 11:00:00"},
+			},
+			[]string{"col1", "email", "password", "created_at"},
+		},
+		{
+			"url, email and password",
+			[][]string{
+				{"https://site.com/login", "a@b.com", "s3cret"},
+				{"https://other.com/", "c@d.com", "hunter2"},
+			},
+			[]string{"url", "email", "password"},
+		},
+		{
+			"numeric column after the email is not a password",
+			[][]string{
+				{"a@b.com", "10", "x"},
+				{"c@d.com", "20", "y"},
+			},
+			[]string{"email", "col2", "col3"},
+		},
+		{
+			"no e-mail column",
+			[][]string{
+				{"admin", "123"},
+				{"root", "toor"},
+			},
+			[]string{"col1", "col2"},
+		},
+	}
+
+	for _, tt := range tests {
+		got := inferHeader(DefaultFieldMapping(), tt.sample)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: inferHeader() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSniffCsv(t *testing.T) {
+	tests := []struct {
+		name  string
+		input Input
+		want  int
+	}{
+		{"csv extension", Input{Name: "users.csv", Ext: ".csv"}, 50},
+		{"tsv extension", Input{Name: "users.tsv", Ext: ".tsv"}, 50},
+		{"credentials header", Input{Name: "users.txt", Ext: ".txt", Head: []byte("\xef\xbb\xbfusuario;senha\r\nadmin;123\r\n")}, 55},
+		{"header without roles", Input{Name: "data.txt", Ext: ".txt", Head: []byte("id;name\n1;joao\n")}, 0},
+		{"plain text", Input{Name: "notes.txt", Ext: ".txt", Head: []byte("hello world\n")}, 0},
+		{"folder", Input{Name: "dump", IsDir: true}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := sniffCsv(tt.input); got != tt.want {
+			t.Errorf("%s: sniffCsv() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// Big tables are written in several results
+func TestCsvChunks(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("email;senha\n")
+	for i := 0; i < recordFlushRows * 2 + 1; i++ {
+		fmt.Fprintf(&sb, "user%d@example.com;Secret%d\n", i, i)
+	}
+
+	opts := testOptions(t)
+	driver, err := NewCsv(slog.New(slog.NewTextHandler(io.Discard, nil)), *opts, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, _ := parseTestFile(t, driver, opts, "dump.csv", sb.String())
+	if len(results) != 3 {
+		t.Fatalf("%d results written, want 3", len(results))
+	}
+	for i, want := range []int{recordFlushRows, recordFlushRows, 1} {
+		if n := len(results[i].Credentials); n != want {
+			t.Errorf("result %d with %d credentials, want %d", i, n, want)
+		}
+	}
+}
@@ -1,4 +1,4 @@
-package driver
+package parsers
 
 import (
 	//"bytes"
@@ -25,6 +25,7 @@ import (
 	"github.com/helviojunior/intelparser/pkg/models"
 	"github.com/helviojunior/intelparser/pkg/runner"
 	"github.com/helviojunior/intelparser/pkg/database"
+	"github.com/helviojunior/intelparser/pkg/ixapi"
 	"gorm.io/gorm"
 )
 
@@ -48,6 +49,38 @@ type InfoData struct {
 	Type string
 	Size uint 
 	SystemID string
+	StorageID string
+	XScore int
+	Language string
+	Relations string
+	SearchTerm string
+}
+
+func init() {
+	Register(&Registration{
+		Name: "intelx",
+		Short: "Parse IntelX downloaded files",
+		Long: `
+Parse IntelX downloaded files (ZIP or folder).
+`,
+		Example: `
+   - intelparser parse intelx -p "~/Desktop/Search 2025-02-05 10_48_28.zip"
+   - intelparser parse intelx -p "~/Desktop/"
+   - intelparser parse intelx -p ~/Desktop/ --write-elastic --write-elasticsearch-uri "http://127.0.0.1:9200/intelparser"
+`,
+		PathUsage: "A Path with IntelX file(s).",
+		Extensions: []string{".zip"},
+		Index: "Info.csv",
+		New: func(logger *slog.Logger, opts runner.Options) (runner.ParserDriver, error) {
+			return NewInteX(logger, opts)
+		},
+		Sniff: func(input Input) int {
+			if (input.IsDir && input.HasEntry("info.csv")) || input.Name == "info.csv" {
+				return 100
+			}
+			return 0
+		},
+	})
 }
 
 // Chromedp is a driver that probes web targets using chromedp
@@ -112,7 +145,7 @@ func (run *IntelxParser) ParseFile(thisRunner *runner.Runner, file runner.FileIt
 	result.MIMEType, _ = tools.GetMimeType(file.RealPath)
 
 	if run.conn != nil {
-		response := run.conn.Raw("SELECT count(id) as count from files WHERE failed = 0 AND file_name = ? AND fingerprint = ?", file_name_ext, result.Fingerprint)
+		response := run.conn.Raw("SELECT count(id) as count from files WHERE failed = ? AND file_name = ? AND fingerprint = ?", false, file_name_ext, result.Fingerprint)
 	    if response != nil {
 	        var cnt int
 	        _ = response.Row().Scan(&cnt)
@@ -123,20 +156,25 @@ func (run *IntelxParser) ParseFile(thisRunner *runner.Runner, file runner.FileIt
 	    }
 	}
 
+	run.infoMutex.Lock()
 	idx := slices.IndexFunc(run.info, func(i InfoData) bool { return i.SystemID == file_name })
+	var info InfoData
+	if idx >= 0 {
+		info = run.info[idx]
+	}
+	run.infoMutex.Unlock()
 	logger.Debug("Get info", "info_idx", idx)
 
 	if idx == -1 {
 		if !tools.SliceHasStr([]string{".DS_Store"}, file_name_ext) {
 			logger.Warn("File is not present at info.csv, ignoring...")
-		}else{
-			logger.Debug("File is not present at info.csv, ignoring...")
+			return result, runner.NewSkipError(runner.FailureNotIndexed, "File is not present at info.csv")
 		}
+		logger.Debug("File is not present at info.csv, ignoring...")
 	    return nil, nil
 	}
 
 	if idx >= 0 {
-		info := run.info[idx]
 		result.Name = info.Name
 		result.Date = info.Date
 		result.Bucket = "IntelX » " + info.Bucket
@@ -144,6 +182,11 @@ func (run *IntelxParser) ParseFile(thisRunner *runner.Runner, file runner.FileIt
 		result.Size = info.Size
 		result.ProviderId = info.SystemID
 		result.Date = info.Date
+		result.StorageID = info.StorageID
+		result.XScore = info.XScore
+		result.Language = info.Language
+		result.Relations = info.Relations
+		result.SearchTerm = info.SearchTerm
 		logger.Debug("Get info", "info_data", info)
 	}
 
@@ -165,6 +208,58 @@ func (run *IntelxParser) ParseFile(thisRunner *runner.Runner, file runner.FileIt
 	return result, nil
 }
 
+// IndexFiles returns the downloaded files of the folder with the Info.csv
+func (run *IntelxParser) IndexFiles(index runner.FileItem) []runner.FileItem {
+	items := []runner.FileItem{}
+
+	entries, err := os.ReadDir(filepath.Dir(index.RealPath))
+	if err != nil {
+		run.log.Error("Error listing IntelX folder", "err", err)
+		return items
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || e.Name() == "Info.csv" || e.Name() == "info.sqlite3" {
+			continue
+		}
+		items = append(items, runner.FileItem{
+			RealPath: filepath.Join(filepath.Dir(index.RealPath), e.Name()),
+			VirtualPath: filepath.Join(filepath.Dir(index.VirtualPath), e.Name()),
+		})
+	}
+
+	return items
+}
+
+// AddSearchResult adds the metadata of a file downloaded by the IntelX API,
+// used instead of the Info.csv when the download is parsed directly
+func (run *IntelxParser) AddSearchResult(item ixapi.SearchResult) {
+	run.infoMutex.Lock()
+	defer run.infoMutex.Unlock()
+
+	id := strings.ToLower(item.SystemID)
+	if id == "" || slices.IndexFunc(run.info, func(i InfoData) bool { return i.SystemID == id }) >= 0 {
+		return
+	}
+
+	csv := item.GetCsv()
+	run.info = append(run.info, InfoData{
+		Name:       csv.Name,
+		Date:       item.Date,
+		Bucket:     csv.Bucket,
+		Media:      csv.Media,
+		Content:    csv.Content,
+		Type:       csv.Type,
+		Size:       uint(csv.Size),
+		SystemID:   id,
+		StorageID:  csv.StorageID,
+		XScore:     csv.XScore,
+		Language:   csv.Language,
+		Relations:  csv.Relations,
+		SearchTerm: csv.SearchTerm,
+	})
+}
+
 func (run *IntelxParser) MustSaveContent(file *models.File) bool {
     s := strings.ToLower(file.Bucket)
     n := strings.ToLower(file.Name)
@@ -224,6 +319,11 @@ func (run *IntelxParser) ParseInfo(file_path string) error {
 	Type := -1
 	Size := -1
 	SystemID := -1
+	StorageID := -1
+	XScore := -1
+	Language := -1
+	Relations := -1
+	SearchTerm := -1
 
 	for idx, c := range records[0] {
 		switch strings.ToLower(c) {
@@ -243,6 +343,16 @@ func (run *IntelxParser) ParseInfo(file_path string) error {
 		        Size = idx
 		    case "system id":
 		        SystemID = idx
+		    case "storage id":
+		        StorageID = idx
+		    case "xscore", "x-score":
+		        XScore = idx
+		    case "language":
+		        Language = idx
+		    case "relations":
+		        Relations = idx
+		    case "search term":
+		        SearchTerm = idx
 		    
 	    }
 	}
@@ -265,6 +375,8 @@ func (run *IntelxParser) ParseInfo(file_path string) error {
 				}
 			}
 
+			xs, _ := strconv.Atoi(GetOrDefault(rec, XScore, "0"))
+
 			run.info = append(run.info, InfoData{
                             Name:  		GetOrDefault(rec, Name, ""),
                             Date:  		dt,
@@ -274,6 +386,11 @@ func (run *IntelxParser) ParseInfo(file_path string) error {
                             Type:  		GetOrDefault(rec, Type, ""),
                             Size:  		uint(s),
                             SystemID:  	strings.ToLower(GetOrDefault(rec, SystemID, "")),
+                            StorageID:  GetOrDefault(rec, StorageID, ""),
+                            XScore:  	xs,
+                            Language:  	GetOrDefault(rec, Language, ""),
+                            Relations:  GetOrDefault(rec, Relations, ""),
+                            SearchTerm: GetOrDefault(rec, SearchTerm, ""),
                         })
 		}
 	}
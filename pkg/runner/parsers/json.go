@@ -0,0 +1,322 @@
+package parsers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/helviojunior/intelparser/internal/tools"
+	"github.com/helviojunior/intelparser/pkg/database"
+	"github.com/helviojunior/intelparser/pkg/models"
+	"github.com/helviojunior/intelparser/pkg/runner"
+	"gorm.io/gorm"
+)
+
+// jsonExtensions are the JSON dump file extensions
+var jsonExtensions = []string{".json", ".ndjson", ".jsonl"}
+
+func init() {
+	Register(&Registration{
+		Name: "json",
+		Short: "Parse JSON/NDJSON leak dumps",
+		Long: `
+Parse JSON array or NDJSON (JSON lines) leak dumps keeping the association
+between the record fields.
+
+Field mapping to credentials and e-mails is auto detected from common key
+names (user, login, usuario, email, password, senha, pwd, url, created_at...)
+or supplied via a mapping file. Fields not mapped are kept as attributes.
+
+Mapping file example:
+
+    {
+        "username": ["login"],
+        "email": ["mail", "contact.email"],
+        "password": ["pwd_plain"],
+        "url": ["site"],
+        "time": ["created_at"]
+    }
+`,
+		Example: `
+   - intelparser parse json -p ~/Desktop/dump.json
+   - intelparser parse json -p ~/Desktop/dumps/ --mapping ~/Desktop/mapping.json
+   - intelparser parse json -p ~/Desktop/dump.ndjson --write-elastic --write-elasticsearch-uri "http://127.0.0.1:9200/intelparser"
+`,
+		PathUsage: "A JSON file or a path with JSON file(s).",
+		Extensions: jsonExtensions,
+		Flags: mappingFlags,
+		New: func(logger *slog.Logger, opts runner.Options) (runner.ParserDriver, error) {
+			mapping, err := flagFieldMapping()
+			if err != nil {
+				return nil, err
+			}
+			return NewJson(logger, opts, mapping)
+		},
+		Sniff: sniffJson,
+	})
+}
+
+// JsonParser is a driver that parses JSON array and NDJSON leak dumps
+// mapping the record fields to credentials and e-mails
+type JsonParser struct {
+	// options for the Runner to consider
+	options runner.Options
+	// logger
+	log *slog.Logger
+	// Record field mapping
+	mapping *FieldMapping
+	//
+	conn *gorm.DB
+}
+
+// NewJson returns a new JsonParser instance. A nil mapping uses the auto detection one
+func NewJson(logger *slog.Logger, opts runner.Options, mapping *FieldMapping) (*JsonParser, error) {
+	var conn *gorm.DB
+	var err error
+	conn, err = database.Connection(opts.Writer.GlobalDbURI, true, false)
+	if err != nil {
+		logger.Debug("Error connecting to the database", "conn", opts.Writer.GlobalDbURI, "err", err)
+		conn = nil
+	}
+
+	if mapping == nil {
+		mapping = DefaultFieldMapping()
+	}
+
+	return &JsonParser{
+		options: opts,
+		log:     logger,
+		mapping: mapping,
+		conn:    conn,
+	}, nil
+}
+
+func (run *JsonParser) ParseFile(thisRunner *runner.Runner, file runner.FileItem) (*models.File, error) {
+	logger := run.log.With("file", filepath.Base(file.RealPath))
+
+	result, err := newLocalFile(file, "JSON")
+	if err != nil {
+		return result, err
+	}
+
+	if alreadyParsed(run.conn, result.FileName, result.Fingerprint) {
+		logger.Debug("[File already parsed]")
+		return nil, nil
+	}
+
+	f, err := os.Open(file.RealPath)
+	if err != nil {
+		return result, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReaderSize(f, 1024 * 1024)
+	first, err := firstNonSpace(br)
+	if err != nil && err != io.EOF {
+		return result, err
+	}
+
+	if first != '[' && first != '{' {
+		// Not a JSON dump, use the regular rules
+		logger.Debug("Not a JSON file, using text rules")
+		err = thisRunner.DetectFile(result)
+		result.FilePath = file.VirtualPath
+		return result, err
+	}
+
+	logger.Debug("Parsing JSON records")
+	dec := json.NewDecoder(br)
+	dec.UseNumber()
+
+	// The records are written every recordFlushRows
+	chunks := &recordChunks{
+		runner: thisRunner,
+		log: logger,
+		newChunk: func(_ string, n int) *models.File {
+			return recordResult(result, file.VirtualPath, n)
+		},
+	}
+	defer chunks.flush()
+
+	records := 0
+	var handleObj func(obj map[string]interface{}, raw []byte)
+	handleObj = func(obj map[string]interface{}, raw []byte) {
+		fields := map[string]string{}
+		flattenJson("", obj, fields)
+
+		finding, t, ok := run.mapping.Finding("JSON » Record", sortedRecord(fields))
+		if !ok {
+			// Wrapper objects like {"data": [{...}, {...}]}
+			for _, v := range obj {
+				if items, isArray := v.([]interface{}); isArray {
+					for _, item := range items {
+						if child, isObj := item.(map[string]interface{}); isObj {
+							b, _ := json.Marshal(child)
+							handleObj(child, b)
+						}
+					}
+				}
+			}
+			return
+		}
+
+		// The record is the context of the finding
+		if run.options.Parser.StoreNearText {
+			finding.Credential.NearText = string(raw)
+			finding.Email.NearText = string(raw)
+		}
+
+		if t.IsZero() {
+			t = result.Date
+		}
+		records++
+		chunks.add("", finding, t)
+	}
+
+	handle := func(raw json.RawMessage) {
+		var obj map[string]interface{}
+		d := json.NewDecoder(bytes.NewReader(raw))
+		d.UseNumber()
+		if err := d.Decode(&obj); err != nil {
+			return
+		}
+		handleObj(obj, raw)
+	}
+
+	if first == '[' {
+		// Stream the array items
+		if _, err := dec.Token(); err != nil {
+			return result, err
+		}
+		for dec.More() {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return result, err
+			}
+			handle(raw)
+		}
+	} else {
+		// NDJSON or concatenated objects
+		for {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				if err == io.EOF {
+					break
+				}
+				if records == 0 {
+					return result, err
+				}
+				logger.Warn("Invalid JSON record, stopping", "records", records, "err", err)
+				break
+			}
+			handle(raw)
+		}
+	}
+
+	logger.Debug("JSON records parsed", "records", records, "results", chunks.chunks)
+	result.FilePath = file.VirtualPath
+
+	return result, nil
+}
+
+func (run *JsonParser) Close() {
+	run.log.Debug("closing JSON parser context")
+}
+
+// flattenJson flattens nested objects using dotted names. Arrays are kept as JSON text
+func flattenJson(prefix string, obj map[string]interface{}, out map[string]string) {
+	for k, v := range obj {
+		name := k
+		if prefix != "" {
+			name = prefix + "." + k
+		}
+
+		switch val := v.(type) {
+		case map[string]interface{}:
+			flattenJson(name, val, out)
+		case string:
+			out[name] = val
+		case nil:
+			out[name] = ""
+		case []interface{}:
+			if b, err := json.Marshal(val); err == nil {
+				out[name] = string(b)
+			}
+		default:
+			out[name] = fmt.Sprintf("%v", val)
+		}
+	}
+}
+
+// firstNonSpace peeks the first non white space byte, skipping the UTF-8 BOM
+func firstNonSpace(br *bufio.Reader) (byte, error) {
+	if b, err := br.Peek(3); err == nil && string(b) == "\xef\xbb\xbf" {
+		br.Discard(3)
+	}
+
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b[0])) {
+			return b[0], nil
+		}
+		br.Discard(1)
+	}
+}
+
+// newLocalFile creates the result of a local dump file (not downloaded from a provider)
+func newLocalFile(file runner.FileItem, bucket string) (*models.File, error) {
+	file_name := filepath.Base(file.RealPath)
+	result := &models.File{
+		Provider: "Local",
+		FilePath: file.RealPath,
+		FileName: file_name,
+		Name: file.VirtualPath,
+		Bucket: bucket,
+		Date: time.Now(),
+		IndexedAt: time.Now(),
+	}
+
+	fst, err := os.Stat(file.RealPath)
+	if err != nil {
+		return result, err
+	}
+	if fst.IsDir() {
+		return result, errors.New("path is a directory")
+	}
+
+	result.Date = fst.ModTime()
+	result.Size = uint(fst.Size())
+	result.Fingerprint, _ = tools.GetHashFromFile(file.RealPath)
+	result.MIMEType, _ = tools.GetMimeType(file.RealPath)
+
+	return result, nil
+}
+
+// sniffJson handles the files with JSON extension or starting with an array/object
+func sniffJson(input Input) int {
+	if input.IsDir {
+		return 0
+	}
+
+	if tools.SliceHasStr(jsonExtensions, input.Ext) {
+		return 50
+	}
+
+	head := bytes.TrimLeft(input.Head, " \r\n\t\xef\xbb\xbf")
+	if len(head) > 0 && (head[0] == '[' || head[0] == '{') {
+		return 45
+	}
+
+	return 0
+}
@@ -0,0 +1,106 @@
+package parsers
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/helviojunior/intelparser/pkg/models"
+	"github.com/helviojunior/intelparser/pkg/runner"
+	"github.com/helviojunior/intelparser/pkg/writers"
+)
+
+// testWriter keeps the results written by the runner
+type testWriter struct {
+	results []*models.File
+	mutex   sync.Mutex
+}
+
+func (w *testWriter) Write(result *models.File) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.results = append(w.results, result)
+	return nil
+}
+
+func testOptions(t *testing.T) *runner.Options {
+	opts := runner.NewDefaultOptions()
+	opts.Logging.Silence = true
+	opts.Writer.GlobalDbURI = "sqlite:///" + filepath.Join(t.TempDir(), "missing.db")
+	return opts
+}
+
+// parseTestFile parses the file data with the driver, returning the results written
+// and the one returned by the driver
+func parseTestFile(t *testing.T, driver runner.ParserDriver, opts *runner.Options, name string, data string) ([]*models.File, *models.File) {
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	writer := &testWriter{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	run, err := runner.NewRunner(logger, driver, *opts, []writers.Writer{writer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := driver.ParseFile(run, runner.FileItem{ RealPath: p, VirtualPath: name })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writer.results, result
+}
+
+// Big dumps are written in several results
+func TestJsonChunks(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < recordFlushRows + 5; i++ {
+		fmt.Fprintf(&sb, "{\"email\": \"user%d@example.com\", \"password\": \"Secret%d\"}\n", i, i)
+	}
+
+	opts := testOptions(t)
+	driver, err := NewJson(slog.New(slog.NewTextHandler(io.Discard, nil)), *opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, result := parseTestFile(t, driver, opts, "dump.json", sb.String())
+	if len(results) != 2 {
+		t.Fatalf("%d results written, want 2", len(results))
+	}
+	if len(results[0].Credentials) != recordFlushRows || len(results[1].Credentials) != 5 {
+		t.Errorf("results with %d and %d credentials, want %d and 5", len(results[0].Credentials), len(results[1].Credentials), recordFlushRows)
+	}
+	if results[0].Fingerprint == results[1].Fingerprint || results[0].Fingerprint == result.Fingerprint {
+		t.Errorf("results with the same fingerprint")
+	}
+	if len(result.Credentials) != 0 {
+		t.Errorf("returned result with %d credentials, want 0", len(result.Credentials))
+	}
+}
+
+// The roles cache is bounded, as NDJSON records may have their own field names
+func TestFieldMappingCache(t *testing.T) {
+	fm := DefaultFieldMapping()
+	for i := 0; i < mappingCacheSize * 2; i++ {
+		roles := fm.roles([]string{"email", fmt.Sprintf("field%d", i)})
+		if _, ok := roles["email"]; !ok {
+			t.Fatalf("roles() without the email role")
+		}
+	}
+
+	cached := 0
+	fm.cache.Range(func(_, _ any) bool {
+		cached++
+		return true
+	})
+	if cached != mappingCacheSize {
+		t.Errorf("%d names cached, want %d", cached, mappingCacheSize)
+	}
+}
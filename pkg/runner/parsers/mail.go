@@ -0,0 +1,371 @@
+package parsers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/helviojunior/intelparser/internal/tools"
+	"github.com/helviojunior/intelparser/pkg/database"
+	"github.com/helviojunior/intelparser/pkg/models"
+	"github.com/helviojunior/intelparser/pkg/runner"
+	"golang.org/x/text/encoding/htmlindex"
+	"gorm.io/gorm"
+)
+
+// mailAddressHeaders are the headers recorded as e-mails
+var mailAddressHeaders = []string{"From", "Sender", "Reply-To", "To", "Cc", "Bcc", "Delivered-To"}
+
+// mailTextExtensions are the attachment extensions handled as text
+var mailTextExtensions = []string{".txt", ".csv", ".tsv", ".log", ".json", ".xml", ".sql", ".ini", ".conf", ".cfg", ".env", ".yml", ".yaml", ".html", ".htm", ".md", ".ps1", ".sh", ".bat", ".config", ".properties"}
+
+// mailMaxDepth limits the nested multipart/message depth
+const mailMaxDepth = 10
+
+var mailWordDecoder = &mime.WordDecoder{
+	CharsetReader: charsetReader,
+}
+
+// mailExtensions are the mailbox/message file extensions
+var mailExtensions = []string{".mbox", ".mbx", ".eml"}
+
+// mailHeaderPrefixes identify the .eml files without extension
+var mailHeaderPrefixes = []string{"return-path:", "received:", "delivered-to:", "message-id:", "mime-version:"}
+
+func init() {
+	Register(&Registration{
+		Name: "mail",
+		Short: "Parse mbox/eml mail archives",
+		Long: `
+Parse mbox mailboxes and .eml messages.
+
+Each message is split and its MIME parts are decoded (base64,
+quoted-printable and charset). The detection runs on every text part
+and text attachment. The From, To, Cc, Bcc, Reply-To and Sender
+addresses are recorded as e-mails using the message date.
+`,
+		Example: `
+   - intelparser parse mail -p ~/Desktop/inbox.mbox
+   - intelparser parse mail -p ~/Desktop/mails/
+   - intelparser parse mail -p ~/Desktop/message.eml --write-elastic --write-elasticsearch-uri "http://127.0.0.1:9200/intelparser"
+`,
+		PathUsage: "A mbox/eml file or a path with mail file(s).",
+		Extensions: mailExtensions,
+		New: func(logger *slog.Logger, opts runner.Options) (runner.ParserDriver, error) {
+			return NewMail(logger, opts)
+		},
+		Sniff: sniffMail,
+	})
+}
+
+// MailParser is a driver that parses mbox and .eml files, recording the
+// message addresses and running the detection on each text part/attachment
+type MailParser struct {
+	// options for the Runner to consider
+	options runner.Options
+	// logger
+	log *slog.Logger
+	//
+	conn *gorm.DB
+}
+
+// NewMail returns a new MailParser instance
+func NewMail(logger *slog.Logger, opts runner.Options) (*MailParser, error) {
+	var conn *gorm.DB
+	var err error
+	conn, err = database.Connection(opts.Writer.GlobalDbURI, true, false)
+	if err != nil {
+		logger.Debug("Error connecting to the database", "conn", opts.Writer.GlobalDbURI, "err", err)
+		conn = nil
+	}
+
+	return &MailParser{
+		options: opts,
+		log:     logger,
+		conn:    conn,
+	}, nil
+}
+
+func (run *MailParser) ParseFile(thisRunner *runner.Runner, file runner.FileItem) (*models.File, error) {
+	logger := run.log.With("file", filepath.Base(file.RealPath))
+
+	result, err := newLocalFile(file, "Mail")
+	if err != nil {
+		return result, err
+	}
+
+	if alreadyParsed(run.conn, result.FileName, result.Fingerprint) {
+		logger.Debug("[File already parsed]")
+		return nil, nil
+	}
+
+	f, err := os.Open(file.RealPath)
+	if err != nil {
+		return result, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReaderSize(f, 1024 * 1024)
+	messages := 0
+
+	if head, _ := br.Peek(5); string(head) == "From " {
+		// mbox: messages are separated by "From " lines
+		var msg bytes.Buffer
+		blank := true
+		for {
+			line, err := br.ReadBytes('\n')
+			if len(line) > 0 {
+				if blank && bytes.HasPrefix(line, []byte("From ")) {
+					if msg.Len() > 0 {
+						run.parseMessage(thisRunner, result, msg.Bytes(), logger)
+						messages++
+					}
+					msg.Reset()
+				} else {
+					// mboxrd quoting
+					if bytes.HasPrefix(bytes.TrimLeft(line, ">"), []byte("From ")) && line[0] == '>' {
+						line = line[1:]
+					}
+					msg.Write(line)
+				}
+				blank = len(bytes.TrimRight(line, "\r\n")) == 0
+			}
+
+			if err != nil {
+				if err != io.EOF {
+					return result, err
+				}
+				break
+			}
+		}
+
+		if msg.Len() > 0 {
+			run.parseMessage(thisRunner, result, msg.Bytes(), logger)
+			messages++
+		}
+	} else {
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return result, err
+		}
+		run.parseMessage(thisRunner, result, data, logger)
+		messages++
+	}
+
+	logger.Debug("Mail messages parsed", "messages", messages)
+	result.FilePath = file.VirtualPath
+
+	return result, nil
+}
+
+func (run *MailParser) Close() {
+	run.log.Debug("closing Mail parser context")
+}
+
+// parseMessage records the message addresses and walks the MIME parts
+func (run *MailParser) parseMessage(thisRunner *runner.Runner, result *models.File, data []byte, logger *slog.Logger) {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		logger.Debug("Invalid mail message", "err", err)
+		return
+	}
+
+	run.walkMessage(thisRunner, result, msg, result.Date, 0, logger)
+}
+
+func (run *MailParser) walkMessage(thisRunner *runner.Runner, result *models.File, msg *mail.Message, parentDate time.Time, depth int, logger *slog.Logger) {
+	date, err := msg.Header.Date()
+	if err != nil {
+		date = parentDate
+	}
+
+	// Header context stored as near text of the addresses
+	nearText := ""
+	if run.options.Parser.StoreNearText {
+		nearText = fmt.Sprintf("Date: %s\nFrom: %s\nTo: %s\nSubject: %s",
+			msg.Header.Get("Date"), decodeMailHeader(msg.Header.Get("From")),
+			decodeMailHeader(msg.Header.Get("To")), decodeMailHeader(msg.Header.Get("Subject")))
+	}
+
+	seen := map[string]bool{}
+	for _, h := range mailAddressHeaders {
+		if msg.Header.Get(h) == "" {
+			continue
+		}
+
+		addresses, err := msg.Header.AddressList(h)
+		if err != nil {
+			continue
+		}
+
+		for _, a := range addresses {
+			email := strings.ToLower(a.Address)
+			if seen[email] || !strings.Contains(email, "@") {
+				continue
+			}
+			seen[email] = true
+
+			thisRunner.AddFinding(result, models.Finding{
+				RuleID: "Mail » Header",
+				Email: models.Email{
+					Domain      : strings.Split(email, "@")[1],
+					Email       : email,
+					NearText    : nearText,
+				},
+			}, date)
+		}
+	}
+
+	run.walkPart(thisRunner, result, mailHeader(msg.Header), msg.Body, date, depth, logger)
+}
+
+// walkPart decodes the part content and runs the detection on text parts
+func (run *MailParser) walkPart(thisRunner *runner.Runner, result *models.File, header mailHeader, body io.Reader, date time.Time, depth int, logger *slog.Logger) {
+	if depth > mailMaxDepth {
+		return
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+		params = map[string]string{}
+	}
+
+	switch strings.ToLower(strings.Trim(header.Get("Content-Transfer-Encoding"), " \t")) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, &base64Cleaner{ r: body })
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err != nil {
+				if err != io.EOF {
+					logger.Debug("Invalid multipart", "err", err)
+				}
+				return
+			}
+			run.walkPart(thisRunner, result, mailHeader(part.Header), part, date, depth + 1, logger)
+		}
+	}
+
+	if mediaType == "message/rfc822" {
+		if msg, err := mail.ReadMessage(body); err == nil {
+			run.walkMessage(thisRunner, result, msg, date, depth + 1, logger)
+		}
+		return
+	}
+
+	fileName := params["name"]
+	if _, dp, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil && dp["filename"] != "" {
+		fileName = dp["filename"]
+	}
+	fileName = decodeMailHeader(fileName)
+
+	if !strings.HasPrefix(mediaType, "text/") && mediaType != "application/json" && mediaType != "application/xml" &&
+		!tools.SliceHasStr(mailTextExtensions, strings.ToLower(filepath.Ext(fileName))) {
+		logger.Debug("Ignoring binary mail part", "type", mediaType, "name", fileName)
+		return
+	}
+
+	if cs := params["charset"]; cs != "" {
+		if r, err := charsetReader(cs, body); err == nil {
+			body = r
+		}
+	}
+
+	limit := int64(thisRunner.MaxTargetMegaBytes) * 1000000
+	if limit <= 0 {
+		limit = 200 * 1000000
+	}
+	content, err := io.ReadAll(io.LimitReader(body, limit))
+	if err != nil && len(content) == 0 {
+		logger.Debug("Error reading mail part", "err", err)
+		return
+	}
+
+	for _, finding := range thisRunner.DetectBytes(content) {
+		thisRunner.AddFinding(result, finding, date)
+	}
+}
+
+// decodeMailHeader decodes the RFC 2047 encoded words of a header value
+func decodeMailHeader(value string) string {
+	if decoded, err := mailWordDecoder.DecodeHeader(value); err == nil {
+		return decoded
+	}
+	return value
+}
+
+// mailHeader allows the same access to message and MIME part headers
+type mailHeader map[string][]string
+
+func (h mailHeader) Get(key string) string {
+	return mail.Header(h).Get(key)
+}
+
+// base64Cleaner drops the chars not accepted by the base64 decoder (spaces, tabs)
+type base64Cleaner struct {
+	r io.Reader
+}
+
+func (c *base64Cleaner) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	j := 0
+	for i := 0; i < n; i++ {
+		if p[i] != ' ' && p[i] != '\t' {
+			p[j] = p[i]
+			j++
+		}
+	}
+	return j, err
+}
+
+// charsetReader converts the content from the charset to UTF-8
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	charset = strings.ToLower(strings.Trim(charset, " \"'"))
+	if charset == "" || charset == "utf-8" || charset == "us-ascii" || charset == "utf8" {
+		return input, nil
+	}
+
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, err
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// sniffMail handles the mail extensions, mbox files and messages starting with
+// the transport headers
+func sniffMail(input Input) int {
+	if input.IsDir {
+		return 0
+	}
+
+	if tools.SliceHasStr(mailExtensions, input.Ext) || bytes.HasPrefix(input.Head, []byte("From ")) {
+		return 70
+	}
+
+	head := strings.ToLower(string(input.Head[:min(len(input.Head), 64)]))
+	for _, p := range mailHeaderPrefixes {
+		if strings.HasPrefix(head, p) {
+			return 65
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,178 @@
+package parsers
+
+import (
+	"io"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/helviojunior/intelparser/pkg/models"
+)
+
+const testMbox = "From sender@corp.org Mon Jan  1 00:00:00 2024\n" +
+	"From: Alice <alice@corp.org>\n" +
+	"To: bob@corp.org\n" +
+	"Subject: =?UTF-8?B?UmVsYXTDs3Jpbw==?=\n" +
+	"Date: Mon, 01 Jan 2024 10:00:00 +0000\n" +
+	"Content-Type: text/plain\n" +
+	"\n" +
+	"plain body plain@corp.org\n" +
+	"\n" +
+	">From quoted@corp.org is not a new message\n" +
+	"\n" +
+	"From sender@corp.org Tue Jan  2 00:00:00 2024\n" +
+	"From: carol@corp.org\n" +
+	"To: dave@corp.org\n" +
+	"Date: Tue, 02 Jan 2024 10:00:00 +0000\n" +
+	"MIME-Version: 1.0\n" +
+	"Content-Type: multipart/mixed; boundary=\"b1\"\n" +
+	"\n" +
+	"--b1\n" +
+	"Content-Type: text/plain; charset=utf-8\n" +
+	"Content-Transfer-Encoding: base64\n" +
+	"\n" +
+	"YmFzZTY0IHBhcnQgYjY0QGNvcnAub3JnCg==\n" +
+	"--b1\n" +
+	"Content-Type: text/html; charset=utf-8\n" +
+	"Content-Transfer-Encoding: quoted-printable\n" +
+	"\n" +
+	"<p class=3D\"x\">quoted printable qp@cor=\n" +
+	"p.org</p>\n" +
+	"--b1\n" +
+	"Content-Type: text/plain; charset=iso-8859-1\n" +
+	"Content-Transfer-Encoding: quoted-printable\n" +
+	"\n" +
+	"Jos=E9 latin@corp.org\n" +
+	"--b1\n" +
+	"Content-Type: message/rfc822\n" +
+	"\n" +
+	"From: nested@corp.org\n" +
+	"To: erin@corp.org\n" +
+	"Subject: inner\n" +
+	"\n" +
+	"nested body nestedbody@corp.org\n" +
+	"--b1\n" +
+	"Content-Type: application/octet-stream; name=\"creds.txt\"\n" +
+	"Content-Disposition: attachment; filename=\"creds.txt\"\n" +
+	"Content-Transfer-Encoding: base64\n" +
+	"\n" +
+	"YXR0YWNobWVudCBhdHRhY2hAY29ycC5vcmcK\n" +
+	"--b1\n" +
+	"Content-Type: application/octet-stream; name=\"image.png\"\n" +
+	"Content-Disposition: attachment; filename=\"image.png\"\n" +
+	"Content-Transfer-Encoding: base64\n" +
+	"\n" +
+	"iVBORyBiaW5hcnlAY29ycC5vcmcK\n" +
+	"--b1--\n"
+
+// mailEmails returns the e-mails of the results by address
+func mailEmails(results []*models.File, result *models.File) map[string]models.Email {
+	emails := map[string]models.Email{}
+	for _, r := range append(results, result) {
+		for _, e := range r.Emails {
+			emails[e.Email] = e
+		}
+	}
+	return emails
+}
+
+func parseTestMail(t *testing.T, storeNearText bool, name string, data string) map[string]models.Email {
+	opts := testOptions(t)
+	opts.Parser.StoreNearText = storeNearText
+	driver, err := NewMail(slog.New(slog.NewTextHandler(io.Discard, nil)), *opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, result := parseTestFile(t, driver, opts, name, data)
+	return mailEmails(results, result)
+}
+
+func TestMailParser(t *testing.T) {
+	emails := parseTestMail(t, false, "inbox.mbox", testMbox)
+
+	got := []string{}
+	for e := range emails {
+		got = append(got, e)
+	}
+	sort.Strings(got)
+
+	// The binary attachment is ignored
+	want := []string{
+		"alice@corp.org", "attach@corp.org", "b64@corp.org", "bob@corp.org", "carol@corp.org",
+		"dave@corp.org", "erin@corp.org", "latin@corp.org", "nested@corp.org", "nestedbody@corp.org",
+		"plain@corp.org", "qp@corp.org", "quoted@corp.org",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("e-mails = %q, want %q", got, want)
+	}
+
+	for _, e := range emails {
+		if e.NearText != "" {
+			t.Errorf("%s with near text %q without --store-neartext", e.Email, e.NearText)
+		}
+	}
+}
+
+func TestMailParserNearText(t *testing.T) {
+	emails := parseTestMail(t, true, "inbox.mbox", testMbox)
+
+	tests := []struct {
+		email    string
+		contains string
+		excludes string
+	}{
+		{"alice@corp.org", "Subject: Relatório", ""},
+		{"quoted@corp.org", "From quoted@corp.org", ">From"},
+		{"latin@corp.org", "José", ""},
+		{"nested@corp.org", "Subject: inner", ""},
+	}
+
+	for _, tt := range tests {
+		e, ok := emails[tt.email]
+		if !ok {
+			t.Errorf("%s not found", tt.email)
+			continue
+		}
+		if !strings.Contains(e.NearText, tt.contains) {
+			t.Errorf("%s near text = %q, want to contain %q", tt.email, e.NearText, tt.contains)
+		}
+		if tt.excludes != "" && strings.Contains(e.NearText, tt.excludes) {
+			t.Errorf("%s near text = %q, want without %q", tt.email, e.NearText, tt.excludes)
+		}
+	}
+}
+
+// A message without the mbox separator
+func TestMailParserEml(t *testing.T) {
+	eml := "Return-Path: <frank@corp.org>\nFrom: frank@corp.org\nTo: grace@corp.org\nSubject: eml\n\nbody eml@corp.org\n"
+	emails := parseTestMail(t, false, "message.eml", eml)
+
+	for _, e := range []string{"frank@corp.org", "grace@corp.org", "eml@corp.org"} {
+		if _, ok := emails[e]; !ok {
+			t.Errorf("%s not found", e)
+		}
+	}
+}
+
+func TestSniffMail(t *testing.T) {
+	tests := []struct {
+		name  string
+		input Input
+		want  int
+	}{
+		{"extension", Input{ Ext: ".eml" }, 70},
+		{"mbox", Input{ Ext: "", Head: []byte("From sender@corp.org Mon Jan  1 00:00:00 2024\n") }, 70},
+		{"headers", Input{ Ext: "", Head: []byte("Received: from mx.corp.org\n") }, 65},
+		{"directory", Input{ Ext: ".mbox", IsDir: true }, 0},
+		{"text", Input{ Ext: ".txt", Head: []byte("just some text\n") }, 0},
+	}
+
+	for _, tt := range tests {
+		if got := sniffMail(tt.input); got != tt.want {
+			t.Errorf("%s: sniffMail() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package parsers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/mail"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/helviojunior/intelparser/internal/tools"
+	"github.com/helviojunior/intelparser/pkg/models"
+	"github.com/helviojunior/intelparser/pkg/runner"
+	"github.com/spf13/pflag"
+	"gorm.io/gorm"
+)
+
+// mappingFile is the --mapping flag, shared by the drivers mapping record fields
+var mappingFile string
+
+// FieldMapping maps the fields of structured records (JSON keys, CSV headers,
+// SQL columns...) to credential and e-mail fields. Each entry is a list of
+// candidate field names in priority order, compared ignoring case, spaces,
+// '-', '_' and '.'. Nested JSON fields may be referenced as parent.child.
+type FieldMapping struct {
+	Username  []string    `json:"username"`
+	Email     []string    `json:"email"`
+	Password  []string    `json:"password"`
+	Url       []string    `json:"url"`
+	Domain    []string    `json:"domain"`
+	CPF       []string    `json:"cpf"`
+	Time      []string    `json:"time"`
+
+	// Roles cache by field names, records of the same table share the names.
+	// Up to mappingCacheSize names are cached, as each NDJSON record may have its own names
+	cache     sync.Map
+	cached    atomic.Int32
+}
+
+const mappingCacheSize = 1024
+
+// recordFlushRows is the number of mapped records of a result, the records of a dump
+// are written every recordFlushRows, so big dumps are not kept in memory
+const recordFlushRows = 10000
+
+// RecordField is a single field of a structured record
+type RecordField struct {
+	Name   string
+	Value  string
+}
+
+// DefaultFieldMapping returns the auto detection mapping with the common key names.
+// Password names follow the Leak2 keyword list
+func DefaultFieldMapping() *FieldMapping {
+	return &FieldMapping{
+		Username: []string{"username", "user", "usuario", "usuário", "login", "user_name", "userid", "nickname", "nick", "account", "conta"},
+		Email:    []string{"email", "e-mail", "mail", "email_address", "emailaddress", "user_email", "correo"},
+		Password: []string{"password", "pass", "senha", "pwd", "passwd", "secret", "token", "contrasena", "contraseña", "password_hash", "passwordhash", "senha_hash", "hash"},
+		Url:      []string{"url", "host", "site", "website", "origin", "origin_url", "action_url", "uri", "link"},
+		Domain:   []string{"domain", "user_domain", "dominio", "domínio"},
+		CPF:      []string{"cpf", "cpf_cnpj", "documento"},
+		Time:     []string{"created_at", "createdat", "created", "creation_date", "date_created", "registered", "register_date", "data_cadastro", "dt_cadastro", "date", "data", "timestamp", "time"},
+	}
+}
+
+// LoadFieldMapping reads a JSON mapping file. Roles absent from the file use
+// the default key names
+func LoadFieldMapping(file_path string) (*FieldMapping, error) {
+	data, err := os.ReadFile(file_path)
+	if err != nil {
+		return nil, err
+	}
+
+	fm := &FieldMapping{}
+	if err := json.Unmarshal(data, fm); err != nil {
+		return nil, err
+	}
+
+	def := DefaultFieldMapping()
+	for _, r := range []struct{ dst *[]string; def []string }{
+		{&fm.Username, def.Username},
+		{&fm.Email, def.Email},
+		{&fm.Password, def.Password},
+		{&fm.Url, def.Url},
+		{&fm.Domain, def.Domain},
+		{&fm.CPF, def.CPF},
+		{&fm.Time, def.Time},
+	} {
+		if len(*r.dst) == 0 {
+			*r.dst = r.def
+		}
+	}
+
+	return fm, nil
+}
+
+// mappingFlags adds the --mapping flag once, as parse auto has the flags of all drivers
+func mappingFlags(flags *pflag.FlagSet) {
+	if flags.Lookup("mapping") == nil {
+		flags.StringVar(&mappingFile, "mapping", "", "JSON file mapping the record fields/column names (username, email, password, url, domain, cpf, time)")
+	}
+}
+
+// flagFieldMapping loads the --mapping file, nil (auto detection) when not set
+func flagFieldMapping() (*FieldMapping, error) {
+	if mappingFile == "" {
+		return nil, nil
+	}
+
+	fm, err := LoadFieldMapping(mappingFile)
+	if err != nil {
+		return nil, errors.New("invalid mapping file: " + err.Error())
+	}
+	return fm, nil
+}
+
+var fieldNameReplacer = strings.NewReplacer(" ", "", "-", "", "_", "", ".", "")
+
+func normalizeFieldName(name string) string {
+	return fieldNameReplacer.Replace(strings.ToLower(strings.Trim(name, " \r\n\t\"'`[]")))
+}
+
+// HasRoles returns true if the field names map at least a credential or e-mail
+func (fm *FieldMapping) HasRoles(names []string) bool {
+	roles := fm.roles(names)
+	_, hasEmail := roles["email"]
+	_, hasUser := roles["username"]
+	_, hasPass := roles["password"]
+	return hasEmail || (hasUser && hasPass)
+}
+
+// roles returns the index of the field mapped to each role
+func (fm *FieldMapping) roles(names []string) map[string]int {
+	key := strings.Join(names, "\x00")
+	if roles, ok := fm.cache.Load(key); ok {
+		return roles.(map[string]int)
+	}
+
+	normalized := map[string]int{}
+	for i, n := range names {
+		nn := normalizeFieldName(n)
+		if _, ok := normalized[nn]; !ok {
+			normalized[nn] = i
+		}
+
+		// Nested fields may be matched by the last name part
+		if p := strings.LastIndex(n, "."); p > 0 {
+			nn = normalizeFieldName(n[p + 1:])
+			if _, ok := normalized[nn]; !ok {
+				normalized[nn] = i
+			}
+		}
+	}
+
+	roles := map[string]int{}
+	for role, candidates := range map[string][]string{
+		"username": fm.Username,
+		"email": fm.Email,
+		"password": fm.Password,
+		"url": fm.Url,
+		"domain": fm.Domain,
+		"cpf": fm.CPF,
+		"time": fm.Time,
+	} {
+		for _, c := range candidates {
+			if i, ok := normalized[normalizeFieldName(c)]; ok {
+				roles[role] = i
+				break
+			}
+		}
+	}
+
+	if fm.cached.Add(1) <= mappingCacheSize {
+		fm.cache.Store(key, roles)
+	}
+	return roles
+}
+
+// Finding builds a finding from a structured record. Fields not mapped to
+// any role are stored as a JSON object at the Attributes field.
+// The returned time is the record time (e.g. created_at) when present.
+func (fm *FieldMapping) Finding(rule string, record []RecordField) (models.Finding, time.Time, bool) {
+	names := make([]string, len(record))
+	for i, f := range record {
+		names[i] = f.Name
+	}
+
+	roles := fm.roles(names)
+	get := func(role string) string {
+		if i, ok := roles[role]; ok {
+			return strings.Trim(record[i].Value, " \r\n\t")
+		}
+		return ""
+	}
+
+	finding := models.Finding{
+		RuleID: rule,
+		Description: "Structured record",
+	}
+
+	username := get("username")
+	password := get("password")
+	email := get("email")
+	if email == "" && strings.Contains(username, "@") {
+		email = username
+	}
+
+	if email != "" {
+		if m, err := mail.ParseAddress(strings.ToLower(email)); err == nil {
+			finding.Email = models.Email{
+				Domain      : strings.Split(m.Address, "@")[1],
+				Email       : m.Address,
+			}
+		}
+	}
+
+	if username == "" {
+		username = email
+	}
+
+	if username != "" && password != "" && !strings.EqualFold(password, "null") {
+		finding.Credential = models.Credential{
+			UserDomain  : get("domain"),
+			Username    : username,
+			Password    : password,
+			Severity    : 100,
+		}
+
+		// Windows style DOMAIN\user
+		if s1 := strings.SplitN(username, "\\", 2); len(s1) == 2 && finding.Credential.UserDomain == "" {
+			finding.Credential.UserDomain = s1[0]
+			finding.Credential.Username = s1[1]
+		}
+
+		if finding.Credential.UserDomain == "" && finding.Email.Email != "" {
+			finding.Credential.UserDomain = finding.Email.Domain
+		}
+
+		if cpf := get("cpf"); cpf != "" {
+			if ok, c := tools.ExtractCPF(cpf); ok {
+				finding.Credential.CPF = c
+			}
+		} else if ok, c := tools.ExtractCPF(username); ok {
+			finding.Credential.CPF = c
+		}
+
+		if u := get("url"); u != "" {
+			finding.Credential.Url = u
+			if !strings.Contains(u, "://") {
+				u = "http://" + u
+			}
+			if pu, err := url.Parse(u); err == nil {
+				finding.Credential.UrlDomain = pu.Hostname()
+			}
+		}
+	}
+
+	if finding.Credential.Username == "" && finding.Email.Email == "" {
+		return finding, time.Time{}, false
+	}
+
+	// Keep the fields not mapped as attributes
+	used := map[int]bool{}
+	for _, i := range roles {
+		used[i] = true
+	}
+	attrs := map[string]string{}
+	for i, f := range record {
+		if !used[i] && strings.Trim(f.Value, " \r\n\t") != "" {
+			attrs[f.Name] = f.Value
+		}
+	}
+	if len(attrs) > 0 {
+		if b, err := json.Marshal(attrs); err == nil {
+			finding.Credential.Attributes = string(b)
+			finding.Email.Attributes = string(b)
+		}
+	}
+
+	t, _ := tools.ParseTime(get("time"))
+	return finding, t, true
+}
+
+// sortedRecord converts a map to a record sorted by field name
+func sortedRecord(fields map[string]string) []RecordField {
+	record := make([]RecordField, 0, len(fields))
+	for k, v := range fields {
+		record = append(record, RecordField{ Name: k, Value: v })
+	}
+	sort.Slice(record, func(i, j int) bool { return record[i].Name < record[j].Name })
+	return record
+}
+
+// recordChunks writes the findings of the dump records in results of up to
+// recordFlushRows records, starting a result when the key (e.g. the SQL table) changes
+type recordChunks struct {
+	runner   *runner.Runner
+	log      *slog.Logger
+	newChunk func(key string, n int) *models.File
+
+	chunk  *models.File
+	key    string
+	rows   int
+	chunks int
+}
+
+func (c *recordChunks) add(key string, finding models.Finding, t time.Time) {
+	if c.chunk == nil || c.key != key || c.rows >= recordFlushRows {
+		c.flush()
+		c.chunks++
+		c.key = key
+		c.chunk = c.newChunk(key, c.chunks)
+	}
+	c.rows++
+	c.runner.AddFinding(c.chunk, finding, t)
+}
+
+// flush writes the current result, it must be called after the last record
+func (c *recordChunks) flush() {
+	if c.chunk != nil && c.rows > 0 {
+		if err := c.runner.AddResult(c.chunk); err != nil {
+			c.log.Error("failed to write result", "bucket", c.chunk.Bucket, "err", err)
+		}
+	}
+	c.chunk = nil
+	c.rows = 0
+}
+
+// recordResult is the result with the n-th chunk of records of a dump file
+func recordResult(file *models.File, virtual_path string, n int) *models.File {
+	result := file.Clone()
+	result.FilePath = virtual_path
+	result.Fingerprint = tools.GetHashFromValues(file.Fingerprint, n)
+
+	return result
+}
+
+// alreadyParsed checks the control database for a successfully parsed file
+func alreadyParsed(conn *gorm.DB, file_name string, fingerprint string) bool {
+	if conn == nil {
+		return false
+	}
+
+	response := conn.Raw("SELECT count(id) as count from files WHERE failed = ? AND file_name = ? AND fingerprint = ?", false, file_name, fingerprint)
+	if response != nil {
+		var cnt int
+		_ = response.Row().Scan(&cnt)
+		return cnt > 0
+	}
+
+	return false
+}
@@ -0,0 +1,162 @@
+package parsers
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/helviojunior/intelparser/pkg/runner"
+	"github.com/spf13/pflag"
+)
+
+// sniffHeadSize is how much of a file is read to sniff its format
+const sniffHeadSize = 8 * 1024
+
+// Registration declares a parser driver, each registration becomes a parse
+// subcommand and is used by the parse auto routing
+type Registration struct {
+	// Name is the subcommand name (parse <name>)
+	Name string
+	// Short, Long (markdown) and Example are the subcommand help
+	Short   string
+	Long    string
+	Example string
+	// PathUsage is the help of the --path flag
+	PathUsage string
+	// Extensions are the file extensions parsed from folders, "" matches the files
+	// without extension and an empty list matches any file. ".zip" files are
+	// extracted before parsing.
+	Extensions []string
+	// Index is the file name of an export index (e.g. Info.csv). Folders with it
+	// are parsed as an export, see IndexedDriver.
+	Index string
+	// Flags adds the driver options to the command flags
+	Flags func(flags *pflag.FlagSet)
+	// New returns a driver instance
+	New func(logger *slog.Logger, opts runner.Options) (runner.ParserDriver, error)
+	// Sniff returns how likely the input is handled by the driver, 0 if not handled
+	Sniff func(input Input) int
+}
+
+// IndexedDriver is implemented by the drivers of exports with an index file.
+// The index is parsed first and IndexFiles returns the export files to parse.
+type IndexedDriver interface {
+	IndexFiles(index runner.FileItem) []runner.FileItem
+}
+
+// Input is a file or folder to sniff. Head is the start of a file and Entries
+// are the folder entry names, names and extensions are lowercase.
+type Input struct {
+	Path    string
+	Name    string
+	Ext     string
+	IsDir   bool
+	Head    []byte
+	Entries []string
+}
+
+var (
+	registrations      = []*Registration{}
+	registrationsMutex = sync.Mutex{}
+)
+
+// Register adds a parser driver to the registry, drivers register themselves at init
+func Register(reg *Registration) {
+	registrationsMutex.Lock()
+	defer registrationsMutex.Unlock()
+
+	registrations = append(registrations, reg)
+	sort.Slice(registrations, func(i, j int) bool { return registrations[i].Name < registrations[j].Name })
+}
+
+// Registrations returns the registered parser drivers sorted by name
+func Registrations() []*Registration {
+	registrationsMutex.Lock()
+	defer registrationsMutex.Unlock()
+
+	return append([]*Registration{}, registrations...)
+}
+
+// Lookup returns the registered parser driver with the name or nil
+func Lookup(name string) *Registration {
+	for _, reg := range Registrations() {
+		if reg.Name == name {
+			return reg
+		}
+	}
+	return nil
+}
+
+// NewInput reads the information used to sniff a file or folder
+func NewInput(path string) (Input, error) {
+	input := Input{
+		Path: path,
+		Name: strings.ToLower(filepath.Base(path)),
+		Ext:  strings.ToLower(filepath.Ext(path)),
+	}
+
+	fst, err := os.Stat(path)
+	if err != nil {
+		return input, err
+	}
+
+	if fst.IsDir() {
+		input.IsDir = true
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return input, err
+		}
+		for _, e := range entries {
+			input.Entries = append(input.Entries, strings.ToLower(e.Name()))
+		}
+		return input, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return input, err
+	}
+	defer f.Close()
+
+	input.Head = make([]byte, sniffHeadSize)
+	n, err := io.ReadFull(f, input.Head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return input, err
+	}
+	input.Head = input.Head[:n]
+
+	return input, nil
+}
+
+// HasEntry checks if the folder has one of the entries (lowercase)
+func (input Input) HasEntry(names ...string) bool {
+	for _, e := range input.Entries {
+		for _, n := range names {
+			if e == n {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Detect returns the parser driver with the highest sniff score for the input,
+// nil if none handles it
+func Detect(input Input) *Registration {
+	var best *Registration
+	bestScore := 0
+	for _, reg := range Registrations() {
+		if reg.Sniff == nil {
+			continue
+		}
+		if score := reg.Sniff(input); score > bestScore {
+			best = reg
+			bestScore = score
+		}
+	}
+	return best
+}
@@ -0,0 +1,808 @@
+package parsers
+
+import (
+	"bufio"
+	"log/slog"
+	"os"
+	"path/filepath"
+	re "regexp"
+	"strings"
+
+	"github.com/helviojunior/intelparser/internal/tools"
+	"github.com/helviojunior/intelparser/pkg/database"
+	"github.com/helviojunior/intelparser/pkg/models"
+	"github.com/helviojunior/intelparser/pkg/runner"
+	"gorm.io/gorm"
+)
+
+// sqlMaxValueSize limits the stored size of each value, bigger values (blobs) are truncated
+const sqlMaxValueSize = 64 * 1024
+
+// sqlMaxStatementSize limits the size of the statements kept in memory (CREATE TABLE, COPY header)
+const sqlMaxStatementSize = 1024 * 1024
+
+var sqlCreateTableRe = re.MustCompile(`(?is)^\s*(?:OR\s+REPLACE\s+)?(?:(?:GLOBAL|LOCAL)\s+)?(?:TEMP(?:ORARY)?\s+|UNLOGGED\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([^\s(]+)\s*\(`)
+var sqlCopyRe = re.MustCompile(`(?is)^\s*(?:ONLY\s+)?([^\s(]+)\s*(?:\(([^)]*)\))?\s+FROM\s+stdin`)
+var sqlConstraintWords = []string{"PRIMARY", "KEY", "UNIQUE", "CONSTRAINT", "INDEX", "FOREIGN", "FULLTEXT", "SPATIAL", "CHECK", "EXCLUDE", "PERIOD", "LIKE"}
+
+// sqlTable is the known (CREATE TABLE) or inferred columns of a table
+type sqlTable struct {
+	columns []string
+	active  bool
+}
+
+// sqlDumpMarkers identify SQL dumps without the .sql extension
+var sqlDumpMarkers = []string{"-- MySQL dump", "-- MariaDB dump", "-- PostgreSQL database dump", "INSERT INTO ", "CREATE TABLE ", ") FROM stdin;"}
+
+func init() {
+	Register(&Registration{
+		Name: "sql",
+		Short: "Parse MySQL/PostgreSQL SQL dumps",
+		Long: `
+Parse MySQL/PostgreSQL SQL dumps (INSERT and COPY statements).
+
+The dump is streamed, so multi-GB files are not loaded into memory. Column
+names are taken from the CREATE TABLE and INSERT statements (or inferred from
+the value shapes) and tables with user/email/password like columns are mapped
+to credentials and e-mails. The rows of each table are written as results of
+their own, with the source table at the bucket (SQL » <table>).
+`,
+		Example: `
+   - intelparser parse sql -p ~/Desktop/dump.sql
+   - intelparser parse sql -p ~/Desktop/dumps/ --mapping ~/Desktop/mapping.json
+`,
+		PathUsage: "A SQL file or a path with SQL file(s).",
+		Extensions: []string{".sql"},
+		Flags: mappingFlags,
+		New: func(logger *slog.Logger, opts runner.Options) (runner.ParserDriver, error) {
+			mapping, err := flagFieldMapping()
+			if err != nil {
+				return nil, err
+			}
+			return NewSql(logger, opts, mapping)
+		},
+		Sniff: sniffSql,
+	})
+}
+
+// SqlParser is a driver that streams MySQL/PostgreSQL dumps, tracking the
+// CREATE TABLE columns and mapping INSERT/COPY rows to credentials and e-mails
+type SqlParser struct {
+	// options for the Runner to consider
+	options runner.Options
+	// logger
+	log *slog.Logger
+	// Record field mapping
+	mapping *FieldMapping
+	//
+	conn *gorm.DB
+}
+
+// sqlScanner is a minimal streaming SQL tokenizer
+type sqlScanner struct {
+	r *bufio.Reader
+	// MySQL style backslash escapes inside strings
+	backslash bool
+}
+
+// NewSql returns a new SqlParser instance. A nil mapping uses the auto detection one
+func NewSql(logger *slog.Logger, opts runner.Options, mapping *FieldMapping) (*SqlParser, error) {
+	var conn *gorm.DB
+	var err error
+	conn, err = database.Connection(opts.Writer.GlobalDbURI, true, false)
+	if err != nil {
+		logger.Debug("Error connecting to the database", "conn", opts.Writer.GlobalDbURI, "err", err)
+		conn = nil
+	}
+
+	if mapping == nil {
+		mapping = DefaultFieldMapping()
+	}
+
+	return &SqlParser{
+		options: opts,
+		log:     logger,
+		mapping: mapping,
+		conn:    conn,
+	}, nil
+}
+
+func (run *SqlParser) ParseFile(thisRunner *runner.Runner, file runner.FileItem) (*models.File, error) {
+	logger := run.log.With("file", filepath.Base(file.RealPath))
+
+	result, err := newLocalFile(file, "SQL")
+	if err != nil {
+		return result, err
+	}
+
+	if alreadyParsed(run.conn, result.FileName, result.Fingerprint) {
+		logger.Debug("[File already parsed]")
+		return nil, nil
+	}
+
+	f, err := os.Open(file.RealPath)
+	if err != nil {
+		return result, err
+	}
+	defer f.Close()
+
+	s := &sqlScanner{
+		r: bufio.NewReaderSize(f, 1024 * 1024),
+		backslash: true,
+	}
+
+	// pg_dump strings do not use backslash escapes
+	if head, _ := s.r.Peek(4096); strings.Contains(string(head), "PostgreSQL database dump") {
+		s.backslash = false
+	}
+
+	tables := map[string]*sqlTable{}
+	records := 0
+
+	// The rows of a table are written in a result per table and every recordFlushRows
+	chunks := &recordChunks{
+		runner: thisRunner,
+		log: logger,
+		newChunk: func(table string, n int) *models.File {
+			return sqlTableResult(result, file.VirtualPath, table, n)
+		},
+	}
+
+	emit := func(table string, columns []string, row []string) {
+		record := make([]RecordField, 0, len(row) + 1)
+		for i, v := range row {
+			if i < len(columns) {
+				record = append(record, RecordField{ Name: columns[i], Value: v })
+			}
+		}
+		record = append(record, RecordField{ Name: "sql_table", Value: table })
+
+		finding, t, ok := run.mapping.Finding("SQL » Record", record)
+		if !ok {
+			return
+		}
+
+		// The original row is the context of the finding
+		if run.options.Parser.StoreNearText {
+			line := table + ": " + strings.Join(row, ", ")
+			finding.Credential.NearText = line
+			finding.Email.NearText = line
+		}
+
+		if t.IsZero() {
+			t = result.Date
+		}
+
+		records++
+		chunks.add(table, finding, t)
+	}
+
+	for {
+		if !s.skipSpace() {
+			break
+		}
+
+		c, ok := s.peek()
+		if !ok {
+			break
+		}
+		if c == ';' {
+			s.r.ReadByte()
+			continue
+		}
+
+		switch strings.ToUpper(s.word()) {
+		case "":
+			// Unexpected char, skip the statement
+			s.skipStatement()
+		case "CREATE":
+			name, columns := parseCreateTable(s.readStatement())
+			if name != "" && len(columns) > 0 {
+				tables[name] = &sqlTable{
+					columns: columns,
+					active: run.mapping.HasRoles(columns),
+				}
+				logger.Debug("SQL table", "table", name, "columns", columns, "active", tables[name].active)
+			}
+		case "INSERT", "REPLACE":
+			run.parseInsert(s, tables, emit)
+		case "COPY":
+			run.parseCopy(s, tables, emit)
+		default:
+			s.skipStatement()
+		}
+	}
+
+	chunks.flush()
+
+	logger.Debug("SQL records parsed", "records", records, "tables", len(tables), "results", chunks.chunks)
+	result.FilePath = file.VirtualPath
+
+	return result, nil
+}
+
+// sqlTableResult returns a result for the rows of a table, the n-th of the file
+func sqlTableResult(file *models.File, virtual_path string, table string, n int) *models.File {
+	result := file.Clone()
+	result.FilePath = virtual_path
+	result.Name = file.Name + " » " + table
+	result.Bucket = "SQL » " + table
+	result.ProviderId = table
+	result.Fingerprint = tools.GetHashFromValues(file.Fingerprint, table, n)
+
+	return result
+}
+
+func (run *SqlParser) Close() {
+	run.log.Debug("closing SQL parser context")
+}
+
+// parseInsert parses INSERT [IGNORE] [INTO] table [(columns)] VALUES (...),(...);
+// Tables without known columns have the columns inferred from the first rows
+func (run *SqlParser) parseInsert(s *sqlScanner, tables map[string]*sqlTable, emit func(string, []string, []string)) {
+	var name string
+	for {
+		s.skipSpace()
+		w := s.identifier()
+		switch strings.ToUpper(w) {
+		case "LOW_PRIORITY", "DELAYED", "HIGH_PRIORITY", "IGNORE", "INTO":
+			continue
+		}
+		name = sqlTableName(w)
+		break
+	}
+
+	if name == "" {
+		s.skipStatement()
+		return
+	}
+
+	var columns []string
+	s.skipSpace()
+	if c, _ := s.peek(); c == '(' {
+		s.r.ReadByte()
+		for {
+			s.skipSpace()
+			col := s.identifier()
+			if col == "" {
+				s.skipStatement()
+				return
+			}
+			columns = append(columns, col)
+			s.skipSpace()
+			c, ok := s.next()
+			if !ok || c == ')' {
+				break
+			}
+		}
+	}
+
+	s.skipSpace()
+	if w := strings.ToUpper(s.word()); w != "VALUES" && w != "VALUE" {
+		// INSERT ... SELECT or SET syntax
+		s.skipStatement()
+		return
+	}
+
+	table := tables[name]
+	if columns != nil {
+		table = &sqlTable{ columns: columns, active: run.mapping.HasRoles(columns) }
+	}
+
+	if table != nil && !table.active {
+		s.skipStatement()
+		return
+	}
+
+	// Unknown columns, infer them from the value shapes of the first rows
+	var sample [][]string
+	for {
+		s.skipSpace()
+		c, ok := s.next()
+		if !ok {
+			break
+		}
+		if c == ',' {
+			continue
+		}
+		if c != '(' {
+			// ON DUPLICATE KEY UPDATE, RETURNING...
+			if c != ';' {
+				s.skipStatement()
+			}
+			break
+		}
+
+		row, ok := s.tuple()
+		if !ok {
+			break
+		}
+
+		if table == nil {
+			sample = append(sample, row)
+			if len(sample) < csvSampleRows {
+				continue
+			}
+			table = run.inferTable(tables, name, sample)
+			if !table.active {
+				s.skipStatement()
+				return
+			}
+			for _, r := range sample {
+				emit(name, table.columns, r)
+			}
+			sample = nil
+			continue
+		}
+
+		emit(name, table.columns, row)
+	}
+
+	if table == nil && len(sample) > 0 {
+		table = run.inferTable(tables, name, sample)
+		if table.active {
+			for _, r := range sample {
+				emit(name, table.columns, r)
+			}
+		}
+	}
+}
+
+// inferTable infers and caches the table columns from the sample rows
+func (run *SqlParser) inferTable(tables map[string]*sqlTable, name string, sample [][]string) *sqlTable {
+	columns := inferHeader(run.mapping, sample)
+	table := &sqlTable{ columns: columns, active: run.mapping.HasRoles(columns) }
+	tables[name] = table
+	run.log.Debug("SQL table inferred", "table", name, "columns", columns, "active", table.active)
+	return table
+}
+
+// parseCopy parses the PostgreSQL COPY table (columns) FROM stdin; data block
+func (run *SqlParser) parseCopy(s *sqlScanner, tables map[string]*sqlTable, emit func(string, []string, []string)) {
+	m := sqlCopyRe.FindStringSubmatch(s.readStatement())
+	if m == nil {
+		return
+	}
+
+	name := sqlTableName(m[1])
+	table := tables[name]
+	if m[2] != "" {
+		columns := []string{}
+		for _, c := range strings.Split(m[2], ",") {
+			columns = append(columns, unquoteIdentifier(strings.Trim(c, " \r\n\t")))
+		}
+		table = &sqlTable{ columns: columns, active: run.mapping.HasRoles(columns) }
+	}
+
+	// Rest of the COPY statement line
+	s.r.ReadString('\n')
+
+	for {
+		line, err := s.r.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line == "\\." || (err != nil && line == "") {
+			return
+		}
+
+		if table != nil && table.active {
+			fields := strings.Split(line, "\t")
+			for i, v := range fields {
+				fields[i] = unescapeCopy(v)
+			}
+			emit(name, table.columns, fields)
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// parseCreateTable returns the table name and column names of a CREATE TABLE statement
+func parseCreateTable(stmt string) (string, []string) {
+	loc := sqlCreateTableRe.FindStringSubmatchIndex(stmt)
+	if loc == nil {
+		return "", nil
+	}
+
+	name := sqlTableName(stmt[loc[2]:loc[3]])
+	body := stmt[loc[1]:]
+
+	columns := []string{}
+	add := func(def string) {
+		if col := columnDefinitionName(def); col != "" {
+			columns = append(columns, col)
+		}
+	}
+
+	depth := 0
+	start := 0
+	var quote byte
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				add(body[start:i])
+				return name, columns
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				add(body[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return name, columns
+}
+
+// columnDefinitionName returns the column name of a definition, or empty for constraints
+func columnDefinitionName(def string) string {
+	def = strings.Trim(def, " \r\n\t")
+	if def == "" {
+		return ""
+	}
+
+	var name string
+	if def[0] == '`' || def[0] == '"' || def[0] == '[' {
+		end := strings.IndexAny(def[1:], "`\"]")
+		if end == -1 {
+			return ""
+		}
+		return def[1:end + 1]
+	}
+
+	name = strings.Fields(def)[0]
+	for _, w := range sqlConstraintWords {
+		if strings.EqualFold(name, w) {
+			return ""
+		}
+	}
+	return name
+}
+
+// sqlTableName normalizes the table name removing the quotes and the schema
+func sqlTableName(name string) string {
+	parts := strings.Split(name, ".")
+	return strings.ToLower(unquoteIdentifier(parts[len(parts) - 1]))
+}
+
+func unquoteIdentifier(name string) string {
+	return strings.Trim(name, "`\"[] \r\n\t")
+}
+
+// unescapeCopy decodes the COPY text format escapes
+func unescapeCopy(v string) string {
+	if v == "\\N" {
+		return ""
+	}
+	if !strings.Contains(v, "\\") {
+		return v
+	}
+	return strings.NewReplacer("\\\\", "\\", "\\t", "\t", "\\n", "\n", "\\r", "\r", "\\b", "\b", "\\f", "\f", "\\v", "\v").Replace(v)
+}
+
+func (s *sqlScanner) peek() (byte, bool) {
+	b, err := s.r.Peek(1)
+	if err != nil {
+		return 0, false
+	}
+	return b[0], true
+}
+
+func (s *sqlScanner) next() (byte, bool) {
+	c, err := s.r.ReadByte()
+	return c, err == nil
+}
+
+// skipSpace skips white spaces and comments. Returns false at the end of the file
+func (s *sqlScanner) skipSpace() bool {
+	for {
+		c, ok := s.peek()
+		if !ok {
+			return false
+		}
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			s.r.ReadByte()
+		case c == '#':
+			s.r.ReadString('\n')
+		case c == '-' || c == '/':
+			b, err := s.r.Peek(2)
+			if err != nil {
+				return true
+			}
+			if string(b) == "--" {
+				s.r.ReadString('\n')
+			} else if string(b) == "/*" {
+				s.r.Discard(2)
+				s.skipBlockComment()
+			} else {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+}
+
+func (s *sqlScanner) skipBlockComment() {
+	var prev byte
+	for {
+		c, ok := s.next()
+		if !ok || (prev == '*' && c == '/') {
+			return
+		}
+		prev = c
+	}
+}
+
+// word reads a bare word (letters, digits, '_' and '$')
+func (s *sqlScanner) word() string {
+	var sb strings.Builder
+	for {
+		c, ok := s.peek()
+		if !ok || !(c == '_' || c == '$' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80) {
+			return sb.String()
+		}
+		sb.WriteByte(c)
+		s.r.ReadByte()
+	}
+}
+
+// identifier reads a bare or quoted identifier, including the schema parts (schema.table)
+func (s *sqlScanner) identifier() string {
+	var sb strings.Builder
+	for {
+		c, ok := s.peek()
+		if !ok {
+			break
+		}
+
+		switch c {
+		case '`', '"', '[':
+			s.r.ReadByte()
+			end := c
+			if c == '[' {
+				end = ']'
+			}
+			for {
+				c, ok := s.next()
+				if !ok || c == end {
+					break
+				}
+				sb.WriteByte(c)
+			}
+		default:
+			w := s.word()
+			if w == "" {
+				return sb.String()
+			}
+			sb.WriteString(w)
+		}
+
+		if c, _ := s.peek(); c != '.' {
+			break
+		}
+		s.r.ReadByte()
+		sb.WriteByte('.')
+	}
+
+	return sb.String()
+}
+
+// skipStatement skips until the end of the current statement (;)
+func (s *sqlScanner) skipStatement() {
+	s.scanStatement(nil)
+}
+
+// readStatement reads until the end of the current statement (;), up to sqlMaxStatementSize bytes
+func (s *sqlScanner) readStatement() string {
+	var sb strings.Builder
+	s.scanStatement(&sb)
+	return sb.String()
+}
+
+func (s *sqlScanner) scanStatement(sb *strings.Builder) {
+	var quote byte
+	for {
+		c, ok := s.next()
+		if !ok {
+			return
+		}
+
+		if quote != 0 {
+			if c == '\\' && s.backslash && quote != '`' {
+				if sb != nil && sb.Len() < sqlMaxStatementSize {
+					sb.WriteByte(c)
+				}
+				c, ok = s.next()
+				if !ok {
+					return
+				}
+			} else if c == quote {
+				quote = 0
+			}
+		} else if c == '\'' || c == '"' || c == '`' {
+			quote = c
+		} else if c == ';' {
+			return
+		}
+
+		if sb != nil && sb.Len() < sqlMaxStatementSize {
+			sb.WriteByte(c)
+		}
+	}
+}
+
+// tuple reads the values of a row, after the opening parenthesis
+func (s *sqlScanner) tuple() ([]string, bool) {
+	row := []string{}
+	for {
+		s.skipSpace()
+		v, ok := s.value()
+		if !ok {
+			return row, false
+		}
+		row = append(row, v)
+
+		s.skipSpace()
+		c, ok := s.next()
+		if !ok {
+			return row, false
+		}
+		if c == ')' {
+			return row, true
+		}
+		if c != ',' {
+			return row, false
+		}
+	}
+}
+
+// value reads a single value: quoted string, NULL, number or expression
+func (s *sqlScanner) value() (string, bool) {
+	c, ok := s.peek()
+	if !ok {
+		return "", false
+	}
+
+	if c == '\'' || c == '"' {
+		return s.quoted(s.backslash)
+	}
+
+	// Prefixed strings: _binary'..', _utf8mb4'..', X'..', E'..', N'..'
+	if c == '_' || c == 'X' || c == 'x' || c == 'E' || c == 'e' || c == 'N' || c == 'n' || c == 'B' || c == 'b' {
+		w := s.word()
+		if strings.HasPrefix(w, "_") {
+			// Charset introducer (_binary 'x')
+			s.skipSpace()
+		}
+		if q, _ := s.peek(); q == '\'' {
+			return s.quoted(s.backslash || strings.EqualFold(w, "E"))
+		}
+		if strings.EqualFold(w, "NULL") {
+			return "", true
+		}
+		if c, _ := s.peek(); c == ',' || c == ')' {
+			return w, true
+		}
+		rest, ok := s.expression()
+		return w + rest, ok
+	}
+
+	return s.expression()
+}
+
+// quoted reads a quoted string, doubled quotes and (optionally) backslash escapes are decoded
+func (s *sqlScanner) quoted(backslash bool) (string, bool) {
+	quote, _ := s.next()
+	var sb strings.Builder
+	for {
+		c, ok := s.next()
+		if !ok {
+			return sb.String(), false
+		}
+
+		if c == '\\' && backslash {
+			c, ok = s.next()
+			if !ok {
+				return sb.String(), false
+			}
+			switch c {
+			case '0':
+				c = 0
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'Z':
+				c = 0x1a
+			}
+		} else if c == quote {
+			if n, _ := s.peek(); n == quote {
+				s.r.ReadByte()
+			} else {
+				return sb.String(), true
+			}
+		}
+
+		if sb.Len() < sqlMaxValueSize {
+			sb.WriteByte(c)
+		}
+	}
+}
+
+// expression reads an unquoted value (numbers, functions...) until the next top level ',' or ')'
+func (s *sqlScanner) expression() (string, bool) {
+	var sb strings.Builder
+	depth := 0
+	for {
+		c, ok := s.peek()
+		if !ok {
+			return sb.String(), false
+		}
+
+		switch {
+		case c == '\'' || c == '"':
+			v, ok := s.quoted(s.backslash)
+			if !ok {
+				return sb.String(), false
+			}
+			sb.WriteString(v)
+			continue
+		case c == '(':
+			depth++
+		case c == ')' || c == ',':
+			if depth == 0 {
+				return strings.Trim(sb.String(), " \r\n\t"), true
+			}
+			if c == ')' {
+				depth--
+			}
+		}
+
+		s.r.ReadByte()
+		if sb.Len() < sqlMaxValueSize {
+			sb.WriteByte(c)
+		}
+	}
+}
+
+// sniffSql handles the .sql files and the text with dump headers or statements
+func sniffSql(input Input) int {
+	if input.IsDir {
+		return 0
+	}
+
+	if input.Ext == ".sql" {
+		return 70
+	}
+
+	head := string(input.Head)
+	for _, m := range sqlDumpMarkers {
+		if strings.Contains(head, m) {
+			return 60
+		}
+	}
+
+	return 0
+}
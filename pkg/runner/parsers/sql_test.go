@@ -0,0 +1,118 @@
+package parsers
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The statement is read after the CREATE keyword
+func TestParseCreateTable(t *testing.T) {
+	tests := []struct {
+		stmt    string
+		name    string
+		columns []string
+	}{
+		{
+			" TABLE `users` (\n  `id` int(11) NOT NULL,\n  `email` varchar(255) DEFAULT ',',\n  `password` varchar(64),\n  PRIMARY KEY (`id`),\n  KEY `idx` (`email`)\n) ENGINE=InnoDB",
+			"users",
+			[]string{"id", "email", "password"},
+		},
+		{
+			" TABLE IF NOT EXISTS public.\"Accounts\" (\n    login text,\n    senha numeric(10,2),\n    CONSTRAINT pk PRIMARY KEY (login)\n);",
+			"accounts",
+			[]string{"login", "senha"},
+		},
+		{" TABLE [dbo].[t] ([user] nvarchar(50), [pass] nvarchar(50))", "t", []string{"user", "pass"}},
+		{" INDEX idx ON users (email)", "", nil},
+	}
+
+	for _, tt := range tests {
+		name, columns := parseCreateTable(tt.stmt)
+		if name != tt.name || !reflect.DeepEqual(columns, tt.columns) {
+			t.Errorf("parseCreateTable(%q) = %q, %q, want %q, %q", tt.stmt, name, columns, tt.name, tt.columns)
+		}
+	}
+}
+
+func TestSqlTableName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"users", "users"},
+		{"`Users`", "users"},
+		{"`db`.`users`", "users"},
+		{"public.\"Users\"", "users"},
+		{"[dbo].[Users]", "users"},
+	}
+
+	for _, tt := range tests {
+		if got := sqlTableName(tt.name); got != tt.want {
+			t.Errorf("sqlTableName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSqlScannerTuple(t *testing.T) {
+	tests := []struct {
+		input     string
+		backslash bool
+		want      []string
+		ok        bool
+	}{
+		{"1,'a@b.com','123')", true, []string{"1", "a@b.com", "123"}, true},
+		{"'O\\'Brien','a\\nb',NULL)", true, []string{"O'Brien", "a\nb", ""}, true},
+		{"'it''s','c:\\dir')", false, []string{"it's", "c:\\dir"}, true},
+		{"_binary 'x',X'4142',E'a\\tb')", false, []string{"x", "4142", "a\tb"}, true},
+		{"NOW(),CONCAT('a', ','),-1.5)", true, []string{"NOW()", "CONCAT(a, ,)", "-1.5"}, true},
+		{"'unterminated", true, []string{}, false},
+	}
+
+	for _, tt := range tests {
+		s := &sqlScanner{ r: bufio.NewReader(strings.NewReader(tt.input)), backslash: tt.backslash }
+		got, ok := s.tuple()
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tuple(%q) = %q, %v, want %q, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestUnescapeCopy(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"plain", "plain"},
+		{"\\N", ""},
+		{"a\\tb\\nc", "a\tb\nc"},
+		{"c:\\\\dir", "c:\\dir"},
+	}
+
+	for _, tt := range tests {
+		if got := unescapeCopy(tt.value); got != tt.want {
+			t.Errorf("unescapeCopy(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSniffSql(t *testing.T) {
+	tests := []struct {
+		name  string
+		input Input
+		want  int
+	}{
+		{"extension", Input{ Ext: ".sql" }, 70},
+		{"mysql header", Input{ Ext: ".txt", Head: []byte("-- MySQL dump 10.13\n") }, 60},
+		{"postgres copy", Input{ Ext: "", Head: []byte("COPY public.users (id, email) FROM stdin;\n") }, 60},
+		{"directory", Input{ Ext: ".sql", IsDir: true }, 0},
+		{"text", Input{ Ext: ".txt", Head: []byte("just some text\n") }, 0},
+	}
+
+	for _, tt := range tests {
+		if got := sniffSql(tt.input); got != tt.want {
+			t.Errorf("%s: sniffSql() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,509 @@
+package parsers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/helviojunior/intelparser/internal/tools"
+	"github.com/helviojunior/intelparser/pkg/database"
+	"github.com/helviojunior/intelparser/pkg/models"
+	"github.com/helviojunior/intelparser/pkg/runner"
+	"gorm.io/gorm"
+)
+
+// telegramMaxArchiveDepth limits the nested archive extraction of attachments
+const telegramMaxArchiveDepth = 3
+
+// telegramMaxArchiveSize limits the extracted size of each attachment archive
+const telegramMaxArchiveSize = 1000 * 1000000
+
+// telegramChat is the chat/channel metadata of a Telegram Desktop export
+type telegramChat struct {
+	Name string
+	Type string
+	ID   string
+}
+
+// telegramMessage is a message of a Telegram Desktop export (result.json)
+type telegramMessage struct {
+	ID           int64           `json:"id"`
+	Type         string          `json:"type"`
+	Date         string          `json:"date"`
+	DateUnixtime string          `json:"date_unixtime"`
+	From         string          `json:"from"`
+	Text         json.RawMessage `json:"text"`
+	File         string          `json:"file"`
+	FileName     string          `json:"file_name"`
+	MimeType     string          `json:"mime_type"`
+	MediaType    string          `json:"media_type"`
+}
+
+// telegramAttachment is an attached file waiting to be parsed
+type telegramAttachment struct {
+	Chat      telegramChat
+	MessageID int64
+	Date      time.Time
+	FileName  string
+	MediaType string
+	Item      runner.FileItem
+}
+
+func init() {
+	Register(&Registration{
+		Name: "telegram",
+		Short: "Parse Telegram Desktop chat/channel exports",
+		Long: `
+Parse Telegram Desktop exports in JSON format (result.json plus the
+files/ folders).
+
+The detection runs on the text of each message and on the attached
+files, attached ZIP archives are extracted recursively. The findings
+are attributed to the message date, and the channel and message id
+are stored as the bucket and provider id of the result.
+
+The path may be an export folder, a folder with several exports or a
+ZIP file of an export.
+`,
+		Example: `
+   - intelparser parse telegram -p ~/Downloads/Telegram\ Desktop/ChatExport_2025-03-01/
+   - intelparser parse telegram -p ~/Downloads/ChatExport_2025-03-01.zip
+   - intelparser parse telegram -p ~/Downloads/Telegram\ Desktop/ --write-elastic --write-elasticsearch-uri "http://127.0.0.1:9200/intelparser"
+`,
+		PathUsage: "A Telegram export folder (with result.json) or ZIP file.",
+		Extensions: []string{".zip"},
+		Index: "result.json",
+		New: func(logger *slog.Logger, opts runner.Options) (runner.ParserDriver, error) {
+			return NewTelegram(logger, opts)
+		},
+		Sniff: func(input Input) int {
+			if (input.IsDir && input.HasEntry("result.json")) || input.Name == "result.json" {
+				return 100
+			}
+			return 0
+		},
+	})
+}
+
+// TelegramParser is a driver that parses Telegram Desktop exports (result.json),
+// running the detection on the message texts and on the attached files
+type TelegramParser struct {
+	// options for the Runner to consider
+	options runner.Options
+	// logger
+	log *slog.Logger
+	//
+	conn *gorm.DB
+	// attachments found at the exports, by real path
+	attachments map[string]telegramAttachment
+	//
+	attachmentsMutex sync.Mutex
+}
+
+// NewTelegram returns a new TelegramParser instance
+func NewTelegram(logger *slog.Logger, opts runner.Options) (*TelegramParser, error) {
+	var conn *gorm.DB
+	var err error
+	conn, err = database.Connection(opts.Writer.GlobalDbURI, true, false)
+	if err != nil {
+		logger.Debug("Error connecting to the database", "conn", opts.Writer.GlobalDbURI, "err", err)
+		conn = nil
+	}
+
+	return &TelegramParser{
+		options:          opts,
+		log:              logger,
+		conn:             conn,
+		attachments:      map[string]telegramAttachment{},
+		attachmentsMutex: sync.Mutex{},
+	}, nil
+}
+
+// ParseFile parses the export index (result.json) or one of its attachments.
+// The messages of the index are written as results by themselves, so the
+// index must be parsed with Runner.ParsePositionalFile before the attachments.
+func (run *TelegramParser) ParseFile(thisRunner *runner.Runner, file runner.FileItem) (*models.File, error) {
+	logger := run.log.With("file", filepath.Base(file.RealPath))
+
+	if strings.ToLower(filepath.Base(file.RealPath)) == "result.json" {
+		return nil, run.parseExport(thisRunner, file, logger)
+	}
+
+	run.attachmentsMutex.Lock()
+	att, ok := run.attachments[filepath.Clean(file.RealPath)]
+	run.attachmentsMutex.Unlock()
+	if !ok {
+		logger.Debug("File is not referenced by a result.json, ignoring...")
+		return nil, runner.NewSkipError(runner.FailureNotIndexed, "File is not referenced by a result.json")
+	}
+
+	result := &models.File{
+		Provider: "Telegram",
+		FilePath: file.RealPath,
+		FileName: filepath.Base(file.RealPath),
+		Name: att.FileName,
+		Date: att.Date,
+		Bucket: "Telegram » " + att.Chat.Name,
+		MediaType: att.MediaType,
+		ProviderId: fmt.Sprintf("%s/%d", att.Chat.ID, att.MessageID),
+		IndexedAt: time.Now(),
+	}
+
+	fst, err := os.Stat(file.RealPath)
+	if err != nil {
+		return result, err
+	}
+	result.Size = uint(fst.Size())
+	result.Fingerprint, _ = tools.GetHashFromFile(file.RealPath)
+	result.MIMEType, _ = tools.GetMimeType(file.RealPath)
+
+	if alreadyParsed(run.conn, result.FileName, result.Fingerprint) {
+		logger.Debug("[File already parsed]")
+		return nil, nil
+	}
+
+	if result.MIMEType == "application/zip" {
+		if err := run.parseArchive(thisRunner, result, file.RealPath, 0, logger); err != nil {
+			return result, err
+		}
+	} else if err := thisRunner.DetectFile(result); err != nil {
+		return result, err
+	}
+
+	result.FilePath = file.VirtualPath
+
+	return result, nil
+}
+
+// IndexFiles returns the attached files referenced by the parsed export index.
+// They are returned only once.
+func (run *TelegramParser) IndexFiles(index runner.FileItem) []runner.FileItem {
+	run.attachmentsMutex.Lock()
+	defer run.attachmentsMutex.Unlock()
+
+	dir := filepath.Dir(filepath.Clean(index.RealPath))
+	items := []runner.FileItem{}
+	for p, att := range run.attachments {
+		if filepath.Dir(p) == dir || strings.HasPrefix(p, dir + string(os.PathSeparator)) {
+			if att.Item.RealPath != "" {
+				items = append(items, att.Item)
+				att.Item = runner.FileItem{}
+				run.attachments[p] = att
+			}
+		}
+	}
+
+	return items
+}
+
+func (run *TelegramParser) Close() {
+	run.log.Debug("closing Telegram parser context")
+}
+
+// parseExport streams the result.json, it may have a single chat (chat/channel export)
+// or the chat list of a full account export
+func (run *TelegramParser) parseExport(thisRunner *runner.Runner, file runner.FileItem, logger *slog.Logger) error {
+	f, err := os.Open(file.RealPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fingerprint, _ := tools.GetHashFromFile(file.RealPath)
+
+	dec := json.NewDecoder(bufio.NewReaderSize(f, 1024 * 1024))
+	dec.UseNumber()
+
+	messages := 0
+	err = run.readChat(dec, func(chat telegramChat, msg telegramMessage) {
+		messages++
+		run.parseMessage(thisRunner, file, fingerprint, chat, msg, logger)
+	})
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	logger.Debug("Telegram messages parsed", "messages", messages)
+	return nil
+}
+
+// readChat reads a chat object calling emit for each message. Full account exports
+// have the chats at "chats"/"left_chats" -> "list".
+func (run *TelegramParser) readChat(dec *json.Decoder, emit func(telegramChat, telegramMessage)) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	chat := telegramChat{}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case "name":
+			var name interface{}
+			if err := dec.Decode(&name); err != nil {
+				return err
+			}
+			if s, ok := name.(string); ok {
+				chat.Name = s
+			}
+		case "type":
+			if err := dec.Decode(&chat.Type); err != nil {
+				return err
+			}
+		case "id":
+			var id json.Number
+			if err := dec.Decode(&id); err != nil {
+				return err
+			}
+			chat.ID = id.String()
+		case "messages":
+			if chat.Name == "" {
+				chat.Name = chat.ID
+			}
+			if err := expectDelim(dec, '['); err != nil {
+				return err
+			}
+			for dec.More() {
+				var msg telegramMessage
+				if err := dec.Decode(&msg); err != nil {
+					return err
+				}
+				emit(chat, msg)
+			}
+			if _, err := dec.Token(); err != nil {
+				return err
+			}
+		case "chats", "left_chats":
+			if err := expectDelim(dec, '{'); err != nil {
+				return err
+			}
+			for dec.More() {
+				listKey, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				if listKey != "list" {
+					var skip json.RawMessage
+					if err := dec.Decode(&skip); err != nil {
+						return err
+					}
+					continue
+				}
+				if err := expectDelim(dec, '['); err != nil {
+					return err
+				}
+				for dec.More() {
+					if err := run.readChat(dec, emit); err != nil {
+						return err
+					}
+				}
+				if _, err := dec.Token(); err != nil {
+					return err
+				}
+			}
+			if _, err := dec.Token(); err != nil {
+				return err
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := dec.Token()
+	return err
+}
+
+// parseMessage runs the detection on the message text, writing the message as a
+// result when something is found, and registers the attached file
+func (run *TelegramParser) parseMessage(thisRunner *runner.Runner, file runner.FileItem, fingerprint string, chat telegramChat, msg telegramMessage, logger *slog.Logger) {
+	if msg.Type != "" && msg.Type != "message" {
+		return
+	}
+
+	date := time.Now()
+	if epoch, err := strconv.ParseInt(msg.DateUnixtime, 10, 64); err == nil && epoch > 0 {
+		date = time.Unix(epoch, 0).UTC()
+	} else if t, ok := tools.ParseTime(msg.Date); ok {
+		date = t
+	}
+
+	providerId := fmt.Sprintf("%s/%d", chat.ID, msg.ID)
+
+	if text := telegramText(msg.Text); strings.TrimSpace(text) != "" {
+		result := &models.File{
+			Provider: "Telegram",
+			FilePath: file.VirtualPath,
+			FileName: filepath.Base(file.RealPath),
+			Name: fmt.Sprintf("%s #%d", chat.Name, msg.ID),
+			Date: date,
+			Bucket: "Telegram » " + chat.Name,
+			MediaType: "Message",
+			ProviderId: providerId,
+			MIMEType: "text/plain",
+			Size: uint(len(text)),
+			Fingerprint: tools.GetHashFromValues(fingerprint, providerId),
+			IndexedAt: time.Now(),
+		}
+
+		// Only the messages with findings are written
+		findings := thisRunner.DetectString(text)
+		if len(findings) > 0 && !alreadyParsed(run.conn, result.FileName, result.Fingerprint) {
+			for _, finding := range findings {
+				thisRunner.AddFinding(result, finding, date)
+			}
+			if err := thisRunner.AddResult(result); err != nil {
+				logger.Error("failed to write result for message", "message_id", msg.ID, "err", err)
+			}
+		}
+	}
+
+	// Files not downloaded by the export have a placeholder text instead of the path
+	if msg.File == "" || strings.HasPrefix(msg.File, "(") {
+		return
+	}
+
+	real_path := filepath.Clean(filepath.Join(filepath.Dir(file.RealPath), filepath.FromSlash(msg.File)))
+	if !tools.FileExists(real_path) {
+		logger.Debug("Attached file not found", "message_id", msg.ID, "file", msg.File)
+		return
+	}
+
+	file_name := msg.FileName
+	if file_name == "" {
+		file_name = filepath.Base(real_path)
+	}
+	media_type := msg.MediaType
+	if media_type == "" {
+		media_type = msg.MimeType
+	}
+
+	run.attachmentsMutex.Lock()
+	run.attachments[real_path] = telegramAttachment{
+		Chat: chat,
+		MessageID: msg.ID,
+		Date: date,
+		FileName: file_name,
+		MediaType: media_type,
+		Item: runner.FileItem{
+			RealPath: real_path,
+			VirtualPath: filepath.Join(filepath.Dir(file.VirtualPath), filepath.FromSlash(msg.File)),
+		},
+	}
+	run.attachmentsMutex.Unlock()
+}
+
+// parseArchive extracts a ZIP attachment and runs the detection on its files,
+// adding the findings to the attachment result
+func (run *TelegramParser) parseArchive(thisRunner *runner.Runner, result *models.File, file_path string, depth int, logger *slog.Logger) error {
+	if depth >= telegramMaxArchiveDepth {
+		logger.Debug("Skipping archive: max depth reached", "file", filepath.Base(file_path))
+		return nil
+	}
+
+	dst, err := os.MkdirTemp("", "intelparser_telegram_")
+	if err != nil {
+		return err
+	}
+	defer tools.RemoveFolder(dst)
+
+	if err := tools.Unzip(file_path, dst, telegramMaxArchiveSize); err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(dst, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		mime, _ := tools.GetMimeType(path)
+		if mime == "application/zip" {
+			if err := run.parseArchive(thisRunner, result, path, depth + 1, logger); err != nil {
+				logger.Debug("Error parsing inner archive", "file", d.Name(), "err", err)
+			}
+			return nil
+		}
+
+		inner := &models.File{
+			FilePath: path,
+			Date: result.Date,
+		}
+		if err := thisRunner.DetectFile(inner); err != nil {
+			logger.Debug("Error parsing archive file", "file", d.Name(), "err", err)
+			return nil
+		}
+
+		result.Credentials = append(result.Credentials, inner.Credentials...)
+		result.Emails = append(result.Emails, inner.Emails...)
+		result.URLs = append(result.URLs, inner.URLs...)
+		result.Secrets = append(result.Secrets, inner.Secrets...)
+		result.PIIs = append(result.PIIs, inner.PIIs...)
+		result.Wallets = append(result.Wallets, inner.Wallets...)
+		result.Cookies = append(result.Cookies, inner.Cookies...)
+		return nil
+	})
+}
+
+// telegramText returns the message text, it is either a string or a list of
+// strings and entities ({"type": "link", "text": "..."})
+func telegramText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+
+	var parts []interface{}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, p := range parts {
+		switch v := p.(type) {
+		case string:
+			sb.WriteString(v)
+		case map[string]interface{}:
+			if t, ok := v["text"].(string); ok {
+				sb.WriteString(t)
+			}
+			// text_link entities have the url apart from the text
+			if href, ok := v["href"].(string); ok && href != "" {
+				sb.WriteString(" " + href + " ")
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+// expectDelim reads the next token checking the json delimiter
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return errors.New(fmt.Sprintf("invalid Telegram export, expected '%s'", delim))
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package parsers
+
+import (
+	"log/slog"
+	"path/filepath"
+
+	"github.com/helviojunior/intelparser/pkg/database"
+	"github.com/helviojunior/intelparser/pkg/models"
+	"github.com/helviojunior/intelparser/pkg/runner"
+	"gorm.io/gorm"
+)
+
+func init() {
+	Register(&Registration{
+		Name: "text",
+		Short: "Parse text files and documents with the detection rules",
+		Long: `
+Parse any text file (combo lists, logs, pastes...) and Office, OpenDocument
+and PDF documents using the detection rules.
+
+It is the fallback of parse auto for the files not handled by other parsers.
+`,
+		Example: `
+   - intelparser parse text -p ~/Desktop/combo.txt
+   - intelparser parse text -p ~/Desktop/pastes/
+`,
+		PathUsage: "A text file or a path with text file(s).",
+		New: func(logger *slog.Logger, opts runner.Options) (runner.ParserDriver, error) {
+			return NewText(logger, opts)
+		},
+		Sniff: func(input Input) int {
+			if input.IsDir {
+				return 0
+			}
+			return 1
+		},
+	})
+}
+
+// TextParser is a driver that runs the detection rules on text files
+type TextParser struct {
+	// options for the Runner to consider
+	options runner.Options
+	// logger
+	log *slog.Logger
+	//
+	conn *gorm.DB
+}
+
+// NewText returns a new TextParser instance
+func NewText(logger *slog.Logger, opts runner.Options) (*TextParser, error) {
+	var conn *gorm.DB
+	var err error
+	conn, err = database.Connection(opts.Writer.GlobalDbURI, true, false)
+	if err != nil {
+		logger.Debug("Error connecting to the database", "conn", opts.Writer.GlobalDbURI, "err", err)
+		conn = nil
+	}
+
+	return &TextParser{
+		options: opts,
+		log:     logger,
+		conn:    conn,
+	}, nil
+}
+
+func (run *TextParser) ParseFile(thisRunner *runner.Runner, file runner.FileItem) (*models.File, error) {
+	logger := run.log.With("file", filepath.Base(file.RealPath))
+
+	result, err := newLocalFile(file, "Text")
+	if err != nil {
+		return result, err
+	}
+
+	if alreadyParsed(run.conn, result.FileName, result.Fingerprint) {
+		logger.Debug("[File already parsed]")
+		return nil, nil
+	}
+
+	err = thisRunner.DetectFile(result)
+	result.FilePath = file.VirtualPath
+
+	return result, err
+}
+
+func (run *TextParser) Close() {
+	run.log.Debug("closing text parser context")
+}
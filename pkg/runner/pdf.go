@@ -0,0 +1,1003 @@
+package runner
+
+import (
+    "bytes"
+    "compress/flate"
+    "compress/zlib"
+    "encoding/ascii85"
+    "encoding/hex"
+    "errors"
+    "io"
+    "math"
+    "os"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "unicode/utf16"
+)
+
+// Minimal PDF reader used to extract the page text. It supports the
+// classic and compressed (object stream) file structures, Flate/ASCIIHex/ASCII85
+// filters, ToUnicode CMaps and simple font encodings. Encrypted files are not supported.
+
+type pdfName string
+type pdfString []byte
+type pdfKeyword string
+type pdfArray []interface{}
+type pdfDict map[pdfName]interface{}
+
+type pdfRef struct {
+    num int
+    gen int
+}
+
+type pdfStream struct {
+    dict pdfDict
+    raw  []byte
+}
+
+var pdfObjRegexp = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// pdfMaxDepth limits the nested form XObjects and page tree depth
+const pdfMaxDepth = 32
+
+// pdfLexer tokenizes PDF objects and content streams
+type pdfLexer struct {
+    data []byte
+    pos  int
+}
+
+func pdfIsSpace(c byte) bool {
+    return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func pdfIsDelimiter(c byte) bool {
+    return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (lex *pdfLexer) skipSpace() {
+    for lex.pos < len(lex.data) {
+        c := lex.data[lex.pos]
+        if pdfIsSpace(c) {
+            lex.pos++
+        } else if c == '%' {
+            for lex.pos < len(lex.data) && lex.data[lex.pos] != '\n' && lex.data[lex.pos] != '\r' {
+                lex.pos++
+            }
+        } else {
+            return
+        }
+    }
+}
+
+// object reads the next object, resolving the "num gen R" references
+func (lex *pdfLexer) object() (interface{}, bool) {
+    tok, ok := lex.token()
+    if !ok {
+        return nil, false
+    }
+
+    // Only the next chars are checked, the lookahead must not parse nested objects
+    isNext := func(check func(c byte) bool) bool {
+        lex.skipSpace()
+        return lex.pos < len(lex.data) && check(lex.data[lex.pos])
+    }
+
+    if n, isNum := tok.(float64); isNum && n >= 0 && n == math.Trunc(n) {
+        saved := lex.pos
+        if isNext(func(c byte) bool { return c >= '0' && c <= '9' }) {
+            gen, _ := lex.token()
+            if g, isNum := gen.(float64); isNum && g == math.Trunc(g) && isNext(func(c byte) bool { return c == 'R' }) {
+                if lex.pos + 1 == len(lex.data) || pdfIsSpace(lex.data[lex.pos + 1]) || pdfIsDelimiter(lex.data[lex.pos + 1]) {
+                    lex.pos++
+                    return pdfRef{ num: int(n), gen: int(g) }, true
+                }
+            }
+        }
+        lex.pos = saved
+    }
+
+    return tok, true
+}
+
+// token reads the next token. Dictionaries and arrays are read as a whole
+func (lex *pdfLexer) token() (interface{}, bool) {
+    lex.skipSpace()
+    if lex.pos >= len(lex.data) {
+        return nil, false
+    }
+
+    c := lex.data[lex.pos]
+    switch {
+    case c == '<' && lex.pos + 1 < len(lex.data) && lex.data[lex.pos + 1] == '<':
+        lex.pos += 2
+        dict := pdfDict{}
+        for {
+            lex.skipSpace()
+            if lex.pos >= len(lex.data) {
+                return dict, true
+            }
+            if bytes.HasPrefix(lex.data[lex.pos:], []byte(">>")) {
+                lex.pos += 2
+                return dict, true
+            }
+            key, ok := lex.token()
+            if !ok {
+                return dict, true
+            }
+            name, isName := key.(pdfName)
+            if !isName {
+                continue
+            }
+            value, ok := lex.object()
+            if !ok {
+                return dict, true
+            }
+            dict[name] = value
+        }
+
+    case c == '[':
+        lex.pos++
+        arr := pdfArray{}
+        for {
+            lex.skipSpace()
+            if lex.pos >= len(lex.data) {
+                return arr, true
+            }
+            if lex.data[lex.pos] == ']' {
+                lex.pos++
+                return arr, true
+            }
+            value, ok := lex.object()
+            if !ok {
+                return arr, true
+            }
+            arr = append(arr, value)
+        }
+
+    case c == '(':
+        return lex.literalString(), true
+
+    case c == '<':
+        lex.pos++
+        end := bytes.IndexByte(lex.data[lex.pos:], '>')
+        if end < 0 {
+            // Unterminated string
+            hexData := lex.data[lex.pos:]
+            lex.pos = len(lex.data)
+            return pdfString(pdfHexDecode(hexData)), true
+        }
+        hexData := lex.data[lex.pos:lex.pos + end]
+        lex.pos += end + 1
+        return pdfString(pdfHexDecode(hexData)), true
+
+    case c == '/':
+        lex.pos++
+        start := lex.pos
+        for lex.pos < len(lex.data) && !pdfIsSpace(lex.data[lex.pos]) && !pdfIsDelimiter(lex.data[lex.pos]) {
+            lex.pos++
+        }
+        name := string(lex.data[start:lex.pos])
+        if strings.Contains(name, "#") {
+            var sb strings.Builder
+            for i := 0; i < len(name); i++ {
+                if name[i] == '#' && i + 2 < len(name) {
+                    if b, err := hex.DecodeString(name[i + 1:i + 3]); err == nil {
+                        sb.WriteByte(b[0])
+                        i += 2
+                        continue
+                    }
+                }
+                sb.WriteByte(name[i])
+            }
+            name = sb.String()
+        }
+        return pdfName(name), true
+
+    case (c >= '0' && c <= '9') || c == '-' || c == '+' || c == '.':
+        start := lex.pos
+        lex.pos++
+        for lex.pos < len(lex.data) && ((lex.data[lex.pos] >= '0' && lex.data[lex.pos] <= '9') || lex.data[lex.pos] == '.') {
+            lex.pos++
+        }
+        n, err := strconv.ParseFloat(string(lex.data[start:lex.pos]), 64)
+        if err != nil {
+            n = 0
+        }
+        return n, true
+
+    case pdfIsDelimiter(c):
+        // Unbalanced delimiter, return it as a keyword to keep the progress
+        lex.pos++
+        return pdfKeyword(string(c)), true
+
+    default:
+        start := lex.pos
+        for lex.pos < len(lex.data) && !pdfIsSpace(lex.data[lex.pos]) && !pdfIsDelimiter(lex.data[lex.pos]) {
+            lex.pos++
+        }
+        return pdfKeyword(string(lex.data[start:lex.pos])), true
+    }
+}
+
+func (lex *pdfLexer) literalString() pdfString {
+    lex.pos++ // (
+    var out []byte
+    depth := 1
+    for lex.pos < len(lex.data) {
+        c := lex.data[lex.pos]
+        lex.pos++
+        switch c {
+        case '(':
+            depth++
+        case ')':
+            depth--
+            if depth == 0 {
+                return pdfString(out)
+            }
+        case '\\':
+            if lex.pos >= len(lex.data) {
+                return pdfString(out)
+            }
+            e := lex.data[lex.pos]
+            lex.pos++
+            switch e {
+            case 'n':
+                c = '\n'
+            case 'r':
+                c = '\r'
+            case 't':
+                c = '\t'
+            case 'b':
+                c = '\b'
+            case 'f':
+                c = '\f'
+            case '\r':
+                // line continuation
+                if lex.pos < len(lex.data) && lex.data[lex.pos] == '\n' {
+                    lex.pos++
+                }
+                continue
+            case '\n':
+                continue
+            default:
+                if e >= '0' && e <= '7' {
+                    v := int(e - '0')
+                    for i := 0; i < 2 && lex.pos < len(lex.data) && lex.data[lex.pos] >= '0' && lex.data[lex.pos] <= '7'; i++ {
+                        v = v * 8 + int(lex.data[lex.pos] - '0')
+                        lex.pos++
+                    }
+                    c = byte(v)
+                } else {
+                    c = e
+                }
+            }
+        }
+        out = append(out, c)
+    }
+    return pdfString(out)
+}
+
+// skipInlineImage moves the lexer after the inline image data (ID ... EI)
+func (lex *pdfLexer) skipInlineImage() {
+    lex.pos++ // single white space after ID
+    for lex.pos + 2 <= len(lex.data) {
+        i := bytes.Index(lex.data[lex.pos:], []byte("EI"))
+        if i < 0 {
+            lex.pos = len(lex.data)
+            return
+        }
+        lex.pos += i + 2
+        if pdfIsSpace(lex.data[lex.pos - 3]) && (lex.pos >= len(lex.data) || pdfIsSpace(lex.data[lex.pos]) || pdfIsDelimiter(lex.data[lex.pos])) {
+            return
+        }
+    }
+}
+
+func pdfHexDecode(data []byte) []byte {
+    clean := make([]byte, 0, len(data) + 1)
+    for _, c := range data {
+        if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+            clean = append(clean, c)
+        }
+    }
+    if len(clean) % 2 == 1 {
+        clean = append(clean, '0')
+    }
+    out := make([]byte, len(clean) / 2)
+    _, _ = hex.Decode(out, clean)
+    return out
+}
+
+// pdfInt returns the value as a non negative integer, crafted files may have
+// negative, fractional or huge lengths and offsets
+func pdfInt(obj interface{}) (int, bool) {
+    n, ok := obj.(float64)
+    if !ok || n < 0 || n > math.MaxInt32 || n != math.Trunc(n) {
+        return 0, false
+    }
+    return int(n), true
+}
+
+// pdfDocument holds the objects of a PDF file
+type pdfDocument struct {
+    objects  map[int]interface{}
+    fonts    map[int]*pdfFont
+    maxBytes int64
+}
+
+// pdf writes the text of the PDF pages
+func (ex *documentTextExtractor) pdf(file_path string) error {
+    data, err := os.ReadFile(file_path)
+    if err != nil {
+        return err
+    }
+
+    doc := &pdfDocument{
+        objects:  map[int]interface{}{},
+        fonts:    map[int]*pdfFont{},
+        maxBytes: ex.maxBytes,
+    }
+    if doc.maxBytes <= 0 {
+        doc.maxBytes = documentMaxBytes
+    }
+    doc.load(data)
+
+    for _, obj := range doc.objects {
+        if d := doc.dict(obj); d != nil && d["Encrypt"] != nil {
+            return errors.New("encrypted PDF files are not supported")
+        }
+    }
+    if bytes.Contains(data, []byte("/Encrypt")) && bytes.Contains(data, []byte("trailer")) {
+        lex := &pdfLexer{ data: data, pos: bytes.LastIndex(data, []byte("trailer")) + 7 }
+        if t, ok := lex.object(); ok {
+            if d, ok := t.(pdfDict); ok && d["Encrypt"] != nil {
+                return errors.New("encrypted PDF files are not supported")
+            }
+        }
+    }
+
+    for _, page := range doc.pages() {
+        if ex.full() {
+            break
+        }
+        resources := doc.dict(page["Resources"])
+        var content []byte
+        switch c := doc.resolve(page["Contents"]).(type) {
+        case *pdfStream:
+            content = doc.decodeStream(c)
+        case pdfArray:
+            for _, part := range c {
+                if s, ok := doc.resolve(part).(*pdfStream); ok {
+                    content = append(content, doc.decodeStream(s)...)
+                    content = append(content, '\n')
+                }
+            }
+        }
+        doc.runContent(ex, content, resources, 0)
+        ex.newLine()
+    }
+
+    return nil
+}
+
+// load reads the objects in the file order, so incremental updates replace
+// the previous object versions, and the objects stored in object streams
+func (doc *pdfDocument) load(data []byte) {
+    end := 0
+    for _, m := range pdfObjRegexp.FindAllSubmatchIndex(data, -1) {
+        if m[0] < end {
+            // match inside a previous stream
+            continue
+        }
+        num, err := strconv.Atoi(string(data[m[2]:m[3]]))
+        if err != nil {
+            continue
+        }
+
+        lex := &pdfLexer{ data: data, pos: m[1] }
+        obj, ok := lex.object()
+        if !ok {
+            continue
+        }
+
+        lex.skipSpace()
+        if dict, isDict := obj.(pdfDict); isDict && bytes.HasPrefix(data[lex.pos:], []byte("stream")) {
+            start := lex.pos + 6
+            if start < len(data) && data[start] == '\r' {
+                start++
+            }
+            if start < len(data) && data[start] == '\n' {
+                start++
+            }
+
+            stop := -1
+            if l, ok := pdfInt(dict["Length"]); ok && l <= len(data) - start {
+                after := &pdfLexer{ data: data, pos: start + l }
+                after.skipSpace()
+                if bytes.HasPrefix(data[after.pos:], []byte("endstream")) {
+                    stop = start + l
+                }
+            }
+            if stop < 0 {
+                i := bytes.Index(data[start:], []byte("endstream"))
+                if i < 0 {
+                    i = len(data) - start
+                }
+                stop = start + i
+            }
+
+            obj = &pdfStream{ dict: dict, raw: data[start:stop] }
+            lex.pos = stop
+        }
+
+        doc.objects[num] = obj
+        end = lex.pos
+    }
+
+    for _, obj := range doc.objects {
+        s, ok := obj.(*pdfStream)
+        if !ok || s.dict["Type"] != pdfName("ObjStm") {
+            continue
+        }
+        n, okN := pdfInt(s.dict["N"])
+        first, okFirst := pdfInt(s.dict["First"])
+        if !okN || !okFirst {
+            continue
+        }
+        decoded := doc.decodeStream(s)
+        if first > len(decoded) {
+            continue
+        }
+
+        header := &pdfLexer{ data: decoded[:first] }
+        for i := 0; i < n; i++ {
+            num, ok1 := header.token()
+            offset, ok2 := header.token()
+            if !ok1 || !ok2 {
+                break
+            }
+            objNum, isNum := pdfInt(num)
+            off, isOff := pdfInt(offset)
+            if !isNum || !isOff || off >= len(decoded) - first {
+                continue
+            }
+            if _, exists := doc.objects[objNum]; exists {
+                continue
+            }
+            lex := &pdfLexer{ data: decoded, pos: first + off }
+            if o, ok := lex.object(); ok {
+                doc.objects[objNum] = o
+            }
+        }
+    }
+}
+
+func (doc *pdfDocument) resolve(obj interface{}) interface{} {
+    for i := 0; i < pdfMaxDepth; i++ {
+        ref, ok := obj.(pdfRef)
+        if !ok {
+            return obj
+        }
+        obj = doc.objects[ref.num]
+    }
+    return nil
+}
+
+// dict returns the dictionary of the object (or of the stream)
+func (doc *pdfDocument) dict(obj interface{}) pdfDict {
+    switch o := doc.resolve(obj).(type) {
+    case pdfDict:
+        return o
+    case *pdfStream:
+        return o.dict
+    }
+    return nil
+}
+
+func (doc *pdfDocument) decodeStream(s *pdfStream) []byte {
+    var filters []interface{}
+    switch f := doc.resolve(s.dict["Filter"]).(type) {
+    case pdfName:
+        filters = append(filters, f)
+    case pdfArray:
+        filters = f
+    }
+
+    data := s.raw
+    for _, f := range filters {
+        switch doc.resolve(f) {
+        case pdfName("FlateDecode"), pdfName("Fl"):
+            var r io.Reader
+            if zr, err := zlib.NewReader(bytes.NewReader(data)); err == nil {
+                r = zr
+            } else {
+                r = flate.NewReader(bytes.NewReader(data))
+            }
+            // Keep the data inflated before any corruption
+            out, _ := io.ReadAll(io.LimitReader(r, doc.maxBytes))
+            data = out
+        case pdfName("ASCIIHexDecode"), pdfName("AHx"):
+            if i := bytes.IndexByte(data, '>'); i >= 0 {
+                data = data[:i]
+            }
+            data = pdfHexDecode(data)
+        case pdfName("ASCII85Decode"), pdfName("A85"):
+            d := bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+            if i := bytes.Index(d, []byte("~>")); i >= 0 {
+                d = d[:i]
+            }
+            out := make([]byte, len(d) * 4 / 5 + 4)
+            n, _, err := ascii85.Decode(out, d, true)
+            if err != nil {
+                return nil
+            }
+            data = out[:n]
+        default:
+            // Image and other filters have no text
+            return nil
+        }
+    }
+
+    return data
+}
+
+// pages returns the page dictionaries in the page tree order,
+// with the inherited resources set
+func (doc *pdfDocument) pages() []pdfDict {
+    pages := []pdfDict{}
+    visited := map[int]bool{}
+
+    var walk func(node interface{}, resources interface{}, depth int)
+    walk = func(node interface{}, resources interface{}, depth int) {
+        if depth > pdfMaxDepth {
+            return
+        }
+        if ref, ok := node.(pdfRef); ok {
+            if visited[ref.num] {
+                return
+            }
+            visited[ref.num] = true
+        }
+
+        d := doc.dict(node)
+        if d == nil {
+            return
+        }
+        if d["Resources"] != nil {
+            resources = d["Resources"]
+        }
+
+        if kids, ok := doc.resolve(d["Kids"]).(pdfArray); ok {
+            for _, k := range kids {
+                walk(k, resources, depth + 1)
+            }
+            return
+        }
+
+        if d["Type"] == pdfName("Page") || d["Contents"] != nil {
+            page := pdfDict{}
+            for k, v := range d {
+                page[k] = v
+            }
+            page["Resources"] = resources
+            pages = append(pages, page)
+        }
+    }
+
+    nums := make([]int, 0, len(doc.objects))
+    for n := range doc.objects {
+        nums = append(nums, n)
+    }
+    sort.Ints(nums)
+
+    for _, n := range nums {
+        if d := doc.dict(doc.objects[n]); d != nil && d["Type"] == pdfName("Catalog") {
+            walk(d["Pages"], nil, 0)
+        }
+    }
+
+    // Broken page tree, use the page objects
+    if len(pages) == 0 {
+        for _, n := range nums {
+            if d := doc.dict(doc.objects[n]); d != nil && d["Type"] == pdfName("Page") {
+                walk(pdfRef{ num: n }, nil, 0)
+            }
+        }
+    }
+
+    return pages
+}
+
+// runContent interprets the text operators of a content stream
+func (doc *pdfDocument) runContent(ex *documentTextExtractor, content []byte, resources pdfDict, depth int) {
+    if depth > pdfMaxDepth || len(content) == 0 {
+        return
+    }
+
+    var (
+        lex      = &pdfLexer{ data: content }
+        operands = []interface{}{}
+        font     *pdfFont
+        y, lastY float64
+        moved    bool
+        newLine  bool
+        hasText  bool
+    )
+
+    show := func(s pdfString) {
+        if font == nil {
+            font = &pdfFont{}
+        }
+        text := font.decode(s)
+        if text == "" {
+            return
+        }
+        if hasText && (newLine || math.Abs(y - lastY) > 1) {
+            ex.newLine()
+        } else if hasText && moved {
+            if b := ex.text.String(); len(b) > 0 && !pdfIsSpace(b[len(b) - 1]) && !strings.HasPrefix(text, " ") {
+                ex.write(" ")
+            }
+        }
+        ex.write(text)
+        lastY = y
+        moved = false
+        newLine = false
+        hasText = true
+    }
+
+    number := func(i int) float64 {
+        if i >= 0 && i < len(operands) {
+            if n, ok := operands[i].(float64); ok {
+                return n
+            }
+        }
+        return 0
+    }
+
+    last := func() pdfString {
+        if len(operands) > 0 {
+            if s, ok := operands[len(operands) - 1].(pdfString); ok {
+                return s
+            }
+        }
+        return nil
+    }
+
+    for !ex.full() {
+        tok, ok := lex.object()
+        if !ok {
+            break
+        }
+
+        op, isOp := tok.(pdfKeyword)
+        if !isOp {
+            if len(operands) < 64 {
+                operands = append(operands, tok)
+            }
+            continue
+        }
+
+        switch op {
+        case "BT":
+            y = 0
+            moved = true
+        case "Tf":
+            if len(operands) > 0 {
+                if name, ok := operands[0].(pdfName); ok {
+                    font = doc.font(resources, name)
+                }
+            }
+        case "Td", "TD":
+            y += number(1)
+            moved = true
+        case "Tm":
+            y = number(5)
+            moved = true
+        case "T*":
+            newLine = true
+        case "Tj":
+            show(last())
+        case "'", "\"":
+            newLine = true
+            show(last())
+        case "TJ":
+            if len(operands) > 0 {
+                if arr, ok := operands[len(operands) - 1].(pdfArray); ok {
+                    for _, item := range arr {
+                        switch v := item.(type) {
+                        case pdfString:
+                            show(v)
+                        case float64:
+                            // large negative adjustments are word spaces
+                            if v < -150 {
+                                moved = true
+                            }
+                        }
+                    }
+                }
+            }
+        case "Do":
+            if len(operands) > 0 {
+                if name, ok := operands[0].(pdfName); ok {
+                    xobjects := doc.dict(resources["XObject"])
+                    if s, ok := doc.resolve(xobjects[name]).(*pdfStream); ok && s.dict["Subtype"] == pdfName("Form") {
+                        formResources := doc.dict(s.dict["Resources"])
+                        if formResources == nil {
+                            formResources = resources
+                        }
+                        ex.newLine()
+                        doc.runContent(ex, doc.decodeStream(s), formResources, depth + 1)
+                        newLine = true
+                    }
+                }
+            }
+        case "ID":
+            lex.skipInlineImage()
+        }
+
+        operands = operands[:0]
+    }
+}
+
+// pdfFont converts the shown strings to text
+type pdfFont struct {
+    toUnicode   *pdfCMap
+    twoBytes    bool
+    differences map[byte]string
+}
+
+func (doc *pdfDocument) font(resources pdfDict, name pdfName) *pdfFont {
+    fonts := doc.dict(resources["Font"])
+    if fonts == nil {
+        return &pdfFont{}
+    }
+
+    ref, isRef := fonts[name].(pdfRef)
+    if isRef {
+        if f, ok := doc.fonts[ref.num]; ok {
+            return f
+        }
+    }
+
+    font := &pdfFont{}
+    if d := doc.dict(fonts[name]); d != nil {
+        if s, ok := doc.resolve(d["ToUnicode"]).(*pdfStream); ok {
+            font.toUnicode = parsePdfCMap(doc.decodeStream(s))
+        }
+        font.twoBytes = d["Subtype"] == pdfName("Type0")
+
+        if enc := doc.dict(d["Encoding"]); enc != nil {
+            if diffs, ok := doc.resolve(enc["Differences"]).(pdfArray); ok {
+                font.differences = map[byte]string{}
+                code := 0
+                for _, item := range diffs {
+                    switch v := doc.resolve(item).(type) {
+                    case float64:
+                        code = int(v)
+                    case pdfName:
+                        if code >= 0 && code < 256 {
+                            font.differences[byte(code)] = pdfGlyphText(string(v))
+                        }
+                        code++
+                    }
+                }
+            }
+        }
+    }
+
+    if isRef {
+        doc.fonts[ref.num] = font
+    }
+    return font
+}
+
+func (f *pdfFont) decode(s pdfString) string {
+    if f.toUnicode != nil {
+        return f.toUnicode.decode(s)
+    }
+
+    // CID fonts without ToUnicode have no text mapping
+    if f.twoBytes {
+        return ""
+    }
+
+    var sb strings.Builder
+    for _, b := range s {
+        if t, ok := f.differences[b]; ok {
+            sb.WriteString(t)
+        } else if b >= 0x20 && b != 0x7f && (b < 0x80 || b >= 0xa0) {
+            sb.WriteRune(rune(b))
+        } else if b == '\t' || b == '\n' || b == '\r' {
+            sb.WriteByte(' ')
+        }
+    }
+    return sb.String()
+}
+
+// pdfGlyphNames are the common glyph names of the ASCII chars without a single char name
+var pdfGlyphNames = map[string]string{
+    "space": " ", "exclam": "!", "quotedbl": "\"", "numbersign": "#", "dollar": "$", "percent": "%",
+    "ampersand": "&", "quotesingle": "'", "quoteright": "'", "parenleft": "(", "parenright": ")",
+    "asterisk": "*", "plus": "+", "comma": ",", "hyphen": "-", "minus": "-", "period": ".", "slash": "/",
+    "zero": "0", "one": "1", "two": "2", "three": "3", "four": "4", "five": "5", "six": "6",
+    "seven": "7", "eight": "8", "nine": "9", "colon": ":", "semicolon": ";", "less": "<", "equal": "=",
+    "greater": ">", "question": "?", "at": "@", "bracketleft": "[", "backslash": "\\",
+    "bracketright": "]", "asciicircum": "^", "underscore": "_", "grave": "`", "quoteleft": "`",
+    "braceleft": "{", "bar": "|", "braceright": "}", "asciitilde": "~",
+}
+
+func pdfGlyphText(name string) string {
+    if len(name) == 1 {
+        return name
+    }
+    if t, ok := pdfGlyphNames[name]; ok {
+        return t
+    }
+    for _, prefix := range []string{"uni", "u"} {
+        if strings.HasPrefix(name, prefix) && len(name) >= len(prefix) + 4 {
+            if v, err := strconv.ParseUint(name[len(prefix):len(prefix) + 4], 16, 32); err == nil {
+                return string(rune(v))
+            }
+        }
+    }
+    return ""
+}
+
+// pdfCMap is a ToUnicode CMap
+type pdfCMap struct {
+    codespaces []pdfCodespace
+    chars      map[uint32]string
+    ranges     []pdfCMapRange
+}
+
+type pdfCodespace struct {
+    size   int
+    lo, hi uint32
+}
+
+type pdfCMapRange struct {
+    lo, hi uint32
+    start  []rune
+    values []string
+}
+
+func pdfCode(b []byte) uint32 {
+    var v uint32
+    for _, c := range b {
+        v = v << 8 | uint32(c)
+    }
+    return v
+}
+
+func pdfUTF16(b []byte) string {
+    if len(b) % 2 == 1 {
+        return string(b)
+    }
+    u := make([]uint16, len(b) / 2)
+    for i := range u {
+        u[i] = uint16(b[2 * i]) << 8 | uint16(b[2 * i + 1])
+    }
+    return string(utf16.Decode(u))
+}
+
+func parsePdfCMap(data []byte) *pdfCMap {
+    cmap := &pdfCMap{ chars: map[uint32]string{} }
+    lex := &pdfLexer{ data: data }
+    operands := []interface{}{}
+
+    for {
+        tok, ok := lex.token()
+        if !ok {
+            break
+        }
+        op, isOp := tok.(pdfKeyword)
+        if !isOp {
+            operands = append(operands, tok)
+            continue
+        }
+
+        switch op {
+        case "endcodespacerange":
+            for i := 0; i + 1 < len(operands); i += 2 {
+                lo, ok1 := operands[i].(pdfString)
+                hi, ok2 := operands[i + 1].(pdfString)
+                if ok1 && ok2 && len(lo) > 0 && len(lo) <= 4 {
+                    cmap.codespaces = append(cmap.codespaces, pdfCodespace{ size: len(lo), lo: pdfCode(lo), hi: pdfCode(hi) })
+                }
+            }
+        case "endbfchar":
+            for i := 0; i + 1 < len(operands); i += 2 {
+                src, ok1 := operands[i].(pdfString)
+                dst, ok2 := operands[i + 1].(pdfString)
+                if ok1 && ok2 {
+                    cmap.chars[pdfCode(src)] = pdfUTF16(dst)
+                }
+                if ok1 && len(cmap.codespaces) == 0 {
+                    cmap.codespaces = append(cmap.codespaces, pdfCodespace{ size: len(src), lo: 0, hi: math.MaxUint32 >> (32 - 8 * min(len(src), 4)) })
+                }
+            }
+        case "endbfrange":
+            for i := 0; i + 2 < len(operands); i += 3 {
+                lo, ok1 := operands[i].(pdfString)
+                hi, ok2 := operands[i + 1].(pdfString)
+                if !ok1 || !ok2 {
+                    continue
+                }
+                r := pdfCMapRange{ lo: pdfCode(lo), hi: pdfCode(hi) }
+                switch dst := operands[i + 2].(type) {
+                case pdfString:
+                    r.start = []rune(pdfUTF16(dst))
+                case pdfArray:
+                    for _, v := range dst {
+                        s, _ := v.(pdfString)
+                        r.values = append(r.values, pdfUTF16(s))
+                    }
+                }
+                if r.hi >= r.lo && (len(r.start) > 0 || len(r.values) > 0) {
+                    cmap.ranges = append(cmap.ranges, r)
+                }
+                if len(cmap.codespaces) == 0 {
+                    cmap.codespaces = append(cmap.codespaces, pdfCodespace{ size: len(lo), lo: 0, hi: math.MaxUint32 >> (32 - 8 * min(len(lo), 4)) })
+                }
+            }
+        }
+
+        operands = operands[:0]
+    }
+
+    sort.Slice(cmap.codespaces, func(i, j int) bool { return cmap.codespaces[i].size < cmap.codespaces[j].size })
+    return cmap
+}
+
+func (cmap *pdfCMap) lookup(code uint32) string {
+    if t, ok := cmap.chars[code]; ok {
+        return t
+    }
+    for _, r := range cmap.ranges {
+        if code < r.lo || code > r.hi {
+            continue
+        }
+        if r.values != nil {
+            if int(code - r.lo) < len(r.values) {
+                return r.values[code - r.lo]
+            }
+            return ""
+        }
+        runes := append([]rune{}, r.start...)
+        runes[len(runes) - 1] += rune(code - r.lo)
+        return string(runes)
+    }
+    return ""
+}
+
+func (cmap *pdfCMap) decode(s pdfString) string {
+    var sb strings.Builder
+    for pos := 0; pos < len(s); {
+        size := 0
+        for _, cs := range cmap.codespaces {
+            if pos + cs.size <= len(s) {
+                code := pdfCode(s[pos:pos + cs.size])
+                if code >= cs.lo && code <= cs.hi {
+                    size = cs.size
+                    break
+                }
+            }
+        }
+        if size == 0 {
+            size = 1
+            if len(cmap.codespaces) > 0 {
+                size = cmap.codespaces[0].size
+            }
+            if pos + size > len(s) {
+                break
+            }
+        }
+
+        sb.WriteString(cmap.lookup(pdfCode(s[pos:pos + size])))
+        pos += size
+    }
+    return sb.String()
+}
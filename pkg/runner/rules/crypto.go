@@ -0,0 +1,133 @@
+package rules
+
+import (
+    re "regexp"
+    "time"
+    "strings"
+    "errors"
+
+    "github.com/helviojunior/intelparser/internal/tools"
+    "github.com/helviojunior/intelparser/pkg/models"
+)
+
+var mnemonicSplitRe = re.MustCompile(`[ \t]+`)
+
+// Crypto returns the optional crypto wallet and seed phrase ruleset
+func Crypto() []*Rule {
+    return []*Rule{
+        BitcoinAddress(),
+        BitcoinWIF(),
+        EthereumAddress(),
+        MoneroAddress(),
+        Bip39Mnemonic(),
+    }
+}
+
+func BitcoinAddress() *Rule {
+    return walletRule(&Rule{
+        RuleID:      "Crypto » BTC Address",
+        Description: "Extract Bitcoin addresses",
+        Regex:       re.MustCompile(`\b([13][a-km-zA-HJ-NP-Z1-9]{25,34}|(?:bc1|BC1)[a-zA-HJ-NP-Z0-9]{25,87})\b`),
+        Entropy:     3,
+    }, "btc-address", 40, func(value string) (string, string, bool, error) {
+        if ok, kind := tools.ValidateBitcoinAddress(value); ok {
+            return value, kind, true, nil
+        }
+        return "", "", false, errors.New("Invalid BTC address")
+    })
+}
+
+func BitcoinWIF() *Rule {
+    return walletRule(&Rule{
+        RuleID:      "Crypto » BTC Private Key",
+        Description: "Extract Bitcoin WIF private keys",
+        Regex:       re.MustCompile(`\b([5KL][1-9A-HJ-NP-Za-km-z]{50,51})\b`),
+        Entropy:     3,
+    }, "btc-wif", 100, func(value string) (string, string, bool, error) {
+        if ok, kind := tools.ValidateBitcoinWIF(value); ok {
+            return value, kind, true, nil
+        }
+        return "", "", false, errors.New("Invalid WIF key")
+    })
+}
+
+func EthereumAddress() *Rule {
+    return walletRule(&Rule{
+        RuleID:      "Crypto » ETH Address",
+        Description: "Extract Ethereum addresses",
+        Regex:       re.MustCompile(`\b(0x[a-fA-F0-9]{40})\b`),
+        Entropy:     2,
+        Keywords:    []string{"0x"},
+    }, "eth-address", 40, func(value string) (string, string, bool, error) {
+        ok, checksummed := tools.ValidateEthereumAddress(value)
+        if !ok {
+            return "", "", false, errors.New("Invalid ETH address")
+        }
+        if checksummed {
+            return value, "eip-55", true, nil
+        }
+        return value, "", false, nil
+    })
+}
+
+func MoneroAddress() *Rule {
+    return walletRule(&Rule{
+        RuleID:      "Crypto » XMR Address",
+        Description: "Extract Monero addresses",
+        Regex:       re.MustCompile(`\b([48][1-9A-HJ-NP-Za-km-z]{94}(?:[1-9A-HJ-NP-Za-km-z]{11})?)\b`),
+        Entropy:     3,
+    }, "xmr-address", 40, func(value string) (string, string, bool, error) {
+        if ok, kind := tools.ValidateMoneroAddress(value); ok {
+            return value, kind, true, nil
+        }
+        return "", "", false, errors.New("Invalid XMR address")
+    })
+}
+
+func Bip39Mnemonic() *Rule {
+    return walletRule(&Rule{
+        RuleID:      "Crypto » BIP-39 Seed Phrase",
+        Description: "Extract 12 to 24 words BIP-39 mnemonic phrases",
+        Regex:       re.MustCompile(`\b((?:[a-z]{3,8}[ \t]+){11,23}[a-z]{3,8})\b`),
+    }, "bip39-mnemonic", 100, func(value string) (string, string, bool, error) {
+        words := mnemonicSplitRe.Split(strings.ToLower(value), -1)
+
+        // Look for the longest valid phrase inside the matched words
+        for size := 24; size >= 12; size -= 3 {
+            for start := 0; start + size <= len(words); start++ {
+                if tools.ValidateBip39Mnemonic(words[start:start + size]) {
+                    return strings.Join(words[start:start + size], " "), "english", true, nil
+                }
+            }
+        }
+
+        return "", "", false, errors.New("Invalid BIP-39 mnemonic")
+    })
+}
+
+// walletRule fills the common wallet rule fields and post processor.
+// validator receives the matched value and returns the normalized value,
+// a detail (address kind, checksum type...) and whether the checksum was validated.
+// Returning an error discards the finding.
+func walletRule(r *Rule, walletType string, severity int, validator func(string) (string, string, bool, error)) *Rule {
+    r.CheckGlobalStopWord = false
+    r.PostProcessor = func(finding *models.Finding) (bool, error) {
+        value, detail, validated, err := validator(strings.Trim(finding.Secret, "\r\n\t "))
+        if err != nil {
+            return false, err
+        }
+
+        finding.WalletData = models.Wallet{
+            Time        : time.Now(),
+            Rule        : finding.RuleID,
+            Type        : walletType,
+            Value       : value,
+            Detail      : detail,
+            Validated   : validated,
+            Severity    : severity,
+        }
+        return true, nil
+    }
+
+    return r
+}
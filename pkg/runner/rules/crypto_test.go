@@ -0,0 +1,44 @@
+package rules
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestBip39Mnemonic(t *testing.T) {
+    phrase := strings.Repeat("abandon ", 11) + "about"
+    tests := []struct {
+        name string
+        text string
+        want []string
+    }{
+        {"phrase alone", phrase, []string{phrase}},
+        {"phrase inside a sentence", "my seed is " + phrase + " keep it safe", []string{phrase}},
+        {"invalid checksum", strings.Repeat("abandon ", 12), []string{}},
+        {"plain text", "the quick brown fox jumps over the lazy dog and then runs away from the farm", []string{}},
+    }
+
+    r := Bip39Mnemonic()
+    for _, tt := range tests {
+        got := []string{}
+        for _, f := range detect(r, tt.text) {
+            got = append(got, f.WalletData.Value)
+        }
+        if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+            t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestEthereumAddressChecksum(t *testing.T) {
+    r := EthereumAddress()
+
+    findings := detect(r, "wallet 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+    if len(findings) != 1 || !findings[0].WalletData.Validated || findings[0].WalletData.Detail != "eip-55" {
+        t.Errorf("checksummed address: got %+v", findings)
+    }
+
+    if findings := detect(r, "wallet 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"); len(findings) != 0 {
+        t.Errorf("invalid EIP-55 checksum accepted: %+v", findings)
+    }
+}
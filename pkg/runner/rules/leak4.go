@@ -0,0 +1,147 @@
+package rules
+
+import (
+    re "regexp"
+    "time"
+    "strings"
+    "errors"
+
+    "github.com/helviojunior/intelparser/internal/tools"
+    "github.com/helviojunior/intelparser/pkg/models"
+)
+
+// Minimum number of non empty lines to consider a fragment as a combo list
+const comboMinLines = 5
+
+// Minimum ratio of lines sharing the same user<sep>pass shape
+const comboMinRatio = 0.6
+
+// Longest line accepted as a combo list entry
+const comboMaxLineSize = 256
+
+var comboSeparators = []byte{':', ';', '|', '\t'}
+var comboUserRe = re.MustCompile(`^[a-zA-Z0-9._\\-]{2,64}$`)
+// Column names, a line with them as user or password is the header row
+var comboIgnoredUsers = []string{
+    "http", "https", "ftp", "host", "url", "user", "username", "login",
+    "email", "pass", "password", "senha", "usuario",
+}
+
+func Leak4() *Rule {
+    var iRe = re.MustCompile(`(?m)^[ \t]*([a-zA-Z0-9._\\-]{2,64})([:;|\t])([^\s]{3,128})[ \t\r]*$`)
+
+    // define rule
+    r := &Rule{
+        RuleID:      "Leak4 » User:Pass",
+        Description: "Extract User:Pass leaks from combo lists",
+        Regex:       iRe,
+        Entropy:     0.91,
+        SecretGroup: 3,
+        Keywords:    []string{":", ";", "|", "\t"},
+        CheckGlobalStopWord: false,
+        FragmentFilter: looksLikeComboList,
+        PostProcessor : func(finding *models.Finding) (bool, error) {
+
+            var u1 string
+            var p1 string
+            var d1 string
+
+            groups := iRe.FindStringSubmatch(finding.Match)
+            if len(groups) < 4 {
+                return false, errors.New("Invalid submatch.")
+            }
+
+            u1 = strings.Trim(groups[1], "\r\n ")
+            p1 = strings.Trim(groups[3], "\r\n ")
+
+            // More fields, e.g. /etc/passwd or a CSV row
+            if strings.Contains(p1, groups[2]) {
+                return false, nil
+            }
+
+            if tools.SliceHasStr(comboIgnoredUsers, strings.ToLower(u1)) ||
+                tools.SliceHasStr(comboIgnoredUsers, strings.ToLower(p1)) {
+                return false, nil
+            }
+
+            // Time stamps, counters and similar
+            if len(u1) < 6 && strings.Trim(u1, "0123456789") == "" {
+                return false, nil
+            }
+
+            if strings.Contains(u1, "\\") {
+                e1 := strings.SplitN(u1, "\\", 2)
+                if e1[0] == "" || e1[1] == "" {
+                    return false, errors.New("Invalid domain user.")
+                }
+                d1 = e1[0]
+                u1 = e1[1]
+            }
+
+            cpf := ""
+            if ok, c := tools.ExtractCPF(u1); ok {
+                cpf = c
+            }
+
+            finding.Credential = models.Credential{
+                Time        : time.Now(),
+                UserDomain  : d1,
+                Username    : u1,
+                Password    : p1,
+                Url         : "",
+                UrlDomain   : "",
+                Severity    : 70,
+                Entropy     : finding.Entropy,
+                CPF         : cpf,
+            }
+            return true, nil
+        },
+    }
+
+    return r
+}
+
+// looksLikeComboList computes line shape statistics over the fragment and
+// returns true when most of the lines follow the same user<sep>pass layout
+func looksLikeComboList(raw string) bool {
+    total := 0
+    shapes := make(map[byte]int)
+
+    for _, line := range strings.Split(raw, "\n") {
+        line = strings.Trim(line, " \r")
+        if line == "" {
+            continue
+        }
+        total++
+
+        if len(line) > comboMaxLineSize || strings.Contains(line, " ") {
+            continue
+        }
+
+        // Only lines with exactly two fields count toward a shape
+        for _, sep := range comboSeparators {
+            idx := strings.IndexByte(line, sep)
+            if idx <= 0 || len(line) - idx - 1 < 3 || strings.IndexByte(line[idx + 1:], sep) >= 0 {
+                continue
+            }
+
+            if comboUserRe.MatchString(line[:idx]) {
+                shapes[sep]++
+                break
+            }
+        }
+    }
+
+    if total < comboMinLines {
+        return false
+    }
+
+    dominant := 0
+    for _, cnt := range shapes {
+        if cnt > dominant {
+            dominant = cnt
+        }
+    }
+
+    return float64(dominant) / float64(total) >= comboMinRatio
+}
@@ -0,0 +1,73 @@
+package rules
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestLooksLikeComboList(t *testing.T) {
+    tests := []struct {
+        name string
+        raw  string
+        want bool
+    }{
+        {"combo list", "alice:Secr3t!\nbob:hunter22\ncarol:p4ssw0rd\ndave:letmein1\neve:qwerty123\n", true},
+        {"semicolon combo list", "alice;Secr3t!\nbob;hunter22\ncarol;p4ssw0rd\ndave;letmein1\neve;qwerty123\n", true},
+        {"too few lines", "alice:Secr3t!\nbob:hunter22\n", false},
+        {"passwd", "root:x:0:0:root:/root:/bin/bash\ndaemon:x:1:1:daemon:/usr/sbin:/usr/sbin/nologin\nbin:x:2:2:bin:/bin:/usr/sbin/nologin\nsys:x:3:3:sys:/dev:/usr/sbin/nologin\nsync:x:4:65534:sync:/bin:/bin/sync\nhelvio:x:1000:1000::/home/helvio:/bin/bash\n", false},
+        {"shadow", "root:$6$abc$def:19000:0:99999:7:::\ndaemon:*:19000:0:99999:7:::\nbin:*:19000:0:99999:7:::\nsys:*:19000:0:99999:7:::\nsync:*:19000:0:99999:7:::\n", false},
+        {"csv", "id;nome;cidade;estado\njoao;Silva;Recife;PE\nmaria;Souza;Natal;RN\npedro;Lima;Olinda;PE\nana;Costa;Recife;PE\n", false},
+        {"text", "This is a plain text\nwith some lines: and colons\nbut no credentials at all\nnothing: to see here\nend of file\n", false},
+    }
+
+    for _, tt := range tests {
+        if got := looksLikeComboList(tt.raw); got != tt.want {
+            t.Errorf("%s: looksLikeComboList() = %v, want %v", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestLeak4(t *testing.T) {
+    tests := []struct {
+        name string
+        text string
+        want []string
+    }{
+        {
+            "combo list",
+            "alice:Secr3t!\nbob:hunter22\ncarol:p4ssw0rd\ndave:letmein1\neve:qwerty123\n",
+            []string{"alice:Secr3t!", "bob:hunter22", "carol:p4ssw0rd", "dave:letmein1", "eve:qwerty123"},
+        },
+        {
+            "header row",
+            "login;senha\nalice;Secr3t!\nbob;hunter22\ncarol;p4ssw0rd\ndave;letmein1\neve;qwerty123\n",
+            []string{"alice:Secr3t!", "bob:hunter22", "carol:p4ssw0rd", "dave:letmein1", "eve:qwerty123"},
+        },
+        {
+            "extra fields",
+            "alice:Secr3t!\nbob:hunter22\ncarol:p4ssw0rd\ndave:letmein1\neve:qwerty123\nroot:x:0:0:root:/root:/bin/bash\n",
+            []string{"alice:Secr3t!", "bob:hunter22", "carol:p4ssw0rd", "dave:letmein1", "eve:qwerty123"},
+        },
+        {
+            "passwd",
+            "root:x:0:0:root:/root:/bin/bash\ndaemon:x:1:1:daemon:/usr/sbin:/usr/sbin/nologin\nbin:x:2:2:bin:/bin:/usr/sbin/nologin\nsys:x:3:3:sys:/dev:/usr/sbin/nologin\nsync:x:4:65534:sync:/bin:/bin/sync\nhelvio:x:1000:1000::/home/helvio:/bin/bash\n",
+            []string{},
+        },
+        {
+            "csv",
+            "id;nome;cidade;estado\njoao;Silva;Recife;PE\nmaria;Souza;Natal;RN\npedro;Lima;Olinda;PE\nana;Costa;Recife;PE\n",
+            []string{},
+        },
+    }
+
+    r := Leak4()
+    for _, tt := range tests {
+        got := []string{}
+        for _, f := range detect(r, tt.text) {
+            got = append(got, f.Credential.Username + ":" + f.Credential.Password)
+        }
+        if !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("%s: Leak4() = %q, want %q", tt.name, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,221 @@
+package rules
+
+import (
+    re "regexp"
+    "time"
+    "strings"
+    "errors"
+
+    "github.com/helviojunior/intelparser/internal/tools"
+    "github.com/helviojunior/intelparser/pkg/models"
+)
+
+var cleanDigitsRe = re.MustCompile(`[^0-9]`)
+
+// phoneCountries maps E.164 country codes to the accepted national number sizes
+var phoneCountries = map[string][]int{
+    "1":   {10},          // US/CA
+    "7":   {10},          // RU/KZ
+    "33":  {9},           // FR
+    "34":  {9},           // ES
+    "39":  {9, 10},       // IT
+    "44":  {10},          // UK
+    "49":  {10, 11},      // DE
+    "52":  {10},          // MX
+    "54":  {10},          // AR
+    "55":  {10, 11},      // BR
+    "56":  {9},           // CL
+    "57":  {10},          // CO
+    "61":  {9},           // AU
+    "81":  {9, 10},       // JP
+    "86":  {11},          // CN
+    "91":  {10},          // IN
+    "351": {9},           // PT
+}
+
+// PII returns the optional personal identifiable information ruleset.
+// The findings that could not be validated (e.g. phone numbers of an unknown
+// country code) are discarded, unless keepUnvalidated is set.
+func PII(keepUnvalidated bool) []*Rule {
+    ruleset := []*Rule{
+        PiiCPF(),
+        PiiCNPJ(),
+        PiiCreditCard(),
+        PiiIBAN(),
+        PiiPhone(),
+        PiiSSN(),
+    }
+
+    if !keepUnvalidated {
+        for _, r := range ruleset {
+            postProcessor := r.PostProcessor
+            r.PostProcessor = func(finding *models.Finding) (bool, error) {
+                ok, err := postProcessor(finding)
+                if err != nil || !ok {
+                    return ok, err
+                }
+                return finding.PIIData.Validated, nil
+            }
+        }
+    }
+
+    return ruleset
+}
+
+func PiiCPF() *Rule {
+    return piiRule(&Rule{
+        RuleID:      "PII » CPF",
+        Description: "Extract Brazilian CPF numbers",
+        Regex:       re.MustCompile(`\b(\d{3}\.?\d{3}\.?\d{3}-?\d{2})\b`),
+        FragmentFilter: hasDigitSequence(11),
+    }, "cpf", func(value string) (string, string, bool, error) {
+        if ok, c := tools.ExtractCPF(value); ok {
+            return c, "BR", true, nil
+        }
+        return "", "", false, errors.New("Invalid CPF")
+    })
+}
+
+func PiiCNPJ() *Rule {
+    return piiRule(&Rule{
+        RuleID:      "PII » CNPJ",
+        Description: "Extract Brazilian CNPJ numbers",
+        Regex:       re.MustCompile(`\b(\d{2}\.?\d{3}\.?\d{3}/?\d{4}-?\d{2})\b`),
+        FragmentFilter: hasDigitSequence(14),
+    }, "cnpj", func(value string) (string, string, bool, error) {
+        if ok, c := tools.ExtractCNPJ(value); ok {
+            return c, "BR", true, nil
+        }
+        return "", "", false, errors.New("Invalid CNPJ")
+    })
+}
+
+func PiiCreditCard() *Rule {
+    return piiRule(&Rule{
+        RuleID:      "PII » Credit Card",
+        Description: "Extract credit card numbers",
+        Regex:       re.MustCompile(`\b(\d{4}[ -]?\d{4}[ -]?\d{4}[ -]?\d{1,7})\b`),
+        FragmentFilter: hasDigitSequence(13),
+    }, "credit-card", func(value string) (string, string, bool, error) {
+        number := cleanDigitsRe.ReplaceAllString(value, "")
+        if len(number) < 13 || len(number) > 19 {
+            return "", "", false, errors.New("Invalid card size")
+        }
+
+        if !tools.ValidateLuhn(number) {
+            return "", "", false, errors.New("Invalid Luhn checksum")
+        }
+
+        brand := tools.CardBrand(number)
+        return number, brand, brand != "", nil
+    })
+}
+
+func PiiIBAN() *Rule {
+    return piiRule(&Rule{
+        RuleID:      "PII » IBAN",
+        Description: "Extract IBAN bank account numbers",
+        Regex:       re.MustCompile(`\b([A-Z]{2}\d{2}(?:[ ]?[A-Z0-9]{4}){2,7}(?:[ ]?[A-Z0-9]{1,4})?)\b`),
+    }, "iban", func(value string) (string, string, bool, error) {
+        iban := tools.NormalizeIBAN(value)
+        if !tools.ValidateIBAN(iban) {
+            return "", "", false, errors.New("Invalid IBAN")
+        }
+        return iban, iban[0:2], true, nil
+    })
+}
+
+func PiiPhone() *Rule {
+    return piiRule(&Rule{
+        RuleID:      "PII » Phone",
+        Description: "Extract E.164 phone numbers",
+        Regex:       re.MustCompile(`(\+[1-9]\d{0,2}[ .-]?\(?\d{1,4}\)?(?:[ .-]?\d{2,5}){1,4})\b`),
+        Keywords:    []string{"+"},
+        FragmentFilter: hasDigitSequence(8),
+    }, "phone", func(value string) (string, string, bool, error) {
+        number := cleanDigitsRe.ReplaceAllString(value, "")
+        if len(number) < 8 || len(number) > 15 {
+            return "", "", false, errors.New("Invalid phone size")
+        }
+
+        // Check the country code and the national number size
+        for i := 3; i >= 1; i-- {
+            if sizes, ok := phoneCountries[number[0:i]]; ok {
+                for _, s := range sizes {
+                    if len(number) - i == s {
+                        return "+" + number, number[0:i], true, nil
+                    }
+                }
+            }
+        }
+
+        return "+" + number, "", false, nil
+    })
+}
+
+func PiiSSN() *Rule {
+    return piiRule(&Rule{
+        RuleID:      "PII » SSN",
+        Description: "Extract US Social Security Numbers",
+        Regex:       re.MustCompile(`\b(\d{3}-\d{2}-\d{4})\b`),
+        Keywords:    []string{"-"},
+        FragmentFilter: hasDigitSequence(9),
+    }, "ssn", func(value string) (string, string, bool, error) {
+        if !tools.ValidateSSN(value) {
+            return "", "", false, errors.New("Invalid SSN")
+        }
+        return cleanDigitsRe.ReplaceAllString(value, ""), "US", true, nil
+    })
+}
+
+// hasDigitSequence returns a fragment filter accepting only the fragments with a
+// number of at least min digits. Up to two separators (space . - / ( )) are allowed
+// between the digits, so formatted numbers like +55 (11) 91234-5678 are counted.
+// It is a single pass over the fragment, cheaper than running the rule regex.
+func hasDigitSequence(min int) func(string) bool {
+    return func(raw string) bool {
+        digits, seps := 0, 0
+        for i := 0; i < len(raw); i++ {
+            c := raw[i]
+            switch {
+            case c >= '0' && c <= '9':
+                digits++
+                seps = 0
+                if digits >= min {
+                    return true
+                }
+            case digits > 0 && seps < 2 && strings.IndexByte(" .-/()", c) >= 0:
+                seps++
+            default:
+                digits, seps = 0, 0
+            }
+        }
+        return false
+    }
+}
+
+// piiRule fills the common PII rule fields and post processor.
+// validator receives the matched value and returns the normalized value,
+// a detail (brand, country...) and whether the checksum was validated.
+// Returning an error discards the finding.
+func piiRule(r *Rule, piiType string, validator func(string) (string, string, bool, error)) *Rule {
+    r.CheckGlobalStopWord = false
+    r.PostProcessor = func(finding *models.Finding) (bool, error) {
+        value, detail, validated, err := validator(strings.Trim(finding.Secret, "\r\n\t "))
+        if err != nil {
+            return false, err
+        }
+
+        finding.PIIData = models.PII{
+            Time        : time.Now(),
+            Rule        : finding.RuleID,
+            Type        : piiType,
+            Value       : value,
+            Detail      : detail,
+            Validated   : validated,
+        }
+        return true, nil
+    }
+
+    return r
+}
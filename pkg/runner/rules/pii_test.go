@@ -0,0 +1,116 @@
+package rules
+
+import (
+    "testing"
+
+    "github.com/helviojunior/intelparser/pkg/models"
+)
+
+// piiRuleByID returns the rule of the ruleset with the rule id
+func piiRuleByID(t *testing.T, ruleset []*Rule, ruleID string) *Rule {
+    for _, r := range ruleset {
+        if r.RuleID == ruleID {
+            return r
+        }
+    }
+    t.Fatalf("rule %s not found", ruleID)
+    return nil
+}
+
+// detect runs the rule regex, fragment filter and post processor on the text,
+// returning the accepted findings
+func detect(r *Rule, text string) []models.Finding {
+    findings := []models.Finding{}
+    if r.FragmentFilter != nil && !r.FragmentFilter(text) {
+        return findings
+    }
+
+    for _, m := range r.Regex.FindAllStringSubmatch(text, -1) {
+        finding := models.Finding{RuleID: r.RuleID, Match: m[0], Secret: m[1]}
+        if ok, err := r.PostProcessor(&finding); err == nil && ok {
+            findings = append(findings, finding)
+        }
+    }
+
+    return findings
+}
+
+func TestHasDigitSequence(t *testing.T) {
+    tests := []struct {
+        raw  string
+        min  int
+        want bool
+    }{
+        {"cpf 123.456.789-09", 11, true},
+        {"cnpj 11.222.333/0001-81", 14, true},
+        {"+55 (11) 91234-5678", 12, true},
+        {"card 4111 1111 1111 1111", 16, true},
+        {"version 1.2.3 build 45", 11, false},
+        {"1234 abcd 5678 efgh 9012", 9, false},
+        {"12345678901", 11, true},
+        {"1234567890", 11, false},
+        {"", 1, false},
+    }
+
+    for _, tt := range tests {
+        if got := hasDigitSequence(tt.min)(tt.raw); got != tt.want {
+            t.Errorf("hasDigitSequence(%d)(%q) = %v, want %v", tt.min, tt.raw, got, tt.want)
+        }
+    }
+}
+
+func TestPIIRules(t *testing.T) {
+    tests := []struct {
+        ruleID string
+        text   string
+        want   []string
+    }{
+        {"PII » CPF", "nome: joao cpf: 529.982.247-25", []string{"52998224725"}},
+        {"PII » CPF", "cpf: 529.982.247-26", []string{}},
+        {"PII » CNPJ", "cnpj 11.222.333/0001-81", []string{"11222333000181"}},
+        {"PII » Credit Card", "cc 4111 1111 1111 1111 exp 12/30", []string{"4111111111111111"}},
+        {"PII » Credit Card", "cc 4111 1111 1111 1112", []string{}},
+        {"PII » IBAN", "iban GB82 WEST 1234 5698 7654 32", []string{"GB82WEST12345698765432"}},
+        {"PII » Phone", "tel +55 11 91234-5678", []string{"+5511912345678"}},
+        {"PII » Phone", "tel +999 1234 5678", []string{}},
+        {"PII » SSN", "ssn 536-22-1234", []string{"536221234"}},
+        {"PII » SSN", "ssn 666-22-1234", []string{}},
+    }
+
+    ruleset := PII(false)
+    for _, tt := range tests {
+        r := piiRuleByID(t, ruleset, tt.ruleID)
+        findings := detect(r, tt.text)
+
+        got := []string{}
+        for _, f := range findings {
+            got = append(got, f.PIIData.Value)
+        }
+        if len(got) != len(tt.want) {
+            t.Errorf("%s on %q = %v, want %v", tt.ruleID, tt.text, got, tt.want)
+            continue
+        }
+        for i := range got {
+            if got[i] != tt.want[i] {
+                t.Errorf("%s on %q = %v, want %v", tt.ruleID, tt.text, got, tt.want)
+            }
+        }
+    }
+}
+
+func TestPIIKeepUnvalidated(t *testing.T) {
+    // Unknown country code, only kept when asked
+    text := "tel +999 1234 5678"
+
+    if findings := detect(piiRuleByID(t, PII(false), "PII » Phone"), text); len(findings) != 0 {
+        t.Errorf("unvalidated phone kept by default: %v", findings)
+    }
+
+    findings := detect(piiRuleByID(t, PII(true), "PII » Phone"), text)
+    if len(findings) != 1 {
+        t.Fatalf("unvalidated phone findings = %d, want 1", len(findings))
+    }
+    if findings[0].PIIData.Validated {
+        t.Error("unvalidated phone marked as validated")
+    }
+}
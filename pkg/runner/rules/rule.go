@@ -42,6 +42,12 @@ type Rule struct {
 
     CheckGlobalStopWord bool
 
+    // FragmentFilter, if set, is evaluated once against the whole fragment
+    // before the regex runs. Returning false skips the rule for that fragment.
+    // It allows rules to take decisions based on the content shape instead of
+    // a single line match.
+    FragmentFilter func(string) bool
+
     PostProcessor func(*models.Finding) (bool, error)
 }
 
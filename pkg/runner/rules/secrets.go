@@ -0,0 +1,167 @@
+package rules
+
+import (
+    re "regexp"
+    "time"
+    "strings"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+
+    "github.com/helviojunior/intelparser/pkg/models"
+)
+
+// Secrets returns the optional API keys and cloud secrets ruleset
+func Secrets() []*Rule {
+    return []*Rule{
+        AwsAccessKey(),
+        GitHubToken(),
+        GitLabToken(),
+        SlackToken(),
+        SlackWebhook(),
+        GoogleApiKey(),
+        StripeKey(),
+        PrivateKey(),
+        JWT(),
+        DbConnectionString(),
+    }
+}
+
+func AwsAccessKey() *Rule {
+    return secretRule(&Rule{
+        RuleID:      "Secret » AWS Access Key",
+        Description: "Extract AWS Access Key IDs",
+        Regex:       re.MustCompile(`\b((?:A3T[A-Z0-9]|AKIA|ASIA|ABIA|ACCA)[A-Z0-9]{16})\b`),
+        Entropy:     3,
+        Keywords:    []string{"A3T", "AKIA", "ASIA", "ABIA", "ACCA"},
+    }, "aws-access-key", 100, nil)
+}
+
+func GitHubToken() *Rule {
+    return secretRule(&Rule{
+        RuleID:      "Secret » GitHub Token",
+        Description: "Extract GitHub personal access, OAuth, app and fine-grained tokens",
+        Regex:       re.MustCompile(`\b((?:ghp|gho|ghu|ghs|ghr)_[0-9a-zA-Z]{36}|github_pat_[0-9a-zA-Z_]{82})\b`),
+        Entropy:     3,
+        Keywords:    []string{"ghp_", "gho_", "ghu_", "ghs_", "ghr_", "github_pat_"},
+    }, "github-token", 90, nil)
+}
+
+func GitLabToken() *Rule {
+    return secretRule(&Rule{
+        RuleID:      "Secret » GitLab Token",
+        Description: "Extract GitLab personal access tokens",
+        Regex:       re.MustCompile(`\b(glpat-[0-9a-zA-Z_-]{20})\b`),
+        Entropy:     3,
+        Keywords:    []string{"glpat-"},
+    }, "gitlab-token", 90, nil)
+}
+
+func SlackToken() *Rule {
+    return secretRule(&Rule{
+        RuleID:      "Secret » Slack Token",
+        Description: "Extract Slack bot, user and app tokens",
+        Regex:       re.MustCompile(`\b(xox[baprse]-[0-9a-zA-Z-]{10,250})\b`),
+        Entropy:     3,
+        Keywords:    []string{"xoxb-", "xoxa-", "xoxp-", "xoxr-", "xoxs-", "xoxe-"},
+    }, "slack-token", 90, nil)
+}
+
+func SlackWebhook() *Rule {
+    return secretRule(&Rule{
+        RuleID:      "Secret » Slack Webhook",
+        Description: "Extract Slack incoming webhook URLs",
+        Regex:       re.MustCompile(`((?:https?://)?hooks\.slack\.com/(?:services|workflows|triggers)/[A-Za-z0-9+/]{43,56})`),
+        Keywords:    []string{"hooks.slack.com"},
+    }, "slack-webhook", 80, nil)
+}
+
+func GoogleApiKey() *Rule {
+    return secretRule(&Rule{
+        RuleID:      "Secret » Google API Key",
+        Description: "Extract Google Cloud/Firebase API keys",
+        Regex:       re.MustCompile(`\b(AIza[0-9A-Za-z_-]{35})\b`),
+        Entropy:     3,
+        Keywords:    []string{"AIza"},
+    }, "google-api-key", 80, nil)
+}
+
+func StripeKey() *Rule {
+    return secretRule(&Rule{
+        RuleID:      "Secret » Stripe Key",
+        Description: "Extract Stripe secret and restricted keys",
+        Regex:       re.MustCompile(`\b((?:sk|rk)_(?:test|live|prod)_[a-zA-Z0-9]{10,99})\b`),
+        Entropy:     2,
+        Keywords:    []string{"sk_test", "sk_live", "sk_prod", "rk_test", "rk_live", "rk_prod"},
+    }, "stripe-key", 90, nil)
+}
+
+func PrivateKey() *Rule {
+    return secretRule(&Rule{
+        RuleID:      "Secret » Private Key",
+        Description: "Extract PEM encoded private keys",
+        Regex:       re.MustCompile(`(?i)(-----BEGIN[ A-Z0-9_-]{0,100}PRIVATE KEY(?: BLOCK)?-----[\s\S-]{64,}?-----END[ A-Z0-9_-]{0,100}PRIVATE KEY(?: BLOCK)?-----)`),
+        Keywords:    []string{"-----BEGIN"},
+    }, "private-key", 100, nil)
+}
+
+func JWT() *Rule {
+    return secretRule(&Rule{
+        RuleID:      "Secret » JWT",
+        Description: "Extract JSON Web Tokens",
+        Regex:       re.MustCompile(`\b(eyJ[a-zA-Z0-9_-]{15,}\.eyJ[a-zA-Z0-9/_-]{15,}\.(?:[a-zA-Z0-9/_-]{10,}={0,2})?)`),
+        Entropy:     3,
+        Keywords:    []string{"eyJ"},
+    }, "jwt", 70, func(finding *models.Finding) error {
+        // The header must be a valid JSON object with the signing algorithm
+        b, err := base64.RawURLEncoding.DecodeString(strings.SplitN(finding.Secret, ".", 2)[0])
+        if err != nil {
+            return err
+        }
+
+        var header map[string]interface{}
+        if err := json.Unmarshal(b, &header); err != nil {
+            return err
+        }
+
+        if _, ok := header["alg"]; !ok {
+            return errors.New("JWT header without alg")
+        }
+        return nil
+    })
+}
+
+func DbConnectionString() *Rule {
+    return secretRule(&Rule{
+        RuleID:      "Secret » DB Connection String",
+        Description: "Extract database connection strings with embedded credentials",
+        Regex:       re.MustCompile(`(?i)\b((?:mongodb(?:\+srv)?|postgres(?:ql)?|mysql|mariadb|rediss?|amqps?|mssql|sqlserver|jdbc:[a-z]+)://[^\s:@/'"]{1,64}:[^\s@/'"]{1,128}@[^\s'"<>]{3,256})`),
+        Entropy:     2,
+        Keywords:    []string{"mongodb", "postgres", "mysql", "mariadb", "redis", "amqp", "mssql", "sqlserver", "jdbc:"},
+    }, "db-connection-string", 100, nil)
+}
+
+// secretRule fills the common secret rule fields and post processor.
+// validator is optional and may reject the finding returning an error.
+func secretRule(r *Rule, secretType string, severity int, validator func(*models.Finding) error) *Rule {
+    r.CheckGlobalStopWord = false
+    r.PostProcessor = func(finding *models.Finding) (bool, error) {
+        if validator != nil {
+            if err := validator(finding); err != nil {
+                return false, err
+            }
+        }
+
+        finding.SecretData = models.Secret{
+            Time        : time.Now(),
+            Rule        : finding.RuleID,
+            Type        : secretType,
+            Value       : strings.Trim(finding.Secret, "\r\n\t "),
+            Severity    : severity,
+            Entropy     : finding.Entropy,
+        }
+        return true, nil
+    }
+
+    return r
+}
@@ -2,7 +2,6 @@ package runner
 
 import (
 	"context"
-	"errors"
 	"log/slog"
 	//"net/url"
 	"os"
@@ -83,6 +82,9 @@ type Runner struct {
 
 	// files larger than this will be skipped
 	MaxTargetMegaBytes int
+
+	// compressed segments inflating to more than this will be discarded
+	MaxInflateMegaBytes int
 }
 
 type Status struct {
@@ -91,6 +93,10 @@ type Status struct {
     Url int
     Email int
     Credential int
+    Secret int
+    PII int
+    Wallet int
+    Cookie int
 	Skipped int
 	Spin string
 	Running bool
@@ -108,7 +114,7 @@ func (st *Status) Print() {
         }
 
     	fmt.Fprintf(os.Stderr, 
-            "%s\n %s read: %d, failed: %d, ignored: %d               \n %s cred: %d, url: %d, email: %d\r\033[A\033[A", 
+            "%s\n %s read: %d, failed: %d, ignored: %d               \n %s cred: %d, url: %d, email: %d, secret: %d, pii: %d, wallet: %d, cookie: %d\r\033[A\033[A", 
         	"                                                                        ",
         	ascii.ColoredSpin(st.Spin), 
             st.Parsed, 
@@ -117,12 +123,16 @@ func (st *Status) Print() {
             space,
             st.Credential, 
             st.Url, 
-            st.Email)
+            st.Email,
+            st.Secret,
+            st.PII,
+            st.Wallet,
+            st.Cookie)
     	
     }else{
         st.log.Info("STATUS", 
             "read", st.Parsed, "failed", st.Error, "ignored", st.Skipped, 
-            "creds", st.Credential, "url", st.Url, "email", st.Email)
+            "creds", st.Credential, "url", st.Url, "email", st.Email, "secret", st.Secret, "pii", st.PII, "wallet", st.Wallet, "cookie", st.Cookie)
     }
 } 
 
@@ -143,7 +153,7 @@ func NewRunner(logger *slog.Logger, parser ParserDriver, opts Options, writers [
 		Rules: []*rules.Rule{},
 		Keywords: make(map[string]struct{}),
 	}
-	id.LoadRules()
+	id.LoadRules(opts)
 
 	return &Runner{
 		Parser:     parser,
@@ -157,7 +167,8 @@ func NewRunner(logger *slog.Logger, parser ParserDriver, opts Options, writers [
 		Identifiers: id,
 		prefilter:   *ahocorasick.NewTrieBuilder().AddStrings(maps.Keys(id.Keywords)).Build(),
 		MaxDecodeDepth: 3,
-		MaxTargetMegaBytes: 200,
+		MaxTargetMegaBytes: opts.Parser.MaxTargetMegaBytes,
+		MaxInflateMegaBytes: opts.Parser.MaxInflateMegaBytes,
 		status:     &Status{
 			Parsed: 0,
 			Error: 0,
@@ -170,13 +181,26 @@ func NewRunner(logger *slog.Logger, parser ParserDriver, opts Options, writers [
 	}, nil
 }
 
-func (id *Identifiers) LoadRules() error {
+func (id *Identifiers) LoadRules(opts Options) error {
 	id.Rules = []*rules.Rule{
 		rules.Url(),
 		rules.Email(),
         rules.Leak1(),
         rules.Leak2(),
         rules.Leak3(),
+        rules.Leak4(),
+	}
+
+	if opts.Parser.Secrets {
+		id.Rules = append(id.Rules, rules.Secrets()...)
+	}
+
+	if opts.Parser.PII {
+		id.Rules = append(id.Rules, rules.PII(opts.Parser.PIIUnvalidated)...)
+	}
+
+	if opts.Parser.Crypto {
+		id.Rules = append(id.Rules, rules.Crypto()...)
 	}
 
 	uniqueKeywords := make(map[string]struct{})
@@ -205,11 +229,32 @@ func (run *Runner) runWriters(result *models.File) error {
 	return nil
 }
 
+// runFailureWriters passes a failed or skipped file to the writers
+// keeping the failures (the control database)
+func (run *Runner) runFailureWriters(result *models.File) error {
+	for _, writer := range run.writers {
+		if fw, ok := writer.(writers.FailureWriter); ok {
+			if err := fw.WriteFailure(result); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func (run *Runner) AddSkipped() {
 	run.status.Skipped += 1
 	run.status.Parsed += 1
 }
 
+// AddResult writes an extra result produced by a driver while parsing a file,
+// e.g. the messages of a chat export
+func (run *Runner) AddResult(result *models.File) error {
+	run.status.AddResult(result)
+	return run.runWriters(result)
+}
+
 func (run *Runner) ParsePositionalFile(file FileItem) error {
 	_, err := run.Parser.ParseFile(run, file)
 	return err
@@ -283,10 +328,34 @@ func (run *Runner) Run() Status {
 
 					file, err := run.Parser.ParseFile(run, file_item)
 					if err != nil {
+						if file == nil {
+							file = &models.File{
+								FilePath: file_item.VirtualPath,
+								FileName: file_name,
+								Name: file_item.VirtualPath,
+								Date: time.Now(),
+								IndexedAt: time.Now(),
+							}
+						}
+						file.FilePath = file_item.VirtualPath
 						file.Failed = true
 						file.FailedReason = err.Error()
-						logger.Error("failed to parse file", "err", err)
-						run.status.AddResult(file)
+						file.FailedCode = FailureCode(err)
+						file.SourcePath = file_item.RealPath
+						file.SourceArchive = file_item.Archive
+						file.Parser = file_item.Driver
+
+						if IsSkipped(err) {
+							logger.Debug("file skipped", "code", file.FailedCode, "reason", file.FailedReason)
+							run.AddSkipped()
+						}else{
+							logger.Error("failed to parse file", "code", file.FailedCode, "err", err)
+							run.status.AddResult(file)
+						}
+
+						if err := run.runFailureWriters(file); err != nil {
+							logger.Error("failed to write failure for file", "err", err)
+						}
                         continue
 					}
 
@@ -327,6 +396,54 @@ func (run *Runner) Close() {
     ascii.ShowCursor()
 }
 
+// AddFinding appends the entities of a finding to the file and updates the status counters.
+// Parser drivers extracting structured records use it to add their own findings.
+func (run *Runner) AddFinding(file *models.File, finding models.Finding, t time.Time) {
+    if finding.Credential.Username != "" {
+        run.status.Credential += 1
+        finding.Credential.Time = t
+        finding.Credential.Rule = finding.RuleID
+        file.Credentials = append(file.Credentials, finding.Credential)
+    }
+
+    if finding.Email.Email != "" {
+        run.status.Email += 1
+        finding.Email.Time = t
+        file.Emails = append(file.Emails, finding.Email)
+    }
+
+    if finding.Url.Url != "" {
+        run.status.Url += 1
+        finding.Url.Time = t
+        file.URLs = append(file.URLs, finding.Url)
+    }
+
+    if finding.SecretData.Value != "" {
+        run.status.Secret += 1
+        finding.SecretData.Time = t
+        file.Secrets = append(file.Secrets, finding.SecretData)
+    }
+
+    if finding.PIIData.Value != "" {
+        run.status.PII += 1
+        finding.PIIData.Time = t
+        file.PIIs = append(file.PIIs, finding.PIIData)
+    }
+
+    if finding.WalletData.Value != "" {
+        run.status.Wallet += 1
+        finding.WalletData.Time = t
+        file.Wallets = append(file.Wallets, finding.WalletData)
+    }
+
+    if finding.CookieData.Name != "" {
+        run.status.Cookie += 1
+        finding.CookieData.Time = t
+        finding.CookieData.Rule = finding.RuleID
+        file.Cookies = append(file.Cookies, finding.CookieData)
+    }
+}
+
 // DetectBytes scans the given bytes and returns a list of findings
 func (run *Runner) DetectBytes(content []byte) []models.Finding {
     return run.DetectString(string(content))
@@ -351,8 +468,17 @@ func (run *Runner) Detect(fragment Fragment) []models.Finding {
     encodedSegments := []EncodedSegment{}
     currentDecodeDepth := 0
     decoder := NewDecoder()
+    decoder.MaxInflateBytes = int64(run.MaxInflateMegaBytes) * 1000000
+
+    // The same value may be found encoded at a pass and decoded at the next ones
+    // (e.g. a percent encoded URL), so the findings of the previous passes are
+    // indexed by rule and decoded value and replaced by the decoded finding at
+    // the same location (the encoded segment the earlier finding came from)
+    seen := make(map[string][]int)
 
     for {
+        passFindings := []models.Finding{}
+
         // build keyword map for prefiltering rules
         keywords := make(map[string]bool)
         normalizedRaw := strings.ToLower(currentRaw)
@@ -365,19 +491,37 @@ func (run *Runner) Detect(fragment Fragment) []models.Finding {
             if len(rule.Keywords) == 0 {
                 // if no keywords are associated with the rule always scan the
                 // fragment using the rule
-                findings = append(findings, run.detectRule(fragment, currentRaw, rule, encodedSegments)...)
+                passFindings = append(passFindings, run.detectRule(fragment, currentRaw, rule, encodedSegments)...)
                 continue
             }
 
             // check if keywords are in the fragment
             for _, k := range rule.Keywords {
                 if _, ok := keywords[strings.ToLower(k)]; ok {
-                    findings = append(findings, run.detectRule(fragment, currentRaw, rule, encodedSegments)...)
+                    passFindings = append(passFindings, run.detectRule(fragment, currentRaw, rule, encodedSegments)...)
                     break
                 }
             }
         }
 
+        passKeys := make(map[string][]int)
+    PassLoop:
+        for _, finding := range passFindings {
+            key := finding.RuleID + "\x00" + decoder.decodeAll(finding.Secret, run.MaxDecodeDepth)
+            for i, idx := range seen[key] {
+                if findingsOverlap(findings[idx], finding) {
+                    findings[idx] = finding
+                    seen[key] = append(seen[key][:i], seen[key][i + 1:]...)
+                    continue PassLoop
+                }
+            }
+            passKeys[key] = append(passKeys[key], len(findings))
+            findings = append(findings, finding)
+        }
+        for key, idx := range passKeys {
+            seen[key] = append(seen[key], idx...)
+        }
+
         // increment the depth by 1 as we start our decoding pass
         currentDecodeDepth++
 
@@ -461,7 +605,7 @@ func (run *Runner) DetectFile(file *models.File) error {
         rawLength := fileSize / 1000000
         if rawLength > int64(run.MaxTargetMegaBytes) {
             logger.Debug("Skipping file: exceeds --max-target-megabytes", "size", rawLength)
-            return errors.New("Skipping file: exceeds --max-target-megabytes")
+            return NewSkipError(FailureSizeLimit, "Skipping file: exceeds --max-target-megabytes")
         }
     }
 
@@ -472,22 +616,29 @@ func (run *Runner) DetectFile(file *models.File) error {
         totalLines = 0
         resultMutex sync.Mutex
     )
+
+    // Check the filetype at the start of file. Office and PDF documents
+    // are scanned by their text, other binary files are skipped
+    if head, _ := reader.Peek(chunkSize); len(head) > 0 {
+        if mimetype, err := filetype.Match(head); err != nil {
+            return err
+        } else if mimetype.MIME.Type == "application" {
+            text, err := extractDocumentText(file.FilePath, mimetype.MIME.Value, int64(run.MaxTargetMegaBytes) * 1000000)
+            if err != nil {
+                logger.Debug("Text extraction failed", "mime", mimetype.MIME.Value, "err", err)
+                return NewSkipError(FailureUnsupported, fmt.Sprintf("Cannot parse %s files", mimetype.MIME.Value)) // skip binary files
+            }
+            logger.Debug("Document text extracted", "mime", mimetype.MIME.Value, "size", len(text))
+            reader = bufio.NewReaderSize(strings.NewReader(text), chunkSize)
+        }
+    }
+
     for {
         n, err := reader.Read(buf)
 
         // "Callers should always process the n > 0 bytes returned before considering the error err."
         // https://pkg.go.dev/io#Reader
         if n > 0 {
-            // Only check the filetype at the start of file.
-            if totalLines == 0 {
-                // TODO: could other optimizations be introduced here?
-                if mimetype, err := filetype.Match(buf[:n]); err != nil {
-                    return err
-                } else if mimetype.MIME.Type == "application" {
-                    return errors.New(fmt.Sprintf("Cannot parse %s files", mimetype.MIME.Value)) // skip binary files
-                }
-            }
-
             // Try to split chunks across large areas of whitespace, if possible.
             peekBuf := bytes.NewBuffer(buf[:n])
             if readErr := readUntilSafeBoundary(reader, n, maxPeekSize, peekBuf); readErr != nil {
@@ -512,26 +663,7 @@ func (run *Runner) DetectFile(file *models.File) error {
                 finding.StartLine += (totalLines - linesInChunk) + 1
                 finding.EndLine += (totalLines - linesInChunk) + 1
                 resultMutex.Lock()
-
-                if finding.Credential.Username != "" {
-                    run.status.Credential += 1
-                    finding.Credential.Time = file.Date
-                    finding.Credential.Rule = finding.RuleID
-                    file.Credentials = append(file.Credentials, finding.Credential)
-                }
-
-                if finding.Email.Email != "" {
-                    run.status.Email += 1
-                    finding.Email.Time = file.Date
-                    file.Emails = append(file.Emails, finding.Email)
-                }
-
-                if finding.Url.Url != "" {
-                    run.status.Url += 1
-                    finding.Url.Time = file.Date
-                    file.URLs = append(file.URLs, finding.Url)
-                }
-
+                run.AddFinding(file, finding, file.Date)
                 resultMutex.Unlock()
 
             }
@@ -728,20 +860,11 @@ func (run *Runner) detectRule(fragment Fragment, currentRaw string, r *rules.Rul
 		}
 	}
 
-    // Replace the main encoding chars
-    currentRaw = strings.Replace(currentRaw, "%40", "@", -1)
-    currentRaw = strings.Replace(currentRaw, "%20", " ", -1)
-    currentRaw = strings.Replace(currentRaw, "%22", "\"", -1)
-    currentRaw = strings.Replace(currentRaw, "%27", "'", -1)
-    currentRaw = strings.Replace(currentRaw, "%7b", "{", -1)
-    currentRaw = strings.Replace(currentRaw, "%7d", "}", -1)
-    currentRaw = strings.Replace(currentRaw, "%5b", "[", -1)
-    currentRaw = strings.Replace(currentRaw, "%0a", "\n", -1)
-    currentRaw = strings.Replace(currentRaw, "%0d", "\r", -1)
-    currentRaw = strings.Replace(currentRaw, "%09", "\t", -1)
-    currentRaw = strings.Replace(currentRaw, "<br />", "\n", -1)
-    currentRaw = strings.Replace(currentRaw, "<br/>", "\n", -1)
-    currentRaw = strings.Replace(currentRaw, "<br>", "\n", -1)
+	// check if the fragment content matches the rule expectation
+	if r.FragmentFilter != nil && !r.FragmentFilter(currentRaw) {
+		return findings
+	}
+
 
     
 	// use currentRaw instead of fragment.Raw since this represents the current
@@ -909,7 +1032,19 @@ func (run *Runner) detectRule(fragment Fragment, currentRaw string, r *rules.Rul
             }
         }
 
-        if finding.Credential.Username == "" && finding.Email.Email == "" && finding.Url.Url == "" {
+        if finding.SecretData.Value != "" {
+            finding.SecretData.NearText = nearText
+        }
+
+        if finding.PIIData.Value != "" {
+            finding.PIIData.NearText = nearText
+        }
+
+        if finding.WalletData.Value != "" {
+            finding.WalletData.NearText = nearText
+        }
+
+        if finding.Credential.Username == "" && finding.Email.Email == "" && finding.Url.Url == "" && finding.SecretData.Value == "" && finding.PIIData.Value == "" && finding.WalletData.Value == "" {
             continue
         }
 
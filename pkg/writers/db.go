@@ -18,6 +18,8 @@ type DbWriter struct {
 	conn          *gorm.DB
 	mutex         sync.Mutex
 	ReadOnly      bool
+	// KeepFailures stores the failed and skipped files (control database)
+	KeepFailures  bool
 }
 
 // NewDbWriter initialises a database writer
@@ -37,6 +39,7 @@ func NewDbWriter(uri string, debug bool) (*DbWriter, error) {
 		conn:          c,
 		mutex:         sync.Mutex{},
 		ReadOnly:      false,
+		KeepFailures:  false,
 	}, nil
 }
 
@@ -50,6 +53,13 @@ func (dw *DbWriter) Write(result *models.File) error {
 	dw.mutex.Lock()
 	defer dw.mutex.Unlock()
 
+	if dw.KeepFailures {
+		// The file was parsed, so its previous failures are solved
+		if err := dw.clearFailures(result); err != nil {
+			return err
+		}
+	}
+
 	if dw.ControlOnly {
 		//Save onl
 		r1 := result.Clone()
@@ -59,3 +69,39 @@ func (dw *DbWriter) Write(result *models.File) error {
 
 	return dw.conn.Session(&gorm.Session{CreateBatchSize: 200}).Create(result).Error
 }
+
+// WriteFailure stores a failed or skipped file, without its findings
+func (dw *DbWriter) WriteFailure(result *models.File) error {
+
+	if dw.ReadOnly || !dw.KeepFailures {
+		return nil
+	}
+
+	dw.mutex.Lock()
+	defer dw.mutex.Unlock()
+
+	if err := dw.clearFailures(result); err != nil {
+		return err
+	}
+
+	r1 := result.Clone()
+	r1.Content = ""
+	r1.Failed = true
+	r1.FailedReason = result.FailedReason
+	r1.FailedCode = result.FailedCode
+	r1.SourcePath = result.SourcePath
+	r1.SourceArchive = result.SourceArchive
+	r1.Parser = result.Parser
+
+	return dw.conn.Create(r1).Error
+}
+
+// clearFailures removes the previous failures of the file
+func (dw *DbWriter) clearFailures(result *models.File) error {
+	q := dw.conn.Where("failed = ? AND file_path = ? AND file_name = ?", true, result.FilePath, result.FileName)
+	if result.Fingerprint != "" {
+		q = q.Or("failed = ? AND file_name = ? AND fingerprint = ?", true, result.FileName, result.Fingerprint)
+	}
+
+	return q.Delete(&models.File{}).Error
+}
@@ -144,7 +144,12 @@ func NewElasticWriter(uri string) (*ElasticWriter, error) {
                     "provider_id": {"type": "text"},
                     "bucket": {"type": "text"},
                     "media_type": {"type": "text"},
-                    "content": {"type": "text"}
+                    "content": {"type": "text"},
+                    "xscore": {"type": "long"},
+                    "language": {"type": "keyword"},
+                    "storage_id": {"type": "keyword"},
+                    "relations": {"type": "text"},
+                    "search_term": {"type": "keyword"}
                 }
             }
 		}`)
@@ -172,6 +177,7 @@ func NewElasticWriter(uri string) (*ElasticWriter, error) {
                     "url_domain": {"type": "keyword"},
                     "severity": {"type": "long"},
                     "entropy": {"type": "long"},
+                    "attributes": {"type": "text"},
                     "near_text": {"type": "text"},
                     "bucket": {"type": "text"},
                     "file_id": {"type": "keyword"}
@@ -219,6 +225,118 @@ func NewElasticWriter(uri string) (*ElasticWriter, error) {
                     "fingerprint": {"type": "keyword"},
                     "domain": {"type": "keyword"},
                     "email": {"type": "keyword"},
+                    "attributes": {"type": "text"},
+                    "near_text": {"type": "text"},
+                    "bucket": {"type": "text"},
+                    "file_id": {"type": "keyword"}
+                }
+            }
+		}`)
+	if err != nil {
+	    return nil, err
+	}
+
+	//Secrets Index
+	err = wr.CreateIndex(wr.Index + "_secrets", `{
+		    "settings": {
+                    "number_of_replicas": 1,
+                    "index": {"highlight.max_analyzed_offset": 10000000}
+                },
+
+            "mappings": {
+                "properties": {
+                    "time": {"type": "date"},
+                    "fingerprint": {"type": "keyword"},
+                    "rule": {"type": "keyword"},
+                    "type": {"type": "keyword"},
+                    "value": {"type": "keyword"},
+                    "severity": {"type": "long"},
+                    "entropy": {"type": "long"},
+                    "near_text": {"type": "text"},
+                    "bucket": {"type": "text"},
+                    "file_id": {"type": "keyword"}
+                }
+            }
+		}`)
+	if err != nil {
+	    return nil, err
+	}
+
+	//PII Index
+	err = wr.CreateIndex(wr.Index + "_pii", `{
+		    "settings": {
+                    "number_of_replicas": 1,
+                    "index": {"highlight.max_analyzed_offset": 10000000}
+                },
+
+            "mappings": {
+                "properties": {
+                    "time": {"type": "date"},
+                    "fingerprint": {"type": "keyword"},
+                    "rule": {"type": "keyword"},
+                    "type": {"type": "keyword"},
+                    "value": {"type": "keyword"},
+                    "detail": {"type": "keyword"},
+                    "validated": {"type": "boolean"},
+                    "near_text": {"type": "text"},
+                    "bucket": {"type": "text"},
+                    "file_id": {"type": "keyword"}
+                }
+            }
+		}`)
+	if err != nil {
+	    return nil, err
+	}
+
+	//Wallets Index
+	err = wr.CreateIndex(wr.Index + "_wallets", `{
+		    "settings": {
+                    "number_of_replicas": 1,
+                    "index": {"highlight.max_analyzed_offset": 10000000}
+                },
+
+            "mappings": {
+                "properties": {
+                    "time": {"type": "date"},
+                    "fingerprint": {"type": "keyword"},
+                    "rule": {"type": "keyword"},
+                    "type": {"type": "keyword"},
+                    "value": {"type": "keyword"},
+                    "detail": {"type": "keyword"},
+                    "validated": {"type": "boolean"},
+                    "severity": {"type": "long"},
+                    "near_text": {"type": "text"},
+                    "bucket": {"type": "text"},
+                    "file_id": {"type": "keyword"}
+                }
+            }
+		}`)
+	if err != nil {
+	    return nil, err
+	}
+
+	//Cookies Index
+	err = wr.CreateIndex(wr.Index + "_cookies", `{
+		    "settings": {
+                    "number_of_replicas": 1,
+                    "index": {"highlight.max_analyzed_offset": 10000000}
+                },
+
+            "mappings": {
+                "properties": {
+                    "time": {"type": "date"},
+                    "fingerprint": {"type": "keyword"},
+                    "rule": {"type": "keyword"},
+                    "browser": {"type": "keyword"},
+                    "domain": {"type": "keyword"},
+                    "path": {"type": "keyword"},
+                    "name": {"type": "keyword"},
+                    "value": {"type": "keyword"},
+                    "expires": {"type": "date"},
+                    "secure": {"type": "boolean"},
+                    "http_only": {"type": "boolean"},
+                    "session": {"type": "boolean"},
+                    "severity": {"type": "long"},
                     "near_text": {"type": "text"},
                     "bucket": {"type": "text"},
                     "file_id": {"type": "keyword"}
@@ -236,8 +354,8 @@ func NewElasticWriter(uri string) (*ElasticWriter, error) {
 func (ew *ElasticWriter) Write(result *models.File) error {
 	var err error
 
-    logger.Debugf("Integrating elastic: %d credentials, %d e-mails, %d urls", 
-    	len(result.Credentials),  len(result.Emails), len(result.URLs))
+    logger.Debugf("Integrating elastic: %d credentials, %d e-mails, %d urls, %d secrets, %d pii, %d wallets, %d cookies", 
+    	len(result.Credentials),  len(result.Emails), len(result.URLs), len(result.Secrets), len(result.PIIs), len(result.Wallets), len(result.Cookies))
 
 	docs := make(map[string][]byte)
 	docs_len := 0
@@ -369,10 +487,166 @@ func (ew *ElasticWriter) Write(result *models.File) error {
 		}
 	}
 
+	//Secrets
+	docs = make(map[string][]byte)
+	docs_len = 0
+	for _, sec := range result.Secrets {
+		b_data, err := json.Marshal(sec)
+		if err != nil {
+		    return err
+		}
+
+		cid := sec.CalcHash(result.Fingerprint)
+		b_data, err = ew.MarshalAppend(b_data, map[string]interface{}{
+			"file_id": result.Fingerprint,
+			"bucket": result.Bucket,
+			"fingerprint": cid,
+		})
+		if err != nil {
+		    return err
+		}
+
+		docs[cid] = b_data
+		docs_len += len(b_data)
+
+		if len(docs) >= elkBulkCount || docs_len >= elkBulkMaxSize {
+			err = ew.CreateDocBulk(ew.Index + "_secrets", docs)
+			if err != nil {
+			    return err
+			}
+			docs = make(map[string][]byte)
+			docs_len = 0
+		}
+	}
+	if len(docs) > 0 {
+		err = ew.CreateDocBulk(ew.Index + "_secrets", docs)
+		if err != nil {
+		    return err
+		}
+	}
+
+	//PII
+	docs = make(map[string][]byte)
+	docs_len = 0
+	for _, p := range result.PIIs {
+		b_data, err := json.Marshal(p)
+		if err != nil {
+		    return err
+		}
+
+		cid := p.CalcHash(result.Fingerprint)
+		b_data, err = ew.MarshalAppend(b_data, map[string]interface{}{
+			"file_id": result.Fingerprint,
+			"bucket": result.Bucket,
+			"fingerprint": cid,
+		})
+		if err != nil {
+		    return err
+		}
+
+		docs[cid] = b_data
+		docs_len += len(b_data)
+
+		if len(docs) >= elkBulkCount || docs_len >= elkBulkMaxSize {
+			err = ew.CreateDocBulk(ew.Index + "_pii", docs)
+			if err != nil {
+			    return err
+			}
+			docs = make(map[string][]byte)
+			docs_len = 0
+		}
+	}
+	if len(docs) > 0 {
+		err = ew.CreateDocBulk(ew.Index + "_pii", docs)
+		if err != nil {
+		    return err
+		}
+	}
+
+	//Wallets
+	docs = make(map[string][]byte)
+	docs_len = 0
+	for _, w := range result.Wallets {
+		b_data, err := json.Marshal(w)
+		if err != nil {
+		    return err
+		}
+
+		cid := w.CalcHash(result.Fingerprint)
+		b_data, err = ew.MarshalAppend(b_data, map[string]interface{}{
+			"file_id": result.Fingerprint,
+			"bucket": result.Bucket,
+			"fingerprint": cid,
+		})
+		if err != nil {
+		    return err
+		}
+
+		docs[cid] = b_data
+		docs_len += len(b_data)
+
+		if len(docs) >= elkBulkCount || docs_len >= elkBulkMaxSize {
+			err = ew.CreateDocBulk(ew.Index + "_wallets", docs)
+			if err != nil {
+			    return err
+			}
+			docs = make(map[string][]byte)
+			docs_len = 0
+		}
+	}
+	if len(docs) > 0 {
+		err = ew.CreateDocBulk(ew.Index + "_wallets", docs)
+		if err != nil {
+		    return err
+		}
+	}
+
+	//Cookies
+	docs = make(map[string][]byte)
+	docs_len = 0
+	for _, c := range result.Cookies {
+		b_data, err := json.Marshal(c)
+		if err != nil {
+		    return err
+		}
+
+		cid := c.CalcHash(result.Fingerprint)
+		b_data, err = ew.MarshalAppend(b_data, map[string]interface{}{
+			"file_id": result.Fingerprint,
+			"bucket": result.Bucket,
+			"fingerprint": cid,
+		})
+		if err != nil {
+		    return err
+		}
+
+		docs[cid] = b_data
+		docs_len += len(b_data)
+
+		if len(docs) >= elkBulkCount || docs_len >= elkBulkMaxSize {
+			err = ew.CreateDocBulk(ew.Index + "_cookies", docs)
+			if err != nil {
+			    return err
+			}
+			docs = make(map[string][]byte)
+			docs_len = 0
+		}
+	}
+	if len(docs) > 0 {
+		err = ew.CreateDocBulk(ew.Index + "_cookies", docs)
+		if err != nil {
+		    return err
+		}
+	}
+
     //File
     result.Credentials = []models.Credential{}
     result.Emails = []models.Email{}
     result.URLs = []models.URL{}
+    result.Secrets = []models.Secret{}
+    result.PIIs = []models.PII{}
+    result.Wallets = []models.Wallet{}
+    result.Cookies = []models.Cookie{}
 
 	b_data, err := json.Marshal(*result) //ew.Marshal(*result)
 	if err != nil {
@@ -6,3 +6,8 @@ import "github.com/helviojunior/intelparser/pkg/models"
 type Writer interface {
 	Write(*models.File) error
 }
+
+// FailureWriter is a writer keeping the failed and skipped files
+type FailureWriter interface {
+	WriteFailure(*models.File) error
+}